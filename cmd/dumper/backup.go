@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"dumper/pkg/logger"
+	"dumper/pkg/mongodb"
+	"dumper/pkg/notify"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// backupCommand runs one-time or periodic backups
+func backupCommand() *command {
+	return &command{
+		name:    "backup",
+		summary: "Back up MongoDB to S3, once or periodically",
+		run:     runBackup,
+	}
+}
+
+// runBackup parses the backup flags and runs the backup service
+func runBackup(args []string) {
+	fs := newFlagSet("backup", "Back up MongoDB to S3, once or periodically.")
+	opts := registerCommonFlags(fs)
+	var (
+		interval          = fs.Duration("interval", envDuration("BACKUP_INTERVAL"), "Backup interval (default: one-time run)")
+		cronSpec          = fs.String("cron", os.Getenv("BACKUP_CRON"), "Cron schedule for backups, e.g. \"0 2 * * *\" for 2am daily (exclusive with -interval)")
+		oneTime           = fs.Bool("one-time", false, "Run a single backup and exit")
+		runChecked        = fs.Bool("run-checked", envBool("RUN_CHECKED"), "Check S3, MongoDB and disk space first, then run a single backup only if all critical checks pass")
+		skipPreflight     = fs.Bool("skip-preflight", envBool("SKIP_PREFLIGHT"), "Don't ping MongoDB before each backup to fail fast when it is unreachable or rejects the credentials")
+		checkServer       = fs.Bool("check-server-version", envBool("CHECK_SERVER_VERSION"), "Warn at startup if mongodump does not support the MongoDB server version (reads buildInfo)")
+		heartbeatInterval = fs.Duration("heartbeat-interval", envDuration("HEARTBEAT_INTERVAL"), "Interval for heartbeat logs while running periodically (default: disabled)")
+		progressInterval  = fs.Duration("progress-log-interval", envDuration("PROGRESS_LOG_INTERVAL"), "Log dump and upload progress at most this often, e.g. 1m (default: every 10%)")
+		metricsAddr       = fs.String("metrics-addr", os.Getenv("METRICS_ADDR"), "Serve Prometheus metrics on this address, e.g. :9090 (default: disabled)")
+		healthAddr        = fs.String("health-addr", os.Getenv("HEALTH_ADDR"), "Serve /healthz and /readyz on this address, e.g. :8080 (default: disabled)")
+		readyMaxAgeFlag   = fs.Duration("ready-max-age", envDuration("READY_MAX_AGE"), "/readyz fails if the last successful backup is older than this (default: 2x -interval, or 24h)")
+		staleTempAge      = fs.Duration("stale-temp-age", envDuration("STALE_TEMP_AGE"), "At startup, remove dump directories and archives a crashed backup left in -temp-dir once older than this (default: 24h, negative disables)")
+		statsWindow       = fs.Int("stats-window", envInt("STATS_WINDOW"), "Recent successful backups summarized in the duration stats logged after each scheduled run (default: 20)")
+		ratioDeviation    = fs.Float64("ratio-deviation", envFloat("RATIO_DEVIATION"), "Warn when the compression ratio differs from the -stats-window average by more than this fraction, e.g. 0.3 (default: disabled)")
+		maxFailures       = fs.Int("max-consecutive-failures", envInt("MAX_CONSECUTIVE_FAILURES"), "Exit non-zero after this many scheduled backups fail in a row (default: never)")
+		shutdownGrace     = fs.Duration("shutdown-grace", envDuration("SHUTDOWN_GRACE"), "On SIGTERM, let a running backup finish for up to this long; a second signal aborts it (default: abort immediately)")
+		timeout           = fs.Duration("timeout", envDuration("BACKUP_TIMEOUT"), "Cancel a backup that takes longer than this, e.g. 2h (default: no limit)")
+		slackWebhook      = fs.String("slack-webhook", os.Getenv("SLACK_WEBHOOK_URL"), "Slack incoming webhook URL notified when a backup fails (optional)")
+		notifyOnSuccess   = fs.Bool("notify-on-success", envBool("NOTIFY_ON_SUCCESS"), "Also send notifications for successful backups")
+		webhookURLs       = &stringList{values: envList("WEBHOOK_URL")}
+		minFreeSpace      = envByteSize("MIN_FREE_SPACE")
+		freeSpaceFloor    = envByteSize("FREE_SPACE_FLOOR")
+		// mongodump tuning
+		forceTableScan      = fs.Bool("force-table-scan", envBool("FORCE_TABLE_SCAN"), "Pass --forceTableScan to mongodump (slow, bypasses indexes)")
+		parallelCollections = fs.Int("parallel-collections", envInt("PARALLEL_COLLECTIONS"), "Collections mongodump dumps at once, --numParallelCollections (default: 4)")
+		mongodumpGzip       = fs.Bool("mongodump-gzip", envBool("MONGODUMP_GZIP"), "Let mongodump gzip each file (--gzip) and upload a plain tarball, reducing temp disk usage")
+		useOplog            = fs.Bool("oplog", envBool("USE_OPLOG"), "Dump with --oplog for a point-in-time consistent replica set snapshot (full server only, no -database)")
+		perDatabase         = fs.Bool("per-database", envBool("PER_DATABASE"), "Back up every database (except admin, config and local) as its own archive, in parallel (no -database)")
+		dbConcurrency       = fs.Int("concurrency", envInt("DATABASE_CONCURRENCY"), "Databases backed up at once with -per-database (default: 2)")
+		collections         = &stringList{values: envList("MONGO_COLLECTIONS")}
+		includeCollections  = fs.String("include-collections", os.Getenv("INCLUDE_COLLECTION_REGEX"), "Only dump collections of -database whose name matches this regular expression")
+		query               = fs.String("query", os.Getenv("MONGO_QUERY"), "Only dump documents matching this extended JSON filter (requires -collection)")
+		excludeCollections  = &stringList{values: envList("MONGO_EXCLUDE_COLLECTIONS")}
+		excludePrefixes     = &stringList{values: envList("MONGO_EXCLUDE_PREFIXES")}
+		readPreference      = fs.String("read-preference", os.Getenv("MONGO_READ_PREFERENCE"), "Dump from other replica set members, e.g. secondary or secondaryPreferred (requires a replica set URI, default: primary)")
+		nice                = fs.Int("nice", envInt("MONGODUMP_NICE"), "Nice level for mongodump, -20 to 19 (Linux only, default: unchanged)")
+		uploadDumpLog       = fs.Bool("upload-dump-log", envBool("UPLOAD_DUMP_LOG"), "Upload the mongodump output as a .log object next to the archive")
+		successMarker       = fs.Bool("success-marker", envBool("WRITE_SUCCESS_MARKER"), "Write an empty _SUCCESS object below the backup prefix once the backup is fully uploaded")
+		shortLocalNames     = fs.Bool("short-local-names", envBool("SHORT_LOCAL_NAMES"), "Use short run IDs for local dump directories (avoids path-length limits)")
+		skipIfUnchanged     = fs.String("skip-if-unchanged", os.Getenv("SKIP_IF_UNCHANGED_COLLECTION"), "Skip the backup if this collection is unchanged since the last backup")
+		changeTokenField    = fs.String("change-token-field", os.Getenv("CHANGE_TOKEN_FIELD"), "Field whose max value detects changes for -skip-if-unchanged (default: _id)")
+		storeSymlinks       = fs.Bool("store-symlinks", envBool("STORE_SYMLINKS"), "Store symlinks in the archive as links instead of skipping them")
+		compression         = fs.String("compression", os.Getenv("COMPRESSION"), "Archive compression: zip, gzip, zstd or none (default: zip)")
+		zstdLevel           = fs.Int("zstd-level", envInt("ZSTD_LEVEL"), "zstd level from 1 (fastest) to 4 (best compression) (default: 2)")
+		compressionLevel    = fs.Int("compression-level", envInt("COMPRESSION_LEVEL"), "Deflate level for zip and gzip, 1 (fastest) to 9 (best compression) (default: 6)")
+		compressBufferSize  = fs.Int("compress-buffer-size", envInt("COMPRESS_BUFFER_SIZE"), "Copy and write buffer size in bytes used when creating archives (default: 32768)")
+		compressionWorkers  = fs.Int("compression-workers", envInt("COMPRESSION_WORKERS"), "Files compressed at once into zip archives (default: 1)")
+		retentionAge        = fs.Duration("retention-age", envDuration("RETENTION_AGE"), "Delete backups older than this after each successful backup, and tag archives with created-date and expire-date (default: keep forever)")
+		retentionCount      = fs.Int("retention-count", envInt("RETENTION_COUNT"), "Keep only this many of the newest backups (default: unlimited)")
+		keyLowercase        = fs.Bool("key-lowercase", envBool("KEY_LOWERCASE"), "Lowercase generated S3 keys for providers that treat keys case-insensitively")
+		s3WarmUp            = fs.Bool("s3-warm-up", envBool("S3_WARM_UP"), "Send a HeadBucket request before each upload so connection setup is not timed")
+		verifyChecksum      = fs.Bool("verify-checksum", envBool("VERIFY_CHECKSUM"), "Send Content-MD5 with uploads and compare the stored ETag afterwards")
+		verifyCounts        = fs.Bool("verify-counts", envBool("VERIFY_COUNTS"), "Count documents before the dump and fail if mongodump reports different counts")
+		countTolerance      = fs.Float64("count-tolerance", envFloat("COUNT_TOLERANCE"), "Fraction by which -verify-counts allows counts to differ, for writes during the dump (default: 0.01)")
+		storageClass        = fs.String("storage-class", os.Getenv("S3_STORAGE_CLASS"), "S3 storage class of uploaded backups, e.g. STANDARD_IA or GLACIER (default: provider default)")
+		sse                 = fs.String("sse", os.Getenv("S3_SSE"), "Server-side encryption of uploaded backups: AES256 or aws:kms (default: bucket setting)")
+		sseKMSKey           = fs.String("sse-kms-key", os.Getenv("S3_SSE_KMS_KEY_ID"), "KMS key id for -sse aws:kms")
+		pipelineUploads     = fs.Bool("pipeline-uploads", envBool("PIPELINE_UPLOADS"), "Upload each collection uncompressed as soon as it is dumped instead of zipping the whole dump")
+		streamToS3          = fs.Bool("stream", envBool("STREAM_TO_S3"), "Stream mongodump --archive output straight to S3 without a local temp directory")
+		uploadConcurrency   = fs.Int("upload-concurrency", envInt("UPLOAD_CONCURRENCY"), "Files uploaded at once with -pipeline-uploads (default: 4)")
+		breakerThreshold    = fs.Int("s3-breaker-threshold", envInt("S3_BREAKER_THRESHOLD"), "Stop uploading for a cooldown after this many consecutive S3 upload failures (default: disabled)")
+		breakerCooldown     = fs.Duration("s3-breaker-cooldown", envDuration("S3_BREAKER_COOLDOWN"), "How long the S3 circuit stays open before probing again (default: 5m)")
+		breakerSkipBackup   = fs.Bool("s3-breaker-skip-backup", envBool("S3_BREAKER_SKIP_BACKUP"), "Skip the whole backup, not just the upload, while the S3 circuit is open")
+	)
+	fs.Var(collections, "collection", "Only dump this collection of -database, repeatable (default: all)")
+	fs.Var(excludeCollections, "exclude-collection", "Skip this collection of -database, repeatable")
+	fs.Var(excludePrefixes, "exclude-prefix", "Skip every collection of -database starting with this prefix, e.g. tmp_, repeatable")
+	fs.Var(minFreeSpace, "min-free-space", "Refuse to start a backup with less free space than this in -temp-dir, e.g. 10GB (Linux only, default: no check)")
+	fs.Var(freeSpaceFloor, "free-space-floor", "Abort a running dump and remove its files once free space in -temp-dir drops below this, e.g. 2GB (Linux only, default: not watched)")
+	fs.Var(webhookURLs, "webhook-url", "URL receiving a JSON POST after every backup attempt, repeatable (optional)")
+	_ = fs.Parse(args)
+
+	appLogger := opts.newLogger()
+
+	// Log all parameters (sensitive info redacted)
+	appLogger.Info("Starting MongoDB Dumper", append(opts.logFields(),
+		"interval", *interval,
+		"cron", *cronSpec,
+		"one_time", *oneTime,
+		"stats_window", *statsWindow,
+		"ratio_deviation", *ratioDeviation,
+		"stale_temp_age", *staleTempAge,
+		"min_free_space", uint64(*minFreeSpace),
+		"free_space_floor", uint64(*freeSpaceFloor),
+		"skip_preflight", *skipPreflight,
+		"check_server_version", *checkServer,
+		"run_checked", *runChecked,
+		"heartbeat_interval", *heartbeatInterval,
+		"progress_log_interval", *progressInterval,
+		"max_consecutive_failures", *maxFailures,
+		"timeout", *timeout,
+		"shutdown_grace", *shutdownGrace,
+		"force_table_scan", *forceTableScan,
+		"parallel_collections", *parallelCollections,
+		"mongodump_gzip", *mongodumpGzip,
+		"oplog", *useOplog,
+		"per_database", *perDatabase,
+		"concurrency", *dbConcurrency,
+		"collections", collections.values,
+		"include_collections", *includeCollections,
+		"query", *query,
+		"exclude_collections", excludeCollections.values,
+		"exclude_prefixes", excludePrefixes.values,
+		"read_preference", *readPreference,
+		"nice", *nice,
+		"store_symlinks", *storeSymlinks,
+		"compression", *compression,
+		"zstd_level", *zstdLevel,
+		"compression_level", *compressionLevel,
+		"compress_buffer_size", *compressBufferSize,
+		"compression_workers", *compressionWorkers,
+		"retention_age", *retentionAge,
+		"retention_count", *retentionCount,
+		"key_lowercase", *keyLowercase,
+		"s3_warm_up", *s3WarmUp,
+		"verify_checksum", *verifyChecksum,
+		"verify_counts", *verifyCounts,
+		"count_tolerance", *countTolerance,
+		"storage_class", *storageClass,
+		"sse", *sse,
+		"metrics_addr", *metricsAddr,
+		"health_addr", *healthAddr,
+		"slack_notifications", *slackWebhook != "",
+		"notify_on_success", *notifyOnSuccess,
+		"webhooks", len(webhookURLs.values),
+		"pipeline_uploads", *pipelineUploads,
+		"stream", *streamToS3,
+		"upload_concurrency", *uploadConcurrency,
+		"s3_breaker_threshold", *breakerThreshold,
+		"s3_breaker_cooldown", *breakerCooldown,
+		"s3_breaker_skip_backup", *breakerSkipBackup,
+		"upload_dump_log", *uploadDumpLog,
+		"success_marker", *successMarker,
+		"short_local_names", *shortLocalNames,
+		"skip_if_unchanged", *skipIfUnchanged)...)
+
+	opts.validate(appLogger)
+
+	// A cron schedule replaces the fixed interval
+	var schedule cron.Schedule
+	if *cronSpec != "" {
+		if *interval != 0 {
+			fatal(appLogger, exitConfig, "Use either -interval or -cron, not both", nil)
+		}
+		var err error
+		if schedule, err = cron.ParseStandard(*cronSpec); err != nil {
+			fatal(appLogger, exitConfig, "Invalid cron schedule", err)
+		}
+	}
+
+	// Determine if this is a one-time run (either explicitly set or no schedule specified)
+	isOneTime := *oneTime || *runChecked || (*interval == 0 && schedule == nil)
+	if isOneTime && *interval == 0 && schedule == nil && !*runChecked {
+		appLogger.Info("No interval specified, defaulting to one-time backup")
+	}
+
+	// next returns the first scheduled run after t
+	next := func(t time.Time) time.Time {
+		return t.Add(*interval)
+	}
+	if schedule != nil {
+		next = schedule.Next
+	}
+	// period approximates the time between runs, for the readiness staleness window
+	period := *interval
+	if schedule != nil {
+		first := schedule.Next(time.Now())
+		period = schedule.Next(first).Sub(first)
+	}
+
+	// Create dumper configuration
+	dumperConfig := opts.dumperConfig(appLogger)
+	dumperConfig.ForceTableScan = *forceTableScan
+	dumperConfig.ParallelCollections = *parallelCollections
+	dumperConfig.MongodumpGzip = *mongodumpGzip
+	dumperConfig.UseOplog = *useOplog
+	dumperConfig.PerDatabase = *perDatabase
+	dumperConfig.DatabaseConcurrency = *dbConcurrency
+	dumperConfig.Collections = collections.values
+	dumperConfig.IncludeCollectionRegex = *includeCollections
+	dumperConfig.Query = *query
+	dumperConfig.ExcludeCollections = excludeCollections.values
+	dumperConfig.ExcludeCollectionPrefixes = excludePrefixes.values
+	dumperConfig.ReadPreference = *readPreference
+	dumperConfig.HeartbeatInterval = *heartbeatInterval
+	dumperConfig.StatsWindow = *statsWindow
+	dumperConfig.RatioDeviation = *ratioDeviation
+	dumperConfig.MinFreeSpace = uint64(*minFreeSpace)
+	dumperConfig.FreeSpaceFloor = uint64(*freeSpaceFloor)
+	dumperConfig.MaxConsecutiveFailures = *maxFailures
+	dumperConfig.Timeout = *timeout
+	dumperConfig.SkipPreflight = *skipPreflight
+	dumperConfig.CheckServerVersion = *checkServer
+	dumperConfig.StoreSymlinks = *storeSymlinks
+	dumperConfig.Compression = *compression
+	dumperConfig.ZstdLevel = *zstdLevel
+	dumperConfig.CompressionLevel = *compressionLevel
+	dumperConfig.CompressBufferSize = *compressBufferSize
+	dumperConfig.ProgressLogInterval = *progressInterval
+	dumperConfig.CompressionWorkers = *compressionWorkers
+	dumperConfig.RetentionAge = *retentionAge
+	dumperConfig.RetentionCount = *retentionCount
+	dumperConfig.KeyLowercase = *keyLowercase
+	dumperConfig.S3WarmUp = *s3WarmUp
+	dumperConfig.VerifyChecksum = *verifyChecksum
+	dumperConfig.VerifyCounts = *verifyCounts
+	dumperConfig.CountTolerance = *countTolerance
+	dumperConfig.StorageClass = *storageClass
+	dumperConfig.ServerSideEncryption = *sse
+	dumperConfig.SSEKMSKeyID = *sseKMSKey
+	dumperConfig.PipelineUploads = *pipelineUploads
+	dumperConfig.StreamToS3 = *streamToS3
+	dumperConfig.UploadConcurrency = *uploadConcurrency
+	dumperConfig.S3BreakerThreshold = *breakerThreshold
+	dumperConfig.S3BreakerCooldown = *breakerCooldown
+	dumperConfig.S3BreakerSkipBackup = *breakerSkipBackup
+	dumperConfig.UploadDumpLog = *uploadDumpLog
+	dumperConfig.WriteSuccessMarker = *successMarker
+	dumperConfig.ShortLocalNames = *shortLocalNames
+	dumperConfig.Nice = *nice
+	dumperConfig.SkipIfUnchangedQuery = *skipIfUnchanged != ""
+	dumperConfig.SkipIfUnchangedCollection = *skipIfUnchanged
+	dumperConfig.SkipIfUnchangedField = *changeTokenField
+
+	var metrics *promMetrics
+	if *metricsAddr != "" {
+		metrics = newPromMetrics()
+		dumperConfig.Metrics = metrics
+	}
+
+	// Create MongoDB dumper
+	dumper := newDumper(appLogger, dumperConfig)
+
+	// Set up contexts with cancellation on OS signals: the first stops scheduling, backups in
+	// flight get -shutdown-grace to finish
+	stopping, ctx, cancel := shutdownContexts(appLogger, *shutdownGrace, dumper.InProgress)
+	defer cancel()
+
+	// The metrics server stops with the context on SIGINT/SIGTERM
+	if metrics != nil {
+		serveHTTP(ctx, appLogger, "metrics", *metricsAddr, metrics.handler())
+	}
+
+	// Liveness and readiness probes, also stopped with the context
+	if *healthAddr != "" {
+		serveHTTP(ctx, appLogger, "health", *healthAddr, healthHandler(dumper, readyMaxAge(*readyMaxAgeFlag, period)))
+	}
+
+	// Log the mongodump version, and whether it supports the server with -check-server-version
+	dumper.CheckVersions(ctx)
+
+	// Free the disk space of backups a crash left behind
+	if *staleTempAge >= 0 {
+		age := *staleTempAge
+		if age == 0 {
+			age = mongodb.DefaultStaleTempAge
+		}
+		if removed, err := dumper.CleanupStaleTemp(age); err != nil {
+			appLogger.Warn("Failed to clean up stale temp files", "error", err)
+		} else if len(removed) > 0 {
+			appLogger.Info("Cleaned up stale temp files", "count", len(removed))
+		}
+	}
+
+	// Apply the retention policy, only ever after a successful backup
+	prune := func() {
+		if err := dumper.PruneBackups(ctx, *retentionCount, *retentionAge); err != nil {
+			appLogger.Error("Failed to prune old backups", "error", err)
+		}
+	}
+
+	// Report backup results: Slack for failures (and successes on request), webhooks always
+	var notifiers notify.Multi
+	if *slackWebhook != "" {
+		var slack notify.Notifier = notify.NewSlackNotifier(*slackWebhook)
+		if !*notifyOnSuccess {
+			slack = notify.FailuresOnly(slack)
+		}
+		notifiers = append(notifiers, slack)
+	}
+	for _, url := range webhookURLs.values {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(url))
+	}
+	runDump := func() error {
+		startTime := time.Now()
+		err := dumper.Dump(ctx)
+		if len(notifiers) > 0 {
+			result := dumper.LastBackup()
+			event := notify.BackupEvent{
+				Environment: opts.environment,
+				Database:    opts.database,
+				S3Key:       result.S3Key,
+				SizeBytes:   result.SizeBytes,
+				Err:         err,
+				Time:        time.Now(),
+				Duration:    time.Since(startTime),
+			}
+			// Not tied to ctx, a backup aborted by shutdown should still be reported. Each
+			// notifier has a short HTTP timeout, so a hung endpoint can't stall the loop.
+			if notifyErr := notifiers.Notify(context.Background(), event); notifyErr != nil {
+				appLogger.Warn("Failed to send backup notification", "error", notifyErr)
+			}
+		}
+		return err
+	}
+
+	// Checked runs validate everything up front, then back up once
+	if *runChecked {
+		code := runCheckedBackup(ctx, appLogger, dumper, runDump, prune)
+		_ = appLogger.Sync()
+		os.Exit(code)
+	}
+
+	// If one-time run is requested
+	if isOneTime {
+		appLogger.Info("Running one-time backup")
+		if err := runDump(); err != nil {
+			fatal(appLogger, exitCode(err), "Backup failed", err)
+		}
+		prune()
+		appLogger.Info("One-time backup completed successfully")
+		return
+	}
+
+	// Run periodic backups
+	appLogger.Info("Starting periodic MongoDB backups",
+		"environment", opts.environment,
+		"interval", *interval,
+		"cron", *cronSpec)
+
+	// Track the next scheduled run so the heartbeat can report it
+	var nextRun atomic.Int64
+	scheduled := next(time.Now())
+	nextRun.Store(scheduled.UnixNano())
+	timer := time.NewTimer(time.Until(scheduled))
+	defer timer.Stop()
+
+	if *heartbeatInterval > 0 {
+		go runHeartbeat(ctx, appLogger, *heartbeatInterval, func() time.Time {
+			return time.Unix(0, nextRun.Load())
+		})
+	}
+
+	// Count failures in a row so a daemon that never succeeds exits and gets noticed
+	consecutiveFailures := 0
+	recordResult := func(msg string, err error) {
+		if err == nil {
+			consecutiveFailures = 0
+			logBackupStats(appLogger, dumper.Stats())
+			prune()
+			return
+		}
+		consecutiveFailures++
+		appLogger.Error(msg, "error", err, "consecutive_failures", consecutiveFailures)
+		if *maxFailures > 0 && consecutiveFailures >= *maxFailures {
+			fatal(appLogger, exitCode(err), fmt.Sprintf("Giving up after %d consecutive backup failures", consecutiveFailures), err)
+		}
+	}
+
+	// Perform initial backup immediately, a cron schedule waits for its first run
+	if schedule == nil {
+		appLogger.Info("Running initial backup")
+		recordResult("Initial backup failed", runDump())
+	}
+	appLogger.Info("Next scheduled backup", "next_run", scheduled)
+
+	// Main backup loop
+	for {
+		select {
+		case <-timer.C:
+			appLogger.Info("Starting scheduled backup")
+			recordResult("Scheduled backup failed", runDump())
+			if stopping.Err() != nil {
+				appLogger.Info("Backup service shutting down")
+				return
+			}
+
+			// Runs missed while the backup was running are skipped
+			now := time.Now()
+			for !scheduled.After(now) {
+				scheduled = next(scheduled)
+			}
+			nextRun.Store(scheduled.UnixNano())
+			timer.Reset(time.Until(scheduled))
+			appLogger.Info("Next scheduled backup", "next_run", scheduled)
+		case <-stopping.Done():
+			appLogger.Info("Backup service shutting down")
+			return
+		}
+	}
+}
+
+// runCheckedBackup runs the preflight checks and, if all critical ones pass, a single backup.
+// It logs the check and backup results together and returns the combined exit code. The
+// backup goes through runDump so it is reported like any other, and a successful one
+// applies the retention policy with prune.
+func runCheckedBackup(ctx context.Context, log *logger.Logger, dumper *mongodb.Dumper, runDump func() error, prune func()) int {
+	log.Info("Running preflight checks")
+	results := dumper.RunChecks(ctx)
+
+	var failed []string
+	for _, r := range results {
+		if !r.OK() {
+			failed = append(failed, r.Name)
+		}
+	}
+
+	if !mongodb.CriticalChecksPassed(results) {
+		log.Error("Checked run finished",
+			"checks_run", len(results),
+			"checks_failed", failed,
+			"backup", "not started")
+		return exitChecksFailed
+	}
+
+	err := runDump()
+	if err != nil {
+		log.Error("Checked run finished",
+			"checks_run", len(results),
+			"checks_failed", failed,
+			"backup", "failed",
+			"error", err)
+		return exitCode(err)
+	}
+	prune()
+
+	log.Info("Checked run finished",
+		"checks_run", len(results),
+		"checks_failed", failed,
+		"backup", "succeeded")
+	return 0
+}
+
+// logBackupStats logs the rolling duration and size stats of recent backups, so a backup
+// taking much longer than usual stands out
+func logBackupStats(log *logger.Logger, stats mongodb.BackupStats) {
+	if stats.Count == 0 {
+		return
+	}
+	log.Info("Backup stats",
+		"backups", stats.Count,
+		"min_duration", stats.MinDuration,
+		"median_duration", stats.MedianDuration,
+		"p95_duration", stats.P95Duration,
+		"max_duration", stats.MaxDuration,
+		"avg_size_bytes", stats.AvgSizeBytes,
+		"avg_compression_ratio", stats.AvgCompressionRatio)
+}
+
+// runHeartbeat periodically logs that the service is alive until the context is cancelled,
+// so monitoring can tell an idle scheduler apart from a hung process
+func runHeartbeat(ctx context.Context, log *logger.Logger, interval time.Duration, nextRun func() time.Time) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			next := nextRun()
+			log.Info("Heartbeat",
+				"next_backup", next.Format(time.RFC3339),
+				"next_backup_in", time.Until(next).Round(time.Second))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
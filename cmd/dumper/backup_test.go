@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the dumper itself when a test re-executes the test binary with
+// DUMPER_TEST_MAIN set, so tests can observe fatal exits and their codes
+func TestMain(m *testing.M) {
+	if args := os.Getenv("DUMPER_TEST_MAIN"); args != "" {
+		os.Args = append([]string{"dumper"}, strings.Split(args, " ")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// writeFakeTool writes a shell script standing in for a MongoDB tool into dir
+func writeFakeTool(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runDumper runs the dumper with args in a child process and returns its exit code and output
+func runDumper(t *testing.T, env []string, args ...string) (int, string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), "DUMPER_TEST_MAIN="+strings.Join(args, " "))
+	cmd.Env = append(cmd.Env, env...)
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), string(output)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0, string(output)
+}
+
+func TestMaxConsecutiveFailuresExits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake MongoDB tools are shell scripts")
+	}
+	tools := t.TempDir()
+	mongodump := writeFakeTool(t, tools, "mongodump", `if [ "$1" = "--version" ]; then
+	echo "mongodump version: 100.9.4"
+	exit 0
+fi
+echo "Failed: connection refused" >&2
+exit 1
+`)
+	writeFakeTool(t, tools, "mongorestore", "exit 0\n")
+
+	code, output := runDumper(t, []string{"PATH=" + tools + string(os.PathListSeparator) + os.Getenv("PATH")},
+		"backup",
+		"-mongo-uri=mongodb://localhost:27017",
+		"-mongodump-path="+mongodump,
+		"-provider=filesystem",
+		"-local-dir="+t.TempDir(),
+		"-temp-dir="+t.TempDir(),
+		"-env=test",
+		"-skip-preflight",
+		"-interval=10ms",
+		"-max-consecutive-failures=3",
+		"-log-format=json",
+	)
+
+	if code != exitMongo {
+		t.Errorf("exit code = %d, want %d (MongoDB failure)\n%s", code, exitMongo, output)
+	}
+	if got := strings.Count(output, `"consecutive_failures"`); got != 3 {
+		t.Errorf("logged %d failed backups, want 3\n%s", got, output)
+	}
+	if !strings.Contains(output, "Giving up after 3 consecutive backup failures") {
+		t.Errorf("exit reason not logged\n%s", output)
+	}
+}
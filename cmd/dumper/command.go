@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultCommand runs when the binary is invoked without a subcommand, for backward compatibility
+const defaultCommand = "backup"
+
+// command is a CLI subcommand with its own flag set
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+// commands returns all available subcommands in the order they are listed in the usage
+func commands() []*command {
+	return []*command{
+		backupCommand(),
+		restoreCommand(),
+		listCommand(),
+		pruneCommand(),
+		verifyCommand(),
+		costCommand(),
+	}
+}
+
+// findCommand looks up a subcommand by name
+func findCommand(name string) *command {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// splitCommand separates the subcommand name from its arguments, falling back to the default
+// command when the first argument is a flag or no arguments are given
+func splitCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return defaultCommand, args
+	}
+	return args[0], args[1:]
+}
+
+// newFlagSet creates the flag set for a subcommand with a usage header naming the command
+func newFlagSet(name, summary string) *flag.FlagSet {
+	fs := flag.NewFlagSet("dumper "+name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: dumper %s [flags]\n\n%s\n\nFlags:\n", name, summary)
+		fs.PrintDefaults()
+	}
+	// Registered for help text only, the files are loaded before flags are parsed
+	_ = fs.String("env-file", ".env", "Path to .env file to load environment variables from")
+	_ = fs.String("config", os.Getenv("CONFIG_FILE"), "Path to a YAML configuration file; flags and env vars take precedence")
+	return fs
+}
+
+// printUsage prints the top-level usage listing all subcommands
+func printUsage() {
+	out := os.Stderr
+	fmt.Fprintf(out, "Usage: dumper [command] [flags]\n\n")
+	fmt.Fprintf(out, "Commands:\n")
+	for _, cmd := range commands() {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nRunning dumper without a command runs %q.\n", defaultCommand)
+	fmt.Fprintf(out, "Use \"dumper <command> -h\" for the flags of a command.\n")
+}
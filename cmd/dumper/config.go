@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AppConfig is the YAML configuration file given with -config. Every value maps to the
+// environment variable of the matching flag, so flags > env > config file > defaults.
+type AppConfig struct {
+	Environment string `yaml:"environment"`
+	TempDir     string `yaml:"temp_dir"`
+	LogFormat   string `yaml:"log_format"`
+	LogLevel    string `yaml:"log_level"`
+
+	Mongo struct {
+		URI                string   `yaml:"uri"`
+		Database           string   `yaml:"database"`
+		ReplicaSet         string   `yaml:"replica_set"`
+		AuthSource         string   `yaml:"auth_source"`
+		Username           string   `yaml:"username"`
+		Password           string   `yaml:"password"`
+		AuthMechanism      string   `yaml:"auth_mechanism"`
+		TLSCAFile          string   `yaml:"tls_ca_file"`
+		TLSCertKeyFile     string   `yaml:"tls_cert_key_file"`
+		TLSInsecure        bool     `yaml:"tls_insecure"`
+		Collections        []string `yaml:"collections"`
+		ExcludeCollections []string `yaml:"exclude_collections"`
+		ExcludePrefixes    []string `yaml:"exclude_prefixes"`
+		ReadPreference     string   `yaml:"read_preference"`
+	} `yaml:"mongo"`
+
+	S3 struct {
+		Endpoint     string `yaml:"endpoint"`
+		Region       string `yaml:"region"`
+		Bucket       string `yaml:"bucket"`
+		AccessKey    string `yaml:"access_key"`
+		SecretKey    string `yaml:"secret_key"`
+		StorageClass string `yaml:"storage_class"`
+	} `yaml:"s3"`
+
+	Schedule struct {
+		Interval time.Duration `yaml:"interval"`
+		Cron     string        `yaml:"cron"`
+	} `yaml:"schedule"`
+
+	Compression      string `yaml:"compression"`
+	CompressionLevel int    `yaml:"compression_level"`
+
+	Retention struct {
+		Age   time.Duration `yaml:"age"`
+		Count int           `yaml:"count"`
+	} `yaml:"retention"`
+}
+
+// LoadConfig reads and validates a YAML configuration file. Unknown keys are rejected so
+// typos don't silently fall back to defaults.
+func LoadConfig(path string) (*AppConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := &AppConfig{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// validate checks the values that can be checked without the rest of the configuration
+func (c *AppConfig) validate() error {
+	if c.Schedule.Interval < 0 {
+		return errors.New("schedule.interval cannot be negative")
+	}
+	if c.Schedule.Interval > 0 && c.Schedule.Cron != "" {
+		return errors.New("use either schedule.interval or schedule.cron, not both")
+	}
+	if c.Retention.Age < 0 || c.Retention.Count < 0 {
+		return errors.New("retention age and count cannot be negative")
+	}
+	switch strings.ToLower(c.LogFormat) {
+	case "", "json", "console", "pretty", "compact":
+	default:
+		return fmt.Errorf("unsupported log_format %q, supported: json, console, pretty, compact", c.LogFormat)
+	}
+	if _, ok := parseLogLevel(c.LogLevel); !ok {
+		return fmt.Errorf("unsupported log_level %q, supported: debug, info, warn, error", c.LogLevel)
+	}
+	return nil
+}
+
+// env returns the configured values keyed by environment variable, leaving out unset ones
+func (c *AppConfig) env() map[string]string {
+	values := map[string]string{
+		"ENVIRONMENT":               c.Environment,
+		"TEMP_DIR":                  c.TempDir,
+		"LOG_FORMAT":                c.LogFormat,
+		"LOG_LEVEL":                 c.LogLevel,
+		"MONGO_URI":                 c.Mongo.URI,
+		"MONGO_DATABASE":            c.Mongo.Database,
+		"MONGO_REPLICA_SET":         c.Mongo.ReplicaSet,
+		"MONGO_AUTH_SOURCE":         c.Mongo.AuthSource,
+		"MONGO_USERNAME":            c.Mongo.Username,
+		"MONGO_PASSWORD":            c.Mongo.Password,
+		"MONGO_AUTH_MECHANISM":      c.Mongo.AuthMechanism,
+		"MONGO_TLS_CA_FILE":         c.Mongo.TLSCAFile,
+		"MONGO_TLS_CERT_KEY_FILE":   c.Mongo.TLSCertKeyFile,
+		"MONGO_COLLECTIONS":         strings.Join(c.Mongo.Collections, ","),
+		"MONGO_EXCLUDE_COLLECTIONS": strings.Join(c.Mongo.ExcludeCollections, ","),
+		"MONGO_EXCLUDE_PREFIXES":    strings.Join(c.Mongo.ExcludePrefixes, ","),
+		"MONGO_READ_PREFERENCE":     c.Mongo.ReadPreference,
+		"S3_ENDPOINT":               c.S3.Endpoint,
+		"S3_REGION":                 c.S3.Region,
+		"S3_BUCKET":                 c.S3.Bucket,
+		"S3_ACCESS_KEY":             c.S3.AccessKey,
+		"S3_SECRET_KEY":             c.S3.SecretKey,
+		"S3_STORAGE_CLASS":          c.S3.StorageClass,
+		"BACKUP_CRON":               c.Schedule.Cron,
+		"COMPRESSION":               c.Compression,
+	}
+	if c.Schedule.Interval > 0 {
+		values["BACKUP_INTERVAL"] = c.Schedule.Interval.String()
+	}
+	if c.CompressionLevel != 0 {
+		values["COMPRESSION_LEVEL"] = strconv.Itoa(c.CompressionLevel)
+	}
+	if c.Retention.Age > 0 {
+		values["RETENTION_AGE"] = c.Retention.Age.String()
+	}
+	if c.Retention.Count > 0 {
+		values["RETENTION_COUNT"] = strconv.Itoa(c.Retention.Count)
+	}
+	if c.Mongo.TLSInsecure {
+		values["MONGO_TLS_INSECURE"] = "true"
+	}
+
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// applyEnv sets the environment variables of all configured values that are not already
+// set, so the real environment (and .env) keeps precedence over the file
+func (c *AppConfig) applyEnv() {
+	for name, value := range c.env() {
+		if _, ok := os.LookupEnv(name); !ok {
+			os.Setenv(name, value)
+		}
+	}
+}
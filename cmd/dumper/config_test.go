@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeConfigFile writes a YAML configuration file to a temp directory and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dumper.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigRoundTrip(t *testing.T) {
+	cfg, err := LoadConfig(writeConfigFile(t, `
+environment: production
+log_format: json
+log_level: warn
+mongo:
+  uri: mongodb+srv://cluster0.example.net
+  database: app
+  replica_set: rs0
+  tls_insecure: true
+  collections: [users, orders]
+  exclude_prefixes: [tmp_]
+s3:
+  bucket: backups
+  region: eu-central-1
+schedule:
+  interval: 6h
+compression: zstd
+compression_level: 3
+retention:
+  age: 720h
+  count: 14
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Mongo.URI != "mongodb+srv://cluster0.example.net" || !cfg.Mongo.TLSInsecure ||
+		!reflect.DeepEqual(cfg.Mongo.Collections, []string{"users", "orders"}) {
+		t.Errorf("mongo section = %+v", cfg.Mongo)
+	}
+	if cfg.Schedule.Interval != 6*time.Hour || cfg.Retention.Age != 30*24*time.Hour || cfg.Retention.Count != 14 {
+		t.Errorf("schedule %+v, retention %+v", cfg.Schedule, cfg.Retention)
+	}
+
+	// Writing the loaded configuration back gives the same settings; unset lists come
+	// back empty instead of nil, so compare what the file configures
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadConfig(writeConfigFile(t, string(data)))
+	if err != nil {
+		t.Fatalf("reloading the marshalled config: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(reloaded.env(), cfg.env()) {
+		t.Errorf("round trip changed the config:\n got %v\nwant %v", reloaded.env(), cfg.env())
+	}
+
+	env := cfg.env()
+	for name, want := range map[string]string{
+		"MONGO_COLLECTIONS":  "users,orders",
+		"MONGO_TLS_INSECURE": "true",
+		"BACKUP_INTERVAL":    "6h0m0s",
+		"RETENTION_AGE":      "720h0m0s",
+		"RETENTION_COUNT":    "14",
+		"COMPRESSION_LEVEL":  "3",
+	} {
+		if env[name] != want {
+			t.Errorf("env %s = %q, want %q", name, env[name], want)
+		}
+	}
+	if _, ok := env["MONGO_USERNAME"]; ok {
+		t.Error("unset values are passed on as empty variables")
+	}
+}
+
+func TestLoadConfigRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"mongo:\n  urii: mongodb://localhost\n", "field urii not found"},
+		{"schedule:\n  interval: 1h\n  cron: '0 3 * * *'\n", "not both"},
+		{"schedule:\n  interval: -1h\n", "cannot be negative"},
+		{"retention:\n  count: -1\n", "cannot be negative"},
+		{"log_format: xml\n", "unsupported log_format"},
+		{"log_level: verbose\n", "unsupported log_level"},
+	}
+	for _, tt := range tests {
+		_, err := LoadConfig(writeConfigFile(t, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadConfig(%q) = %v, want an error containing %q", tt.content, err, tt.want)
+		}
+	}
+
+	// An empty file is a valid configuration without values
+	if _, err := LoadConfig(writeConfigFile(t, "")); err != nil {
+		t.Errorf("LoadConfig of an empty file: %v", err)
+	}
+}
+
+func TestConfigFileDoesNotOverrideEnvironment(t *testing.T) {
+	t.Setenv("MONGO_DATABASE", "from-env")
+	os.Unsetenv("S3_BUCKET")
+	t.Cleanup(func() { os.Unsetenv("S3_BUCKET") })
+
+	cfg := &AppConfig{}
+	cfg.Mongo.Database = "from-file"
+	cfg.S3.Bucket = "file-bucket"
+	cfg.applyEnv()
+
+	if got := os.Getenv("MONGO_DATABASE"); got != "from-env" {
+		t.Errorf("MONGO_DATABASE = %q, the environment must take precedence over the file", got)
+	}
+	if got := os.Getenv("S3_BUCKET"); got != "file-bucket" {
+		t.Errorf("S3_BUCKET = %q, want the file value for an unset variable", got)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"dumper/pkg/mongodb"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// costCommand estimates the monthly storage cost of the bucket
+func costCommand() *command {
+	return &command{
+		name:    "cost",
+		summary: "Estimate the monthly storage cost per environment",
+		run:     runCost,
+	}
+}
+
+// runCost parses the cost flags, lists the bucket and prints the estimate
+func runCost(args []string) {
+	fs := newFlagSet("cost", "Estimate the monthly storage cost per environment and storage class from a listing of the whole bucket.")
+	opts := registerCommonFlags(fs)
+	var (
+		storageRates = fs.String("storage-rates", os.Getenv("STORAGE_RATES"), "Monthly USD price per GB by storage class, e.g. STANDARD=0.006,GLACIER=0.004")
+		asJSON       = fs.Bool("json", false, "Print the estimate as JSON for scripting")
+	)
+	fs.Parse(args)
+
+	appLogger := opts.newLogger()
+	opts.validate(appLogger)
+
+	rates, err := parseStorageRates(*storageRates)
+	if err != nil {
+		fatal(appLogger, exitConfig, "Invalid storage rates", err)
+	}
+
+	cfg := opts.dumperConfig(appLogger)
+	cfg.StorageRates = rates
+	dumper := newDumper(appLogger, cfg)
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	estimates, err := dumper.EstimateCost(ctx)
+	if err != nil {
+		fatal(appLogger, exitCode(err), "Failed to estimate storage cost", err)
+	}
+	if err := printCostEstimate(os.Stdout, estimates, *asJSON); err != nil {
+		fatal(appLogger, exitCode(err), "Failed to print storage cost", err)
+	}
+}
+
+// parseStorageRates parses "CLASS=rate,CLASS=rate" into monthly USD prices per GB
+func parseStorageRates(value string) (map[string]float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	rates := map[string]float64{}
+	for _, pair := range strings.Split(value, ",") {
+		class, rate, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || class == "" {
+			return nil, fmt.Errorf("invalid storage rate %q, expected CLASS=rate", pair)
+		}
+		parsed, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage rate for %s: %w", class, err)
+		}
+		rates[strings.ToUpper(class)] = parsed
+	}
+	return rates, nil
+}
+
+// printCostEstimate writes the estimates as a table with per-environment and overall totals, or as JSON
+func printCostEstimate(w io.Writer, estimates []mongodb.CostEstimate, asJSON bool) error {
+	var total float64
+	for _, e := range estimates {
+		total += e.MonthlyCost
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Estimates        []mongodb.CostEstimate `json:"estimates"`
+			TotalMonthlyCost float64                `json:"total_monthly_cost"`
+		}{estimates, total})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENVIRONMENT\tSTORAGE CLASS\tOBJECTS\tSIZE (GB)\tRATE ($/GB)\tMONTHLY COST ($)")
+	for _, e := range estimates {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%.4f\t%.2f\n",
+			e.Environment, e.StorageClass, e.Objects,
+			float64(e.SizeBytes)/1024/1024/1024, e.RatePerGB, e.MonthlyCost)
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t\t\t%.2f\n", total)
+	return tw.Flush()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"dumper/pkg/mongodb"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthHandler serves /healthz, 200 as long as the process serves requests, and /readyz,
+// 200 only if the last backup succeeded within maxAge
+func healthHandler(dumper *mongodb.Dumper, maxAge time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		lastSuccess := dumper.LastSuccess()
+		if lastSuccess.IsZero() {
+			http.Error(w, "no successful backup yet", http.StatusServiceUnavailable)
+			return
+		}
+		if age := time.Since(lastSuccess); age > maxAge {
+			http.Error(w, fmt.Sprintf("last successful backup is %s old (max %s)", age.Round(time.Second), maxAge),
+				http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "ok, last successful backup at %s\n", lastSuccess.UTC().Format(time.RFC3339))
+	})
+	return mux
+}
+
+// readyMaxAge returns the staleness window of /readyz: the configured value, otherwise two
+// backup intervals so one failed backup doesn't flip readiness, or a day for one-time runs
+func readyMaxAge(configured, interval time.Duration) time.Duration {
+	if configured > 0 {
+		return configured
+	}
+	if interval > 0 {
+		return 2 * interval
+	}
+	return 24 * time.Hour
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"dumper/pkg/mongodb"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// listCommand lists the backups stored in S3
+func listCommand() *command {
+	return &command{
+		name:    "list",
+		summary: "List the backups of the environment stored in S3",
+		run:     runList,
+	}
+}
+
+// runList parses the list flags and prints the backups newest first
+func runList(args []string) {
+	fs := newFlagSet("list", "List the backups of the environment stored in S3, newest first.")
+	opts := registerCommonFlags(fs)
+	var (
+		successMarker = fs.Bool("success-marker", envBool("WRITE_SUCCESS_MARKER"), "Only list backups completed with a _SUCCESS marker")
+		asJSON        = fs.Bool("json", false, "Print the backups as JSON for scripting")
+	)
+	fs.Parse(args)
+
+	appLogger := opts.newLogger()
+	opts.validate(appLogger)
+
+	cfg := opts.dumperConfig(appLogger)
+	cfg.WriteSuccessMarker = *successMarker
+	dumper := newDumper(appLogger, cfg)
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	backups, err := dumper.ListBackups(ctx)
+	if err != nil {
+		fatal(appLogger, exitCode(err), "Failed to list backups", err)
+	}
+	if err := printBackups(os.Stdout, backups, *asJSON); err != nil {
+		fatal(appLogger, exitCode(err), "Failed to print backups", err)
+	}
+}
+
+// printBackups writes the backups as a table with human-readable sizes, or as JSON
+func printBackups(w io.Writer, backups []mongodb.BackupInfo, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		// An empty list stays an array for scripts instead of null
+		if backups == nil {
+			backups = []mongodb.BackupInfo{}
+		}
+		return enc.Encode(backups)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tSIZE\tLAST MODIFIED")
+	for _, b := range backups {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", b.Key, formatSize(b.Size), b.LastModified.UTC().Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+// formatSize formats a byte count as KB, MB or GB
+func formatSize(size int64) string {
+	switch {
+	case size < 1024*1024:
+		return fmt.Sprintf("%.2f KB", float64(size)/1024)
+	case size < 1024*1024*1024:
+		return fmt.Sprintf("%.2f MB", float64(size)/1024/1024)
+	default:
+		return fmt.Sprintf("%.2f GB", float64(size)/1024/1024/1024)
+	}
+}
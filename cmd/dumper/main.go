@@ -1,40 +1,42 @@
 package main
 
 import (
-	"context"
 	"dumper/pkg/logger"
 	"dumper/pkg/mongodb"
 	"errors"
-	"flag"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
-	"os/signal"
-	"path/filepath"
+	"strconv"
 	"strings"
-	"syscall"
 	"time"
 )
 
 func main() {
-	var envFile string
-	var appLogger *logger.Logger
-	// Determine log format
-
-	// Create a temporary FlagSet just to parse the env-file flag
-	tempFlags := flag.NewFlagSet("temp", flag.ContinueOnError)
-	tempEnvFile := tempFlags.String("env-file", ".env", "")
-	// Silence errors as we're only interested in the env-file flag
-	tempFlags.SetOutput(io.Discard)
-	_ = tempFlags.Parse(os.Args[1:])
-	envFile = *tempEnvFile
+	// Top-level help lists the subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "help", "-h", "-help", "--help":
+			printUsage()
+			return
+		}
+	}
+
+	// Split off the subcommand, bare invocation runs a backup for backward compatibility
+	name, args := splitCommand(os.Args[1:])
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(exitConfig)
+	}
 
 	// Get a logger for early initialization
 	earlyLogger := logger.New()
 
-	// Load .env file first
-	if envFile != "" {
+	// Load .env file first so its values become flag defaults
+	if envFile := envFileFromArgs(args); envFile != "" {
 		earlyLogger.Info("Loading environment variables from file", "file", envFile)
 		if err := loadEnv(envFile); err != nil {
 			earlyLogger.Warn("Failed to load environment file", "file", envFile, "error", err)
@@ -43,200 +45,318 @@ func main() {
 		}
 	}
 
-	// Reset flags to ensure we start from scratch
-	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
-	// Now parse all command line flags - these will override any env vars
-	var (
-		mongoURI    = flag.String("mongo-uri", os.Getenv("MONGO_URI"), "MongoDB connection string URI")
-		database    = flag.String("database", os.Getenv("MONGO_DATABASE"), "MongoDB database name (optional)")
-		environment = flag.String("env", os.Getenv("ENVIRONMENT"), "Environment (staging or production)")
-		s3Endpoint  = flag.String("s3-endpoint", os.Getenv("S3_ENDPOINT"), "S3 endpoint URL (Backblaze)")
-		s3Region    = flag.String("s3-region", os.Getenv("S3_REGION"), "S3 region")
-		s3Bucket    = flag.String("s3-bucket", os.Getenv("S3_BUCKET"), "S3 bucket name")
-		s3AccessKey = flag.String("s3-access-key", os.Getenv("S3_ACCESS_KEY"), "S3 access key")
-		s3SecretKey = flag.String("s3-secret-key", os.Getenv("S3_SECRET_KEY"), "S3 secret key")
-		tempDir     = flag.String("temp-dir", os.Getenv("TEMP_DIR"), "Temporary directory for backups")
-		interval    = flag.Duration("interval", 0, "Backup interval (default: one-time run)")
-		oneTime     = flag.Bool("one-time", false, "Run a single backup and exit")
-		logFormat   = flag.String("log-format", os.Getenv("LOG_FORMAT"), "Log format: json, console, pretty, compact (default: pretty)")
-		// Re-add env-file flag for help text
-		_ = flag.String("env-file", ".env", "Path to .env file to load environment variables from")
-	)
-	flag.Parse()
-	var logOutputFormat logger.OutputFormat
-	switch strings.ToLower(*logFormat) {
-	case "json":
-		logOutputFormat = logger.FormatJSON
-	case "console":
-		logOutputFormat = logger.FormatConsole
-	case "compact":
-		logOutputFormat = logger.FormatCompact
-	case "pretty", "":
-		logOutputFormat = logger.FormatPretty
-	default:
-		logOutputFormat = logger.FormatPretty
+	// Secrets mounted as files fill in their variables before the config file gets a chance
+	if err := loadSecretFiles(earlyLogger); err != nil {
+		fatal(earlyLogger, exitConfig, "Failed to load secret file", err)
 	}
 
-	// Create logger with good defaults and application info
-	logConfig := logger.Config{
-		Level:         logger.InfoLevel,
-		Format:        logOutputFormat,
-		TimeFormat:    logger.TimeFormatISO8601,
-		Output:        "stdout",
-		Development:   true,
-		AddCallerInfo: true,
-		StackTrace:    true,
-		ServiceName:   "mongodb-dumper",
-		Environment:   *environment,
+	// The config file only fills in variables the environment and .env left unset
+	if configFile := flagFromArgs(args, "config", os.Getenv("CONFIG_FILE")); configFile != "" {
+		earlyLogger.Info("Loading configuration file", "file", configFile)
+		appConfig, err := LoadConfig(configFile)
+		if err != nil {
+			fatal(earlyLogger, exitConfig, "Failed to load configuration file", err)
+		}
+		appConfig.applyEnv()
 	}
 
-	appLogger = logger.NewWithConfig(logConfig)
-
-	// Log all parameters (sensitive info redacted)
-	appLogger.Info("Starting MongoDB Dumper",
-		"mongo_uri", redactURI(*mongoURI),
-		"database", *database,
-		"environment", *environment,
-		"s3_endpoint", *s3Endpoint,
-		"s3_region", *s3Region,
-		"s3_bucket", *s3Bucket,
-		"s3_access_key", redactKey(*s3AccessKey),
-		"temp_dir", *tempDir,
-		"interval", *interval,
-		"one_time", *oneTime)
-
-	// Validate required parameters
-	if *mongoURI == "" {
-		appLogger.Fatal("MongoDB URI is required", nil)
+	cmd.run(args)
+}
+
+// Exit codes, so automation can tell why the dumper failed
+const (
+	exitFailure      = 1 // Any failure without a more specific code
+	exitConfig       = 2 // Invalid flags, environment or configuration file
+	exitChecksFailed = 3 // Critical preflight checks of -run-checked failed
+	exitMongo        = 4 // MongoDB, mongodump or mongorestore failed
+	exitCompression  = 5 // Creating or extracting the archive failed
+	exitStorage      = 6 // Uploading to or downloading from the object store failed
+)
+
+// exitCode returns the exit code of an error's category
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, mongodb.ErrConfig):
+		return exitConfig
+	case errors.Is(err, mongodb.ErrMongo):
+		return exitMongo
+	case errors.Is(err, mongodb.ErrCompression):
+		return exitCompression
+	case errors.Is(err, mongodb.ErrStorage):
+		return exitStorage
+	default:
+		return exitFailure
 	}
-	if *s3Endpoint == "" || *s3Bucket == "" || *s3AccessKey == "" || *s3SecretKey == "" {
-		appLogger.Fatal("S3 configuration is incomplete", nil)
+}
+
+// fatal logs msg with err, if any, and exits with code
+func fatal(log *logger.Logger, code int, msg string, err error) {
+	if err != nil {
+		log.Error(msg, "error", err)
+	} else {
+		log.Error(msg)
 	}
-	// Make environment optional by removing the required check
-	// Only validate if a value is provided
-	if *environment != "" && *environment != "staging" && *environment != "production" {
-		appLogger.Warn("Environment should be 'staging' or 'production', using provided value anyway",
-			"environment", *environment)
+	_ = log.Sync()
+	os.Exit(code)
+}
+
+// envFileFromArgs returns the value of the -env-file flag without parsing the other flags
+func envFileFromArgs(args []string) string {
+	return flagFromArgs(args, "env-file", ".env")
+}
+
+// flagFromArgs returns the value of a string flag without parsing the other flags, or
+// defaultValue if it is not given
+func flagFromArgs(args []string, name, defaultValue string) string {
+	value := defaultValue
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimLeft(args[i], "-")
+		if len(arg) == len(args[i]) {
+			continue
+		}
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			value = v
+		} else if arg == name && i+1 < len(args) {
+			value = args[i+1]
+			i++
+		}
 	}
+	return value
+}
 
-	// Set default temp directory if not provided
-	if *tempDir == "" {
-		*tempDir = filepath.Join(os.TempDir(), "mongodb-dumps")
-		appLogger.Info("No temporary directory specified, using default", "tempDir", *tempDir)
+// loadEnv loads environment variables from a .env file
+func loadEnv(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
 	}
 
-	// Ensure temp directory exists
-	if err := os.MkdirAll(*tempDir, 0755); err != nil {
-		appLogger.Warn("Failed to create temporary directory", "tempDir", *tempDir, "error", err)
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := parseEnvLine(line); ok {
+			os.Setenv(key, value)
+		}
 	}
 
-	// Determine if this is a one-time run (either explicitly set or no interval specified)
-	isOneTime := *oneTime || *interval == 0
-	if isOneTime && *interval == 0 {
-		appLogger.Info("No interval specified, defaulting to one-time backup")
+	return nil
+}
+
+// secretEnvVars are the variables that can instead be read from the file named by the same
+// variable with a _FILE suffix, e.g. S3_SECRET_KEY_FILE=/run/secrets/s3_secret_key
+var secretEnvVars = []string{
+	"MONGO_URI",
+	"MONGO_PASSWORD",
+	"RESTORE_TARGET_URI",
+	"S3_ACCESS_KEY",
+	"S3_SECRET_KEY",
+	"GCS_HMAC_SECRET",
+	"AZURE_STORAGE_KEY",
+	"SLACK_WEBHOOK_URL",
+	"WEBHOOK_URL",
+}
+
+// loadSecretFiles sets each secret variable from the file its _FILE variable points to, as
+// Docker and Kubernetes mount secrets, so the value doesn't have to be passed in the container's
+// environment. Trailing newlines are trimmed. A variable that is set directly
+// (including by .env) takes precedence over its file.
+func loadSecretFiles(log *logger.Logger) error {
+	for _, name := range secretEnvVars {
+		path := os.Getenv(name + "_FILE")
+		if path == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			log.Warn("Ignoring secret file, the variable is set directly",
+				"variable", name,
+				"file", path)
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		os.Setenv(name, strings.TrimRight(string(data), "\r\n"))
+		log.Info("Loaded secret from file", "variable", name, "file", path)
 	}
+	return nil
+}
 
-	// Create dumper configuration
-	dumperConfig := mongodb.DumperConfig{
-		MongoURI:    *mongoURI,
-		Database:    *database,
-		Environment: *environment,
-		S3Endpoint:  *s3Endpoint,
-		S3Region:    *s3Region,
-		S3Bucket:    *s3Bucket,
-		S3AccessKey: *s3AccessKey,
-		S3SecretKey: *s3SecretKey,
-		TempDir:     *tempDir,
-		Logger:      appLogger.GetZapLogger(), // Get the underlying zap logger
+// parseEnvLine parses a single KEY=value line of a .env file. It accepts an optional
+// export prefix, single quoted values (taken literally), double quoted values (with \n,
+// \t, \" and \\ escapes) and unquoted values, which end at a " #" inline comment.
+// Everything after the first equals sign belongs to the value.
+func parseEnvLine(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
 	}
+	line = strings.TrimPrefix(line, "export ")
 
-	// Create MongoDB dumper
-	dumper, err := mongodb.NewDumper(dumperConfig)
-	if err != nil {
-		if errors.Is(err, mongodb.ErrMongoDumpNotFound) {
-			appLogger.Fatal("MongoDB tools not found", err)
-			appLogger.Info("Help: Please install MongoDB Database Tools: brew install mongodb/brew/mongodb-database-tools")
-		} else {
-			appLogger.Fatal("Failed to create MongoDB dumper", err)
+	key, value, ok = strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", false
+	}
+	value = strings.TrimSpace(value)
+
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if unquoted, ok := unquoteEnvValue(value); ok {
+			return key, unquoted, true
 		}
 	}
 
-	// Set up context with cancellation
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Handle OS signals for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		sig := <-sigChan
-		appLogger.Info("Received signal, shutting down", "signal", sig.String())
-		cancel()
-	}()
-
-	// If one-time run is requested
-	if isOneTime {
-		appLogger.Info("Running one-time backup")
-		if err := dumper.Dump(ctx); err != nil {
-			appLogger.Fatal("Backup failed", err)
+	// An unquoted value ends at an inline comment, which must follow whitespace so
+	// values like URL fragments keep their #
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	} else if i := strings.Index(value, "\t#"); i >= 0 {
+		value = value[:i]
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// unquoteEnvValue returns the content of a quoted value, ignoring anything after the
+// closing quote. It returns false if the quote is never closed.
+func unquoteEnvValue(value string) (string, bool) {
+	quote := value[0]
+	var b strings.Builder
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == quote:
+			return b.String(), true
+		case c == '\\' && quote == '"' && i+1 < len(value):
+			i++
+			switch value[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(value[i])
+			}
+		default:
+			b.WriteByte(c)
 		}
-		appLogger.Info("One-time backup completed successfully")
-		return
 	}
+	return "", false
+}
 
-	// Run periodic backups
-	appLogger.Info("Starting periodic MongoDB backups",
-		"environment", *environment,
-		"interval", *interval)
+// envBool reads a boolean environment variable, treating unset or invalid values as false
+func envBool(name string) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+	return err == nil && value
+}
+
+// envInt reads an integer environment variable, treating unset or invalid values as zero
+func envInt(name string) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+	return value
+}
 
-	ticker := time.NewTicker(*interval)
-	defer ticker.Stop()
+// envFloat reads a float environment variable, treating unset or invalid values as zero
+func envFloat(name string) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
 
-	// Perform initial backup immediately
-	appLogger.Info("Running initial backup")
-	if err := dumper.Dump(ctx); err != nil {
-		appLogger.Error("Initial backup failed", "error", err)
+// envDuration reads a duration environment variable, treating unset or invalid values as zero
+func envDuration(name string) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return 0
 	}
+	return value
+}
 
-	// Main backup loop
-	for {
-		select {
-		case <-ticker.C:
-			appLogger.Info("Starting scheduled backup")
-			if err := dumper.Dump(ctx); err != nil {
-				appLogger.Error("Scheduled backup failed", "error", err)
-			}
-		case <-ctx.Done():
-			appLogger.Info("Backup service shutting down")
-			return
+// envList reads a comma-separated environment variable, skipping empty entries
+func envList(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
 		}
 	}
+	return values
 }
 
-// loadEnv loads environment variables from a .env file
-func loadEnv(filename string) error {
-	data, err := os.ReadFile(filename)
+// byteSize is a size flag accepting a number of bytes with an optional KB, MB, GB or TB
+// suffix, in binary units like the logged sizes (1GB = 1024MB)
+type byteSize uint64
+
+// envByteSize reads a size environment variable, treating unset or invalid values as zero
+func envByteSize(name string) *byteSize {
+	size, _ := parseByteSize(os.Getenv(name))
+	return &size
+}
+
+// String returns the size in bytes
+func (s *byteSize) String() string {
+	return strconv.FormatUint(uint64(*s), 10)
+}
+
+// Set parses a size such as 512MB or 10GB
+func (s *byteSize) Set(value string) error {
+	size, err := parseByteSize(value)
 	if err != nil {
 		return err
 	}
+	*s = size
+	return nil
+}
 
-	lines := string(data)
-	for _, line := range strings.Split(lines, "\n") {
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+// parseByteSize parses a size such as 512MB, 1.5GB or a plain number of bytes
+func parseByteSize(value string) (byteSize, error) {
+	number := strings.ToUpper(strings.TrimSpace(value))
+	units := []struct {
+		suffix string
+		factor float64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	factor := 1.0
+	for _, unit := range units {
+		if trimmed, ok := strings.CutSuffix(number, unit.suffix); ok {
+			number, factor = strings.TrimSpace(trimmed), unit.factor
+			break
 		}
+	}
 
-		// Split by first equals sign
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			os.Setenv(key, value)
-		}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, use e.g. 512MB or 10GB", value)
 	}
+	return byteSize(n * factor), nil
+}
+
+// stringList is a repeatable string flag. Defaults (e.g. from the environment) are replaced,
+// not extended, by the first value given on the command line.
+type stringList struct {
+	values []string
+	set    bool
+}
 
+// String returns the values comma-separated
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(l.values, ",")
+}
+
+// Set adds a value from the command line
+func (l *stringList) Set(value string) error {
+	if !l.set {
+		l.values = nil
+		l.set = true
+	}
+	l.values = append(l.values, value)
 	return nil
 }
 
@@ -245,13 +365,9 @@ func getDefaultLogger() *logger.Logger {
 	return logger.New()
 }
 
-// redactURI redacts sensitive information from URIs
+// redactURI masks the password and secret options of a connection string for logging
 func redactURI(uri string) string {
-	// Simple redaction - in a real system you'd want to parse the URI properly
-	if uri == "" {
-		return ""
-	}
-	return "[REDACTED_URI]"
+	return mongodb.RedactMongoURI(uri)
 }
 
 // redactKey redacts sensitive keys
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"dumper/pkg/logger"
+	"dumper/pkg/mongodb"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		key   string
+		value string
+		ok    bool
+	}{
+		{line: "", ok: false},
+		{line: "   ", ok: false},
+		{line: "# MONGO_URI=mongodb://localhost", ok: false},
+		{line: "=value", ok: false},
+		{line: "NO_EQUALS_SIGN", ok: false},
+		{line: "MONGO_DATABASE=app", key: "MONGO_DATABASE", value: "app", ok: true},
+		{line: "  S3_REGION = eu-central-1  ", key: "S3_REGION", value: "eu-central-1", ok: true},
+		{line: "export S3_BUCKET=backups", key: "S3_BUCKET", value: "backups", ok: true},
+		{line: "EMPTY=", key: "EMPTY", value: "", ok: true},
+		// Everything after the first equals sign is the value
+		{line: "MONGO_URI=mongodb://localhost/?w=majority", key: "MONGO_URI", value: "mongodb://localhost/?w=majority", ok: true},
+		{line: "S3_BUCKET=backups # nightly", key: "S3_BUCKET", value: "backups", ok: true},
+		{line: "S3_BUCKET=backups\t# nightly", key: "S3_BUCKET", value: "backups", ok: true},
+		{line: "WEBHOOK_URL=https://example.com/hook#frag", key: "WEBHOOK_URL", value: "https://example.com/hook#frag", ok: true},
+		{line: `MONGO_PASSWORD='p@ss # not a comment'`, key: "MONGO_PASSWORD", value: "p@ss # not a comment", ok: true},
+		{line: `MONGO_PASSWORD='no\nescapes'`, key: "MONGO_PASSWORD", value: `no\nescapes`, ok: true},
+		{line: `NOTE="line one\nline two\t\"quoted\" \\ end"`, key: "NOTE", value: "line one\nline two\t\"quoted\" \\ end", ok: true},
+		{line: `S3_BUCKET="backups" # nightly`, key: "S3_BUCKET", value: "backups", ok: true},
+		{line: `EMPTY=""`, key: "EMPTY", value: "", ok: true},
+		// An unclosed quote is kept as part of an unquoted value
+		{line: `S3_BUCKET="backups`, key: "S3_BUCKET", value: `"backups`, ok: true},
+	}
+	for _, tt := range tests {
+		key, value, ok := parseEnvLine(tt.line)
+		if ok != tt.ok || key != tt.key || value != tt.value {
+			t.Errorf("parseEnvLine(%q) = %q, %q, %v, want %q, %q, %v", tt.line, key, value, ok, tt.key, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Only from the file, with the trailing newline of editors and echo trimmed
+	t.Setenv("S3_SECRET_KEY_FILE", writeSecret("s3_secret_key", "file-secret\r\n"))
+	unsetEnv(t, "S3_SECRET_KEY")
+	// Set directly as well, here by .env: the variable wins, even when empty
+	t.Setenv("MONGO_PASSWORD_FILE", writeSecret("mongo_password", "file-password\n"))
+	unsetEnv(t, "MONGO_PASSWORD")
+	if err := loadEnv(writeSecret(".env", "MONGO_PASSWORD=env-password\n")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("S3_ACCESS_KEY_FILE", writeSecret("s3_access_key", "file-access-key"))
+	t.Setenv("S3_ACCESS_KEY", "")
+
+	if err := loadSecretFiles(logger.NewTestLogger()); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"S3_SECRET_KEY":  "file-secret",
+		"MONGO_PASSWORD": "env-password",
+		"S3_ACCESS_KEY":  "",
+	} {
+		if got := os.Getenv(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	// A missing file is an error naming the variable
+	t.Setenv("WEBHOOK_URL_FILE", filepath.Join(dir, "missing"))
+	unsetEnv(t, "WEBHOOK_URL")
+	err := loadSecretFiles(logger.NewTestLogger())
+	if err == nil || !strings.Contains(err.Error(), "WEBHOOK_URL_FILE") {
+		t.Errorf("loadSecretFiles with a missing file = %v, want an error naming WEBHOOK_URL_FILE", err)
+	}
+}
+
+// unsetEnv unsets an environment variable for the duration of a test
+func unsetEnv(t *testing.T, name string) {
+	t.Helper()
+	t.Setenv(name, "") // Restores the previous value after the test
+	os.Unsetenv(name)
+}
+
+func TestExitCode(t *testing.T) {
+	cause := errors.New("boom")
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{cause, exitFailure},
+		{&mongodb.DumpError{Err: cause}, exitMongo},
+		{&mongodb.UploadError{Key: "backup.zip", Err: cause}, exitStorage},
+		{&mongodb.CompressionError{Phase: "compress", Path: "backup.zip", Err: cause}, exitCompression},
+		{fmt.Errorf("restore: %w", &mongodb.UploadError{Key: "backup.zip", Err: cause}), exitStorage},
+		{fmt.Errorf("%w: %w", mongodb.ErrConfig, cause), exitConfig},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// promMetrics exports backup outcomes as Prometheus metrics
+type promMetrics struct {
+	registry    *prometheus.Registry
+	lastSuccess prometheus.Gauge
+	duration    prometheus.Gauge
+	size        prometheus.Gauge
+	failures    prometheus.Counter
+	inProgress  prometheus.Gauge
+}
+
+// newPromMetrics creates the backup metrics on their own registry
+func newPromMetrics() *promMetrics {
+	m := &promMetrics{
+		registry: prometheus.NewRegistry(),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "backup_last_success_timestamp",
+			Help: "Unix time of the last successful backup.",
+		}),
+		duration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "backup_duration_seconds",
+			Help: "Duration of the last backup, successful or not.",
+		}),
+		size: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "backup_size_bytes",
+			Help: "Bytes uploaded by the last backup that uploaded anything.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "backup_failures_total",
+			Help: "Number of failed backups.",
+		}),
+		inProgress: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "backup_in_progress",
+			Help: "1 while a backup is running, 0 otherwise.",
+		}),
+	}
+	m.registry.MustRegister(m.lastSuccess, m.duration, m.size, m.failures, m.inProgress)
+	return m
+}
+
+// BackupStarted marks a backup as running
+func (m *promMetrics) BackupStarted() {
+	m.inProgress.Set(1)
+}
+
+// BackupSucceeded records a successful backup
+func (m *promMetrics) BackupSucceeded(duration time.Duration, sizeBytes int64) {
+	m.inProgress.Set(0)
+	m.duration.Set(duration.Seconds())
+	m.lastSuccess.SetToCurrentTime()
+	// Skipped backups upload nothing, keep the size of the last real one
+	if sizeBytes > 0 {
+		m.size.Set(float64(sizeBytes))
+	}
+}
+
+// BackupFailed records a failed backup
+func (m *promMetrics) BackupFailed(duration time.Duration) {
+	m.inProgress.Set(0)
+	m.duration.Set(duration.Seconds())
+	m.failures.Inc()
+}
+
+// handler serves the backup metrics in the Prometheus text format
+func (m *promMetrics) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	return mux
+}
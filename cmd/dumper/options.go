@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"dumper/pkg/logger"
+	"dumper/pkg/mongodb"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// commonOptions holds the connection, storage and logging flags shared by all subcommands
+type commonOptions struct {
+	mongoURI    string
+	database    string
+	environment string
+	replicaSet  string
+	authSource  string
+
+	username      string
+	password      string
+	authMechanism string
+
+	tlsCAFile      string
+	tlsCertKeyFile string
+	tlsInsecure    bool
+
+	s3Endpoint    string
+	s3Region      string
+	s3Bucket      string
+	s3AccessKey   string
+	s3SecretKey   string
+	s3MaxAttempts int
+	keyTemplate   string
+
+	defaultCredentials bool
+	awsProfile         string
+
+	provider        string
+	gcsBucket       string
+	gcsHMACAccessID string
+	gcsHMACSecret   string
+	azureAccount    string
+	azureAccountKey string
+	azureContainer  string
+	azureEndpoint   string
+	localDir        string
+
+	maxRetries     int
+	retryBaseDelay time.Duration
+
+	connectTimeout         time.Duration
+	socketTimeout          time.Duration
+	serverSelectionTimeout time.Duration
+
+	releaseSHA     string
+	releaseVersion string
+
+	tempDir          string
+	tempDirMode      string
+	dirMode          os.FileMode // tempDirMode parsed by validate
+	mongodumpPath    string
+	logFormat        string
+	logLevel         string
+	logCompactFields string
+}
+
+// registerCommonFlags registers the shared flags on a subcommand's flag set.
+// Defaults come from the environment, so flags override any env vars.
+func registerCommonFlags(fs *flag.FlagSet) *commonOptions {
+	o := &commonOptions{}
+	fs.StringVar(&o.mongoURI, "mongo-uri", os.Getenv("MONGO_URI"), "MongoDB connection string URI")
+	fs.StringVar(&o.database, "database", os.Getenv("MONGO_DATABASE"), "MongoDB database name (optional)")
+	fs.StringVar(&o.replicaSet, "replica-set", os.Getenv("MONGO_REPLICA_SET"), "Replica set name added to the MongoDB URI (optional)")
+	fs.StringVar(&o.authSource, "auth-source", os.Getenv("MONGO_AUTH_SOURCE"), "Authentication database, added to the MongoDB URI or passed with -username (optional)")
+	fs.StringVar(&o.username, "username", os.Getenv("MONGO_USERNAME"), "MongoDB user, passed to mongodump as --username instead of in the URI (optional)")
+	fs.StringVar(&o.password, "password", os.Getenv("MONGO_PASSWORD"), "MongoDB password for -username (optional)")
+	fs.StringVar(&o.authMechanism, "auth-mechanism", os.Getenv("MONGO_AUTH_MECHANISM"), "MongoDB authentication mechanism, e.g. SCRAM-SHA-256 (default: negotiated)")
+	fs.StringVar(&o.tlsCAFile, "tls-ca-file", os.Getenv("MONGO_TLS_CA_FILE"), "PEM file with the CA certificates verifying MongoDB, enables TLS (optional)")
+	fs.StringVar(&o.tlsCertKeyFile, "tls-cert-key-file", os.Getenv("MONGO_TLS_CERT_KEY_FILE"), "PEM file with the client certificate and key for MongoDB, enables TLS (optional)")
+	fs.BoolVar(&o.tlsInsecure, "tls-insecure", envBool("MONGO_TLS_INSECURE"), "Connect to MongoDB with TLS without verifying its certificate or host name")
+	fs.StringVar(&o.environment, "env", os.Getenv("ENVIRONMENT"), "Environment (staging or production)")
+	fs.StringVar(&o.s3Endpoint, "s3-endpoint", os.Getenv("S3_ENDPOINT"), "S3 endpoint URL (Backblaze)")
+	fs.StringVar(&o.s3Region, "s3-region", os.Getenv("S3_REGION"), "S3 region")
+	fs.StringVar(&o.s3Bucket, "s3-bucket", os.Getenv("S3_BUCKET"), "S3 bucket name")
+	fs.StringVar(&o.s3AccessKey, "s3-access-key", os.Getenv("S3_ACCESS_KEY"), "S3 access key")
+	fs.StringVar(&o.s3SecretKey, "s3-secret-key", os.Getenv("S3_SECRET_KEY"), "S3 secret key")
+	fs.BoolVar(&o.defaultCredentials, "s3-default-credentials", envBool("S3_USE_DEFAULT_CREDENTIALS"), "Use the AWS credential chain (env, shared config, instance role) instead of -s3-access-key and -s3-secret-key")
+	fs.StringVar(&o.awsProfile, "aws-profile", os.Getenv("S3_AWS_PROFILE"), "AWS shared config profile for S3 credentials, implies -s3-default-credentials")
+	fs.StringVar(&o.provider, "provider", os.Getenv("STORAGE_PROVIDER"), "Where backups are stored: s3, gcs, azure or filesystem (default: s3)")
+	fs.StringVar(&o.gcsBucket, "gcs-bucket", os.Getenv("GCS_BUCKET"), "Google Cloud Storage bucket (-provider=gcs)")
+	fs.StringVar(&o.gcsHMACAccessID, "gcs-hmac-access-id", os.Getenv("GCS_HMAC_ACCESS_ID"), "Google Cloud Storage HMAC key access id (-provider=gcs)")
+	fs.StringVar(&o.gcsHMACSecret, "gcs-hmac-secret", os.Getenv("GCS_HMAC_SECRET"), "Google Cloud Storage HMAC key secret (-provider=gcs)")
+	fs.StringVar(&o.azureAccount, "azure-account", os.Getenv("AZURE_STORAGE_ACCOUNT"), "Azure storage account (-provider=azure)")
+	fs.StringVar(&o.azureAccountKey, "azure-account-key", os.Getenv("AZURE_STORAGE_KEY"), "Azure storage account key (-provider=azure)")
+	fs.StringVar(&o.azureContainer, "azure-container", os.Getenv("AZURE_CONTAINER"), "Azure Blob Storage container (-provider=azure)")
+	fs.StringVar(&o.azureEndpoint, "azure-endpoint", os.Getenv("AZURE_BLOB_ENDPOINT"), "Azure blob service URL, e.g. for Azurite (default: https://<account>.blob.core.windows.net/)")
+	fs.StringVar(&o.localDir, "local-dir", os.Getenv("LOCAL_BACKUP_DIR"), "Directory backups are stored in, e.g. an NFS mount (-provider=filesystem)")
+	fs.StringVar(&o.keyTemplate, "key-template", os.Getenv("S3_KEY_TEMPLATE"), "Go template for backup keys with {{.Env}}, {{.DB}}, {{.Date}} and {{.Timestamp}} (default: {{.Env}}/{{.Date}}/{{.DB}}-{{.Env}}-{{.Timestamp}})")
+	fs.IntVar(&o.s3MaxAttempts, "s3-max-attempts", envInt("S3_MAX_ATTEMPTS"), "Max attempts per S3 request made by the AWS SDK retryer (default: SDK default)")
+	fs.IntVar(&o.maxRetries, "s3-max-retries", envInt("S3_MAX_RETRIES"), "Retries of uploads, downloads and listings failing with 5xx or network errors (default: 0)")
+	fs.DurationVar(&o.retryBaseDelay, "s3-retry-base-delay", envDuration("S3_RETRY_BASE_DELAY"), "Delay before the first S3 retry, doubled per attempt (default: 1s)")
+	fs.DurationVar(&o.connectTimeout, "connect-timeout", envDuration("MONGO_CONNECT_TIMEOUT"), "MongoDB connect timeout (default: driver default)")
+	fs.DurationVar(&o.socketTimeout, "socket-timeout", envDuration("MONGO_SOCKET_TIMEOUT"), "MongoDB socket timeout (default: driver default)")
+	fs.DurationVar(&o.serverSelectionTimeout, "server-selection-timeout", envDuration("MONGO_SERVER_SELECTION_TIMEOUT"), "MongoDB server selection timeout (default: driver default)")
+	fs.StringVar(&o.releaseSHA, "release-sha", os.Getenv("RELEASE_SHA"), "Application release git SHA stored with backups (optional)")
+	fs.StringVar(&o.releaseVersion, "release-version", os.Getenv("RELEASE_VERSION"), "Application release version stored with backups (optional)")
+	fs.StringVar(&o.tempDir, "temp-dir", os.Getenv("TEMP_DIR"), "Temporary directory for backups")
+	fs.StringVar(&o.tempDirMode, "temp-dir-mode", os.Getenv("TEMP_DIR_MODE"), "Octal permission of the temp directory and dumps in it, files get it without execute bits (default: 0700)")
+	fs.StringVar(&o.mongodumpPath, "mongodump-path", os.Getenv("MONGODUMP_PATH"), "mongodump executable to run (default: mongodump from PATH)")
+	fs.StringVar(&o.logFormat, "log-format", os.Getenv("LOG_FORMAT"), "Log format: json, console, pretty, compact (default: pretty)")
+	fs.StringVar(&o.logLevel, "log-level", os.Getenv("LOG_LEVEL"), "Log level: debug, info, warn, error (default: info)")
+	fs.StringVar(&o.logCompactFields, "log-compact-fields", os.Getenv("LOG_COMPACT_FIELDS"), "Comma-separated keys kept by the compact log format: time, level, message, caller, logger, stacktrace")
+	return o
+}
+
+// newLogger creates the application logger from the shared options
+func (o *commonOptions) newLogger() *logger.Logger {
+	var logOutputFormat logger.OutputFormat
+	switch strings.ToLower(o.logFormat) {
+	case "json":
+		logOutputFormat = logger.FormatJSON
+	case "console":
+		logOutputFormat = logger.FormatConsole
+	case "compact":
+		logOutputFormat = logger.FormatCompact
+	case "pretty", "":
+		logOutputFormat = logger.FormatPretty
+	default:
+		logOutputFormat = logger.FormatPretty
+	}
+
+	logLevel, levelOK := parseLogLevel(o.logLevel)
+
+	// Create logger with good defaults and application info
+	logConfig := logger.Config{
+		Level:         logLevel,
+		Format:        logOutputFormat,
+		TimeFormat:    logger.TimeFormatISO8601,
+		Output:        "stdout",
+		Development:   true,
+		AddCallerInfo: true,
+		CallerSkip:    1, // Report the code calling the Logger methods, not the wrapper
+		StackTrace:    true,
+		ServiceName:   "mongodb-dumper",
+		Environment:   o.environment,
+		// Credentials are never logged on purpose, list them explicitly as a safety net
+		RedactFields: []string{"password", "secret", "token", "s3_access_key", "s3_secret_key"},
+	}
+	if o.logCompactFields != "" {
+		logConfig.CompactFields = strings.Split(o.logCompactFields, ",")
+	}
+
+	log := logger.NewWithConfig(logConfig)
+	if !levelOK {
+		log.Warn("Unknown log level, using info", "log_level", o.logLevel)
+	}
+
+	// Tag every log line with the application release the backed-up data belongs to
+	releaseFields := map[string]interface{}{}
+	if o.releaseSHA != "" {
+		releaseFields["release_sha"] = o.releaseSHA
+	}
+	if o.releaseVersion != "" {
+		releaseFields["release_version"] = o.releaseVersion
+	}
+	if len(releaseFields) > 0 {
+		log = log.WithFields(releaseFields)
+	}
+
+	return log
+}
+
+// parseLogLevel converts a -log-level value, reporting false for unknown levels which fall
+// back to info
+func parseLogLevel(level string) (logger.LogLevel, bool) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return logger.DebugLevel, true
+	case "info", "":
+		return logger.InfoLevel, true
+	case "warn", "warning":
+		return logger.WarnLevel, true
+	case "error":
+		return logger.ErrorLevel, true
+	default:
+		return logger.InfoLevel, false
+	}
+}
+
+// logFields returns the shared options as key-value pairs for logging (sensitive info redacted)
+func (o *commonOptions) logFields() []interface{} {
+	return []interface{}{
+		"mongo_uri", redactURI(o.mongoURI),
+		"database", o.database,
+		"replica_set", o.replicaSet,
+		"auth_source", o.authSource,
+		"username", o.username,
+		"auth_mechanism", o.authMechanism,
+		"tls_ca_file", o.tlsCAFile,
+		"tls_cert_key_file", o.tlsCertKeyFile,
+		"tls_insecure", o.tlsInsecure,
+		"environment", o.environment,
+		"provider", mongodb.GetValueOrDefault(o.provider, mongodb.ProviderS3),
+		"gcs_bucket", o.gcsBucket,
+		"azure_account", o.azureAccount,
+		"azure_container", o.azureContainer,
+		"local_dir", o.localDir,
+		"s3_endpoint", o.s3Endpoint,
+		"s3_region", o.s3Region,
+		"s3_bucket", o.s3Bucket,
+		"s3_access_key", redactKey(o.s3AccessKey),
+		"s3_default_credentials", o.defaultCredentials,
+		"aws_profile", o.awsProfile,
+		"s3_max_attempts", o.s3MaxAttempts,
+		"key_template", o.keyTemplate,
+		"s3_max_retries", o.maxRetries,
+		"s3_retry_base_delay", o.retryBaseDelay,
+		"temp_dir", o.tempDir,
+		"temp_dir_mode", o.tempDirMode,
+		"mongodump_path", o.mongodumpPath,
+	}
+}
+
+// validate checks the required shared options and fills in defaults, exiting on fatal errors
+func (o *commonOptions) validate(log *logger.Logger) {
+	o.validateLocal(log)
+	o.validateStorage(log)
+}
+
+// validateStorage checks the settings of the configured storage provider and exits on error
+func (o *commonOptions) validateStorage(log *logger.Logger) {
+	switch o.provider {
+	case "", mongodb.ProviderS3:
+		if o.s3Endpoint == "" || o.s3Bucket == "" {
+			fatal(log, exitConfig, "S3 configuration is incomplete", nil)
+		}
+		if !o.defaultCredentials && o.awsProfile == "" && (o.s3AccessKey == "" || o.s3SecretKey == "") {
+			fatal(log, exitConfig, "S3 credentials are missing, set -s3-access-key and -s3-secret-key or -s3-default-credentials", nil)
+		}
+	case mongodb.ProviderGCS:
+		if o.gcsBucket == "" || o.gcsHMACAccessID == "" || o.gcsHMACSecret == "" {
+			fatal(log, exitConfig, "GCS configuration is incomplete, set -gcs-bucket, -gcs-hmac-access-id and -gcs-hmac-secret", nil)
+		}
+	case mongodb.ProviderAzure:
+		if o.azureAccount == "" || o.azureAccountKey == "" || o.azureContainer == "" {
+			fatal(log, exitConfig, "Azure configuration is incomplete, set -azure-account, -azure-account-key and -azure-container", nil)
+		}
+	case mongodb.ProviderFS:
+		if o.localDir == "" {
+			fatal(log, exitConfig, "Local backup directory is missing, set -local-dir", nil)
+		}
+	default:
+		fatal(log, exitConfig, "Unsupported storage provider, use s3, gcs, azure or filesystem", fmt.Errorf("provider %q", o.provider))
+	}
+}
+
+// validateLocal checks the MongoDB and temp directory settings and exits on error. Restores
+// of local archives only need these.
+func (o *commonOptions) validateLocal(log *logger.Logger) {
+	if o.mongoURI == "" {
+		fatal(log, exitConfig, "MongoDB URI is required", nil)
+	}
+	// Make environment optional by removing the required check
+	// Only validate if a value is provided
+	if o.environment != "" && o.environment != "staging" && o.environment != "production" {
+		log.Warn("Environment should be 'staging' or 'production', using provided value anyway",
+			"environment", o.environment)
+	}
+
+	// Set default temp directory if not provided
+	if o.tempDir == "" {
+		o.tempDir = filepath.Join(os.TempDir(), "mongodb-dumps")
+		log.Info("No temporary directory specified, using default", "tempDir", o.tempDir)
+	}
+
+	// Dumps hold the whole database, so by default only the owner may read them
+	o.dirMode = mongodb.DefaultTempDirMode
+	if o.tempDirMode != "" {
+		mode, err := strconv.ParseUint(o.tempDirMode, 8, 32)
+		if err != nil {
+			fatal(log, exitConfig, "Invalid temp directory mode, use an octal permission such as 0700", err)
+		}
+		o.dirMode = os.FileMode(mode)
+	}
+
+	// Ensure temp directory exists
+	if err := os.MkdirAll(o.tempDir, o.dirMode); err != nil {
+		log.Warn("Failed to create temporary directory", "tempDir", o.tempDir, "error", err)
+	}
+}
+
+// dumperConfig builds the dumper configuration from the shared options
+func (o *commonOptions) dumperConfig(log *logger.Logger) mongodb.DumperConfig {
+	return mongodb.DumperConfig{
+		MongoURI:              o.mongoURI,
+		Database:              o.database,
+		Environment:           o.environment,
+		ReplicaSet:            o.replicaSet,
+		AuthSource:            o.authSource,
+		Username:              o.username,
+		Password:              o.password,
+		AuthMechanism:         o.authMechanism,
+		TLSCAFile:             o.tlsCAFile,
+		TLSCertKeyFile:        o.tlsCertKeyFile,
+		TLSInsecure:           o.tlsInsecure,
+		Provider:              o.provider,
+		GCSBucket:             o.gcsBucket,
+		GCSHMACAccessID:       o.gcsHMACAccessID,
+		GCSHMACSecret:         o.gcsHMACSecret,
+		AzureAccount:          o.azureAccount,
+		AzureAccountKey:       o.azureAccountKey,
+		AzureContainer:        o.azureContainer,
+		AzureEndpoint:         o.azureEndpoint,
+		LocalDir:              o.localDir,
+		S3Endpoint:            o.s3Endpoint,
+		S3Region:              o.s3Region,
+		S3Bucket:              o.s3Bucket,
+		S3AccessKey:           o.s3AccessKey,
+		S3SecretKey:           o.s3SecretKey,
+		UseDefaultCredentials: o.defaultCredentials,
+		AWSProfile:            o.awsProfile,
+		S3SDKMaxAttempts:      o.s3MaxAttempts,
+		KeyTemplate:           o.keyTemplate,
+		MaxRetries:            o.maxRetries,
+		RetryBaseDelay:        o.retryBaseDelay,
+		ReleaseSHA:            o.releaseSHA,
+		ReleaseVersion:        o.releaseVersion,
+		TempDir:               o.tempDir,
+		TempDirMode:           o.dirMode,
+		MongodumpPath:         o.mongodumpPath,
+		Log:                   log, // Not the zap logger, so the package's logs are redacted too
+
+		ConnectTimeout:         o.connectTimeout,
+		SocketTimeout:          o.socketTimeout,
+		ServerSelectionTimeout: o.serverSelectionTimeout,
+	}
+}
+
+// newDumper creates the MongoDB dumper, exiting with a helpful message on failure
+func newDumper(log *logger.Logger, cfg mongodb.DumperConfig) *mongodb.Dumper {
+	dumper, err := mongodb.NewDumper(cfg)
+	if err != nil {
+		if errors.Is(err, mongodb.ErrMongoDumpNotFound) || errors.Is(err, mongodb.ErrMongoRestoreNotFound) {
+			log.Info("Help: Please install MongoDB Database Tools: brew install mongodb/brew/mongodb-database-tools")
+			fatal(log, exitConfig, "MongoDB tools not found", err)
+		} else {
+			fatal(log, exitConfig, "Failed to create MongoDB dumper", err)
+		}
+	}
+	return dumper
+}
+
+// newLocalDumper creates a dumper restoring local archives or exits
+func newLocalDumper(log *logger.Logger, cfg mongodb.DumperConfig) *mongodb.Dumper {
+	dumper, err := mongodb.NewLocalDumper(cfg)
+	if err != nil {
+		if errors.Is(err, mongodb.ErrMongoRestoreNotFound) {
+			log.Info("Help: Please install MongoDB Database Tools: brew install mongodb/brew/mongodb-database-tools")
+			fatal(log, exitConfig, "MongoDB tools not found", err)
+		}
+		fatal(log, exitConfig, "Failed to create MongoDB dumper", err)
+	}
+	return dumper
+}
+
+// signalContext returns a context that is cancelled on SIGINT or SIGTERM
+func signalContext(log *logger.Logger) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Handle OS signals for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		log.Info("Received signal, shutting down", "signal", sig.String())
+		cancel()
+	}()
+
+	return ctx, cancel
+}
+
+// shutdownContexts supports a graceful shutdown. stopping is cancelled by the first SIGINT or
+// SIGTERM, so no new backup starts; running is cancelled by a second signal, once grace has
+// passed, or right away if busy reports no backup in progress, aborting the work in flight.
+// With a zero grace, the first signal cancels both.
+func shutdownContexts(log *logger.Logger, grace time.Duration, busy func() bool) (stopping, running context.Context, cancel context.CancelFunc) {
+	stopping, stop := context.WithCancel(context.Background())
+	running, abort := context.WithCancel(context.Background())
+
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigChan
+		stop()
+		if grace <= 0 || !busy() {
+			log.Info("Received signal, shutting down", "signal", sig.String())
+			abort()
+			return
+		}
+
+		log.Info("Received signal, finishing the current backup before shutting down",
+			"signal", sig.String(),
+			"grace", grace)
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case sig := <-sigChan:
+			log.Warn("Received second signal, aborting the current backup", "signal", sig.String())
+		case <-timer.C:
+			log.Warn("Shutdown grace period passed, aborting the current backup", "grace", grace)
+		case <-running.Done():
+		}
+		abort()
+	}()
+
+	return stopping, running, func() {
+		stop()
+		abort()
+	}
+}
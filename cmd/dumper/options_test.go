@@ -0,0 +1,99 @@
+package main
+
+import (
+	"dumper/pkg/mongodb"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReleaseFromEnvironment(t *testing.T) {
+	t.Setenv("RELEASE_SHA", "4f2a9c1")
+	t.Setenv("RELEASE_VERSION", "v1.8.0")
+
+	fs := newFlagSet("backup", "test")
+	opts := registerCommonFlags(fs)
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	cfg := opts.dumperConfig(opts.newLogger())
+	if cfg.ReleaseSHA != "4f2a9c1" || cfg.ReleaseVersion != "v1.8.0" {
+		t.Errorf("release = %q %q, want the RELEASE_SHA and RELEASE_VERSION values", cfg.ReleaseSHA, cfg.ReleaseVersion)
+	}
+
+	// Flags take precedence over the environment
+	fs = newFlagSet("backup", "test")
+	opts = registerCommonFlags(fs)
+	if err := fs.Parse([]string{"-release-sha=9e0d7b3"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := opts.dumperConfig(opts.newLogger()); cfg.ReleaseSHA != "9e0d7b3" {
+		t.Errorf("release sha = %q, want the flag value 9e0d7b3", cfg.ReleaseSHA)
+	}
+}
+
+// captureStdout redirects os.Stdout to a pipe while build runs, so loggers created by it
+// write there, and returns a function closing the pipe and returning what was written
+func captureStdout(t *testing.T, build func()) func() string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	build()
+	os.Stdout = stdout
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- data
+	}()
+	return func() string {
+		writer.Close()
+		data := <-output
+		reader.Close()
+		return string(data)
+	}
+}
+
+func TestDumperConfigLogsAreRedacted(t *testing.T) {
+	fs := newFlagSet("backup", "test")
+	opts := registerCommonFlags(fs)
+	args := []string{
+		"-mongo-uri=mongodb://localhost:27017",
+		"-tls-insecure",
+		"-mongodump-path=" + writeFakeTool(t, t.TempDir(), "mongodump", "exit 0\n"),
+		"-log-format=json",
+	}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	var cfg mongodb.DumperConfig
+	output := captureStdout(t, func() { cfg = opts.dumperConfig(opts.newLogger()) })
+
+	if cfg.Logger != nil || cfg.Log == nil {
+		t.Fatal("dumperConfig must pass the redacting logger as Log, not the zap logger")
+	}
+	// Logged by pkg/mongodb itself, through the configured Log
+	if _, err := mongodb.NewMongoDumper(cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Log.Warn("Connecting to MongoDB", "mongo_password", "hunter2", "s3_secret_key", "wJalrXUtnFEMI")
+
+	logged := output()
+	if !strings.Contains(logged, "MongoDB TLS certificate verification is disabled") ||
+		!strings.Contains(logged, "mongodb/dump.go:") {
+		t.Errorf("pkg/mongodb log line missing or not attributed to its caller:\n%s", logged)
+	}
+	for _, secret := range []string{"hunter2", "wJalrXUtnFEMI"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("secret %q logged:\n%s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, `"mongo_password":"[REDACTED]"`) {
+		t.Errorf("password not redacted:\n%s", logged)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+// pruneCommand deletes old backups according to a retention policy
+func pruneCommand() *command {
+	return &command{
+		name:    "prune",
+		summary: "Delete old backups from S3 according to a retention policy",
+		run:     runPrune,
+	}
+}
+
+// runPrune parses the prune flags and applies the retention policy once
+func runPrune(args []string) {
+	fs := newFlagSet("prune", "Delete old backups from S3 according to a retention policy.\nThe newest backup is always kept.")
+	opts := registerCommonFlags(fs)
+	var (
+		retentionAge   = fs.Duration("retention-age", envDuration("RETENTION_AGE"), "Delete backups older than this (default: no age limit)")
+		retentionCount = fs.Int("retention-count", envInt("RETENTION_COUNT"), "Keep only this many of the newest backups (default: unlimited)")
+	)
+	fs.Parse(args)
+
+	appLogger := opts.newLogger()
+
+	if *retentionAge <= 0 && *retentionCount <= 0 {
+		fatal(appLogger, exitConfig, "A retention policy is required, use -retention-age and/or -retention-count", nil)
+	}
+
+	appLogger.Info("Pruning MongoDB backups", append(opts.logFields(),
+		"retention_age", *retentionAge,
+		"retention_count", *retentionCount)...)
+
+	opts.validate(appLogger)
+
+	dumper := newDumper(appLogger, opts.dumperConfig(appLogger))
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	if err := dumper.PruneBackups(ctx, *retentionCount, *retentionAge); err != nil {
+		fatal(appLogger, exitCode(err), "Failed to prune backups", err)
+	}
+	appLogger.Info("Pruning completed successfully")
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"dumper/pkg/mongodb"
+	"os"
+	"time"
+)
+
+// restoreCommand restores a backup from S3 or a local archive
+func restoreCommand() *command {
+	return &command{
+		name:    "restore",
+		summary: "Restore a backup from S3 or a local archive with mongorestore",
+		run:     runRestore,
+	}
+}
+
+// runRestore parses the restore flags, downloads the backup and restores it
+func runRestore(args []string) {
+	fs := newFlagSet("restore", "Restore a backup from S3 or a local archive with mongorestore.")
+	opts := registerCommonFlags(fs)
+	var (
+		s3Key     = fs.String("s3-key", "", "S3 key of the backup archive to restore (see \"dumper list\")")
+		latest    = fs.Bool("restore-latest", envBool("RESTORE_LATEST"), "Restore the newest backup of the environment instead of -s3-key")
+		localFile = fs.String("restore-file", os.Getenv("RESTORE_FILE"), "Restore this local backup archive instead of one from S3, storage settings are not needed")
+		targetURI = fs.String("target-uri", os.Getenv("RESTORE_TARGET_URI"), "Restore into this MongoDB cluster instead of -mongo-uri (optional)")
+		dryRun    = fs.Bool("restore-dry-run", envBool("RESTORE_DRY_RUN"), "Validate the backup and run mongorestore --dryRun without writing anything")
+		db        = fs.String("restore-db", os.Getenv("RESTORE_DB"), "Only restore this database of the backup (optional)")
+		colls     = &stringList{values: envList("RESTORE_COLLECTIONS")}
+		nsFrom    = fs.String("restore-ns-from", os.Getenv("RESTORE_NS_FROM"), "Rename restored namespaces matching this pattern, e.g. prod.* (requires -restore-ns-to)")
+		nsTo      = fs.String("restore-ns-to", os.Getenv("RESTORE_NS_TO"), "Target of -restore-ns-from, e.g. prod_restore_test.*")
+		// Downloads of large archives
+		parallelDownload    = fs.Bool("parallel-download", envBool("PARALLEL_DOWNLOAD"), "Download the archive with concurrent ranged requests and verify its SHA-256 (S3 and GCS only)")
+		downloadPartSize    = envByteSize("DOWNLOAD_PART_SIZE")
+		downloadConcurrency = fs.Int("download-concurrency", envInt("DOWNLOAD_CONCURRENCY"), "Parts downloaded at once with -parallel-download (default: 4)")
+	)
+	fs.Var(colls, "restore-collection", "Only restore this collection, of -restore-db or any database, repeatable; '*' matches any characters")
+	fs.Var(downloadPartSize, "download-part-size", "Bytes per ranged request with -parallel-download, e.g. 64MB (default: 16MB)")
+	fs.Parse(args)
+
+	appLogger := opts.newLogger()
+
+	sources := 0
+	for _, set := range []bool{*s3Key != "", *latest, *localFile != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources == 0 {
+		fatal(appLogger, exitConfig, "A backup is required, use -s3-key, -restore-latest or -restore-file", nil)
+	}
+	if sources > 1 {
+		fatal(appLogger, exitConfig, "Use only one of -s3-key, -restore-latest and -restore-file", nil)
+	}
+
+	// Restoring into another cluster only swaps the connection string mongorestore uses
+	if *targetURI != "" {
+		opts.mongoURI = *targetURI
+	}
+
+	appLogger.Info("Starting MongoDB restore", append(opts.logFields(),
+		"s3_key", *s3Key,
+		"restore_latest", *latest,
+		"restore_file", *localFile,
+		"target_uri", redactURI(*targetURI),
+		"dry_run", *dryRun,
+		"restore_db", *db,
+		"restore_collections", colls.values,
+		"restore_ns_from", *nsFrom,
+		"restore_ns_to", *nsTo,
+		"parallel_download", *parallelDownload,
+		"download_part_size", uint64(*downloadPartSize),
+		"download_concurrency", *downloadConcurrency)...)
+
+	// Local archives don't touch the object store, so its settings are not required
+	if *localFile != "" {
+		opts.validateLocal(appLogger)
+	} else {
+		opts.validate(appLogger)
+	}
+	if (*nsFrom == "") != (*nsTo == "") {
+		fatal(appLogger, exitConfig, "Use -restore-ns-from and -restore-ns-to together", nil)
+	}
+
+	cfg := opts.dumperConfig(appLogger)
+	cfg.RestoreDryRun = *dryRun
+	cfg.RestoreNamespaces = restoreNamespaces(*db, colls.values)
+	cfg.RestoreNSFrom = *nsFrom
+	cfg.RestoreNSTo = *nsTo
+	cfg.ParallelDownload = *parallelDownload
+	cfg.DownloadPartSize = int64(*downloadPartSize)
+	cfg.DownloadConcurrency = *downloadConcurrency
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	if *localFile != "" {
+		dumper := newLocalDumper(appLogger, cfg)
+		if err := dumper.RestoreFromFile(ctx, *localFile); err != nil {
+			fatal(appLogger, exitCode(err), "Restore failed", err)
+		}
+		if *dryRun {
+			appLogger.Info("Restore dry run passed, nothing was written", "path", *localFile)
+			return
+		}
+		appLogger.Info("Restore completed successfully", "path", *localFile)
+		return
+	}
+
+	dumper := newDumper(appLogger, cfg)
+
+	if *latest {
+		backup, err := dumper.LatestBackup(ctx)
+		if err != nil {
+			fatal(appLogger, exitCode(err), "Failed to find the latest backup", err)
+		}
+		appLogger.Info("Selected the latest backup",
+			"s3_key", backup.Key,
+			"created_at", backup.CreatedAt.UTC().Format(time.RFC3339),
+			"size_bytes", backup.Size)
+		*s3Key = backup.Key
+	}
+
+	if err := dumper.RestoreBackup(ctx, *s3Key); err != nil {
+		fatal(appLogger, exitCode(err), "Restore failed", err)
+	}
+	if *dryRun {
+		appLogger.Info("Restore dry run passed, nothing was written", "s3_key", *s3Key)
+		return
+	}
+	appLogger.Info("Restore completed successfully", "s3_key", *s3Key)
+}
+
+// restoreNamespaces builds the --nsInclude patterns selecting a database and collections.
+// Collections without a database match in any database; neither restores everything.
+func restoreNamespaces(database string, collections []string) []string {
+	if database == "" && len(collections) == 0 {
+		return nil
+	}
+	if len(collections) == 0 {
+		return []string{database + ".*"}
+	}
+
+	database = mongodb.GetValueOrDefault(database, "*")
+	namespaces := make([]string, 0, len(collections))
+	for _, collection := range collections {
+		namespaces = append(namespaces, database+"."+collection)
+	}
+	return namespaces
+}
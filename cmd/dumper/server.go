@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"dumper/pkg/logger"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// serveHTTP serves handler on addr until ctx is cancelled, then shuts the server down gracefully
+func serveHTTP(ctx context.Context, log *logger.Logger, name, addr string, handler http.Handler) {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		log.Info("Starting HTTP server", "server", name, "addr", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("HTTP server failed", "server", name, "error", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Warn("Failed to shut down HTTP server", "server", name, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"dumper/pkg/mongodb"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// verifyCommand proves a stored backup is restorable without restoring it
+func verifyCommand() *command {
+	return &command{
+		name:    "verify",
+		summary: "Check that every collection of a backup is readable BSON",
+		run:     runVerify,
+	}
+}
+
+// runVerify parses the verify flags, reads the backup back and prints each collection
+func runVerify(args []string) {
+	fs := newFlagSet("verify", "Download a backup, extract it and read every collection file as BSON without restoring\nanything. Exits non-zero if a file is truncated or corrupt.")
+	opts := registerCommonFlags(fs)
+	var (
+		s3Key  = fs.String("s3-key", "", "S3 key of the backup archive to verify (see \"dumper list\")")
+		asJSON = fs.Bool("json", false, "Print the collections as JSON for scripting")
+	)
+	fs.Parse(args)
+
+	appLogger := opts.newLogger()
+
+	if *s3Key == "" {
+		fatal(appLogger, exitConfig, "An S3 key is required, use -s3-key", nil)
+	}
+
+	appLogger.Info("Verifying MongoDB backup", append(opts.logFields(),
+		"s3_key", *s3Key)...)
+
+	opts.validate(appLogger)
+
+	dumper := newDumper(appLogger, opts.dumperConfig(appLogger))
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	result, err := dumper.VerifyBackup(ctx, *s3Key)
+	if len(result.Collections) > 0 {
+		if printErr := printVerifyResult(os.Stdout, result, *asJSON); printErr != nil {
+			appLogger.Warn("Failed to print verification result", "error", printErr)
+		}
+	}
+	if err != nil {
+		fatal(appLogger, exitCode(err), "Backup verification failed", err)
+	}
+}
+
+// verifiedCollection is one collection of the JSON verification output
+type verifiedCollection struct {
+	Collection string `json:"collection"`
+	Documents  int64  `json:"documents"`
+	Error      string `json:"error,omitempty"`
+}
+
+// printVerifyResult writes the collections of a verified backup as a table, or as JSON
+func printVerifyResult(w io.Writer, result mongodb.VerifyResult, asJSON bool) error {
+	if asJSON {
+		collections := make([]verifiedCollection, 0, len(result.Collections))
+		for _, check := range result.Collections {
+			collection := verifiedCollection{Collection: check.Namespace, Documents: check.Documents}
+			if check.Err != nil {
+				collection.Error = check.Err.Error()
+			}
+			collections = append(collections, collection)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(collections)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTION\tDOCUMENTS\tSTATUS")
+	for _, check := range result.Collections {
+		status := "ok"
+		if check.Err != nil {
+			status = "CORRUPT: " + check.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", check.Namespace, check.Documents, status)
+	}
+	return tw.Flush()
+}
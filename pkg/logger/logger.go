@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -10,6 +11,7 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // LogLevel represents logging levels
@@ -54,7 +56,7 @@ type Config struct {
 	Level              LogLevel
 	Format             OutputFormat
 	TimeFormat         TimeFormat
-	Output             string // stdout, stderr, or file path
+	Output             string // stdout, stderr, syslog, syslog://host:514, syslog+tcp://host:514, or file path; comma-separate to log to several
 	Development        bool
 	AddCallerInfo      bool
 	CallerSkip         int      // How many levels of stack to skip when capturing caller
@@ -67,6 +69,11 @@ type Config struct {
 	SamplingThereafter int      // Sampling rate after initial allowance
 	ContextualFields   []string // Additional contextual fields to always include
 	RedactFields       []string // Fields to redact from logs (e.g. "password", "token")
+	CompactFields      []string // Keys kept by FormatCompact: time, level, message, caller, logger, stacktrace (nil = all but time)
+	MaxSizeMB          int      // Rotate a file Output once it reaches this size (0 = 100 MB)
+	MaxBackups         int      // Rotated files to keep (0 = all, subject to MaxAgeDays)
+	MaxAgeDays         int      // Delete rotated files older than this many days (0 = never)
+	Compress           bool     // Gzip rotated files
 }
 
 // Logger wraps zap logger with additional functionality
@@ -173,6 +180,9 @@ func NewWithConfig(config Config) *Logger {
 		encoderConfig.LevelKey = "l"
 		encoderConfig.MessageKey = "m"
 		encoderConfig.CallerKey = "c"
+		if config.CompactFields != nil {
+			applyCompactFields(&encoderConfig, config.CompactFields)
+		}
 		encoderConfig.EncodeLevel = func(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 			// Single letter level indicators
 			switch l {
@@ -192,30 +202,6 @@ func NewWithConfig(config Config) *Logger {
 		}
 	}
 
-	// Configure output
-	var output zapcore.WriteSyncer
-	switch strings.ToLower(config.Output) {
-	case "stdout":
-		output = zapcore.AddSync(os.Stdout)
-	case "stderr":
-		output = zapcore.AddSync(os.Stderr)
-	default:
-		// Create directory if needed
-		dir := filepath.Dir(config.Output)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to create log directory %s: %v\n", dir, err)
-		}
-
-		// Assume it's a file path
-		file, err := os.OpenFile(config.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to open log file %s: %v\n", config.Output, err)
-			output = zapcore.AddSync(os.Stderr)
-		} else {
-			output = zapcore.AddSync(file)
-		}
-	}
-
 	// Configure encoder format
 	var encoder zapcore.Encoder
 	switch config.Format {
@@ -227,17 +213,17 @@ func NewWithConfig(config Config) *Logger {
 		encoder = zapcore.NewJSONEncoder(encoderConfig)
 	}
 
+	// Configure output
+	core := configCore(config, encoder, atomicLevel)
+
 	// Configure sampling if enabled
-	var core zapcore.Core
 	if config.SamplingEnabled {
 		core = zapcore.NewSamplerWithOptions(
-			zapcore.NewCore(encoder, output, atomicLevel),
+			core,
 			time.Second,
 			config.SamplingInitial,
 			config.SamplingThereafter,
 		)
-	} else {
-		core = zapcore.NewCore(encoder, output, atomicLevel)
 	}
 
 	// Add options
@@ -292,6 +278,178 @@ func NewWithConfig(config Config) *Logger {
 	}
 }
 
+// configCore opens every destination in the comma-separated config.Output. Several
+// destinations receive the same encoded entries. Empty entries are skipped with a warning,
+// and stderr is used if none are left. Syslog destinations get a core of their own, which
+// sends each entry at the syslog severity of its level.
+func configCore(config Config, encoder zapcore.Encoder, level zapcore.LevelEnabler) zapcore.Core {
+	var outputs []zapcore.WriteSyncer
+	var cores []zapcore.Core
+	for _, target := range strings.Split(config.Output, ",") {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			fmt.Fprintf(os.Stderr, "Ignoring empty log output in %q\n", config.Output)
+			continue
+		}
+		if isSyslogOutput(target) {
+			writer, err := syslogOutput(config, target)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to connect to syslog %s, logging to stderr: %v\n", target, err)
+				outputs = append(outputs, zapcore.AddSync(os.Stderr))
+				continue
+			}
+			cores = append(cores, &leveledCore{LevelEnabler: level, enc: encoder.Clone(), out: writer})
+			continue
+		}
+		outputs = append(outputs, openOutput(config, target))
+	}
+
+	switch {
+	case len(outputs) == 0 && len(cores) == 0:
+		fmt.Fprintf(os.Stderr, "No log output configured, logging to stderr\n")
+		outputs = append(outputs, zapcore.AddSync(os.Stderr))
+	case len(outputs) == 0:
+		return zapcore.NewTee(cores...)
+	}
+	output := outputs[0]
+	if len(outputs) > 1 {
+		output = zapcore.NewMultiWriteSyncer(outputs...)
+	}
+	return zapcore.NewTee(append([]zapcore.Core{zapcore.NewCore(encoder, output, level)}, cores...)...)
+}
+
+// openOutput opens a single log destination other than syslog
+func openOutput(config Config, target string) zapcore.WriteSyncer {
+	switch strings.ToLower(target) {
+	case "stdout":
+		return zapcore.AddSync(os.Stdout)
+	case "stderr":
+		return zapcore.AddSync(os.Stderr)
+	}
+	return fileOutput(config, target)
+}
+
+// fileOutput opens path as a log file that rotates by size, falling back to stderr if the
+// file can't be opened
+func fileOutput(config Config, path string) zapcore.WriteSyncer {
+	// Create directory if needed
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create log directory %s: %v\n", dir, err)
+	}
+
+	// Open the file up front: lumberjack would only report a failure on the first write
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open log file %s: %v\n", path, err)
+		return zapcore.AddSync(os.Stderr)
+	}
+	file.Close()
+
+	return zapcore.AddSync(&lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    config.MaxSizeMB,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAgeDays,
+		Compress:   config.Compress,
+	})
+}
+
+// isSyslogOutput reports whether output names a syslog destination
+func isSyslogOutput(output string) bool {
+	lower := strings.ToLower(output)
+	return lower == "syslog" || strings.HasPrefix(lower, "syslog://") || strings.HasPrefix(lower, "syslog+tcp://")
+}
+
+// syslogOutput connects to the syslog destination target, tagged with the service name:
+// "syslog" is the local daemon, syslog://host:port sends over UDP and syslog+tcp://host:port
+// over TCP.
+func syslogOutput(config Config, target string) (leveledWriter, error) {
+	var network, addr string
+	if scheme, host, ok := strings.Cut(target, "://"); ok {
+		network = "udp"
+		if strings.EqualFold(scheme, "syslog+tcp") {
+			network = "tcp"
+		}
+		addr = strings.TrimSuffix(host, "/")
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "514")
+		}
+	}
+	return dialSyslog(network, addr, config.ServiceName)
+}
+
+// leveledWriter writes an encoded entry at a severity chosen by its level
+type leveledWriter interface {
+	WriteLevel(level zapcore.Level, p []byte) error
+}
+
+// leveledCore encodes entries like zapcore.NewCore but passes each entry's level on to its
+// writer, so syslog receives errors at LOG_ERR whatever the configured minimum level is
+type leveledCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	out leveledWriter
+}
+
+func (c *leveledCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &leveledCore{LevelEnabler: c.LevelEnabler, enc: c.enc.Clone(), out: c.out}
+	for _, field := range fields {
+		field.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *leveledCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *leveledCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	err = c.out.WriteLevel(entry.Level, buf.Bytes())
+	buf.Free()
+	return err
+}
+
+// Sync is a no-op, syslog writes are not buffered
+func (c *leveledCore) Sync() error {
+	return nil
+}
+
+// applyCompactFields keeps only the listed keys in the compact encoder, using short key names
+func applyCompactFields(encoderConfig *zapcore.EncoderConfig, fields []string) {
+	keep := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keep[strings.ToLower(strings.TrimSpace(f))] = true
+	}
+
+	keys := []struct {
+		name  string
+		key   *string
+		short string
+	}{
+		{"time", &encoderConfig.TimeKey, "t"},
+		{"level", &encoderConfig.LevelKey, "l"},
+		{"message", &encoderConfig.MessageKey, "m"},
+		{"caller", &encoderConfig.CallerKey, "c"},
+		{"logger", &encoderConfig.NameKey, "n"},
+		{"stacktrace", &encoderConfig.StacktraceKey, "s"},
+	}
+	for _, k := range keys {
+		if keep[k.name] {
+			*k.key = k.short
+		} else {
+			*k.key = zapcore.OmitKey
+		}
+	}
+}
+
 // fieldsToArgs converts a fields map to a slice of alternating keys and values
 func fieldsToArgs(fields map[string]interface{}) []interface{} {
 	args := make([]interface{}, 0, len(fields)*2)
@@ -353,6 +511,31 @@ func (l *Logger) shouldRedact(key string) bool {
 	return false
 }
 
+// redactArgs masks the values of key-value pairs whose key matches a RedactField. The
+// arguments are only copied if something needs masking.
+func (l *Logger) redactArgs(keysAndValues []interface{}) []interface{} {
+	if len(l.config.RedactFields) == 0 {
+		return keysAndValues
+	}
+
+	var redacted []interface{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok || !l.shouldRedact(key) {
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]interface{}, len(keysAndValues))
+			copy(redacted, keysAndValues)
+		}
+		redacted[i+1] = "[REDACTED]"
+	}
+	if redacted == nil {
+		return keysAndValues
+	}
+	return redacted
+}
+
 // WithFields returns a logger with multiple fields added to it
 func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
 	newFields := make(map[string]interface{}, len(l.fields)+len(fields))
@@ -459,22 +642,22 @@ func (l *Logger) GetLevel() LogLevel {
 
 // Debug logs a debug message with optional key-value pairs
 func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
-	l.SugaredLogger.Debugw(msg, keysAndValues...)
+	l.SugaredLogger.Debugw(msg, l.redactArgs(keysAndValues)...)
 }
 
 // Info logs an info message with optional key-value pairs
 func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
-	l.SugaredLogger.Infow(msg, keysAndValues...)
+	l.SugaredLogger.Infow(msg, l.redactArgs(keysAndValues)...)
 }
 
 // Warn logs a warning message with optional key-value pairs
 func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
-	l.SugaredLogger.Warnw(msg, keysAndValues...)
+	l.SugaredLogger.Warnw(msg, l.redactArgs(keysAndValues)...)
 }
 
 // Error logs an error message with optional key-value pairs
 func (l *Logger) Error(msg string, keysAndValues ...interface{}) {
-	l.SugaredLogger.Errorw(msg, keysAndValues...)
+	l.SugaredLogger.Errorw(msg, l.redactArgs(keysAndValues)...)
 }
 
 // Fatal logs a fatal message with optional key-value pairs and then exits
@@ -488,7 +671,7 @@ func (l *Logger) Fatal(msg string, err error) {
 
 // Panic logs a panic message with optional key-value pairs and then panics
 func (l *Logger) Panic(msg string, keysAndValues ...interface{}) {
-	l.SugaredLogger.Panicw(msg, keysAndValues...)
+	l.SugaredLogger.Panicw(msg, l.redactArgs(keysAndValues)...)
 }
 
 // HTTPRequest logs an HTTP request with detailed information
@@ -568,6 +751,11 @@ func NewProductionLogger(serviceName, version, environment string) *Logger {
 	return NewWithConfig(config)
 }
 
+// Nop returns a zap.Logger that discards everything, for library consumers that don't want logs
+func Nop() *zap.Logger {
+	return zap.NewNop()
+}
+
 // GetZapLogger returns the underlying zap.Logger
 func (l *Logger) GetZapLogger() *zap.Logger {
 	return l.SugaredLogger.Desugar()
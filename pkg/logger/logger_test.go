@@ -0,0 +1,220 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFileLogger returns a logger writing to a temp log file, and a function reading the
+// lines written so far
+func newFileLogger(t *testing.T, config Config) (*Logger, func() []string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	if config.Output == "" {
+		config.Output = path
+	} else {
+		config.Output += "," + path
+	}
+	log := NewWithConfig(config)
+
+	return log, func() []string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+}
+
+func TestCompactFieldsKeepsOnlyConfiguredKeys(t *testing.T) {
+	tests := []struct {
+		fields []string
+		check  func(parts []string) bool
+	}{
+		{
+			fields: []string{"level", "message"},
+			check: func(parts []string) bool {
+				return len(parts) == 3 && parts[0] == "W" && parts[1] == "disk almost full"
+			},
+		},
+		{
+			fields: []string{"message"},
+			check: func(parts []string) bool {
+				return len(parts) == 2 && parts[0] == "disk almost full"
+			},
+		},
+		{
+			fields: []string{" Time ", "level", "message", "caller"},
+			check: func(parts []string) bool {
+				return len(parts) == 5 && parts[0] != "" && parts[1] == "W" &&
+					strings.HasPrefix(parts[2], "logger/logger_test.go:") && parts[3] == "disk almost full"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		log, lines := newFileLogger(t, Config{
+			Level:         InfoLevel,
+			Format:        FormatCompact,
+			AddCallerInfo: true,
+			CallerSkip:    1,
+			ServiceName:   "dumper",
+			CompactFields: tt.fields,
+		})
+		log.Warn("disk almost full", "free_bytes", 1024)
+
+		got := lines()
+		if len(got) != 1 {
+			t.Fatalf("CompactFields %v: logged %d lines, want 1: %q", tt.fields, len(got), got)
+		}
+		parts := strings.Split(got[0], "\t")
+		if !tt.check(parts) {
+			t.Errorf("CompactFields %v: unexpected line %q", tt.fields, got[0])
+		}
+		if fields := parts[len(parts)-1]; !strings.Contains(fields, `"free_bytes": 1024`) {
+			t.Errorf("CompactFields %v: context fields missing from %q", tt.fields, got[0])
+		}
+	}
+}
+
+func TestFileOutputRotatesAtMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	log := NewWithConfig(Config{
+		Level:      InfoLevel,
+		Format:     FormatJSON,
+		Output:     path,
+		MaxSizeMB:  1,
+		MaxBackups: 5,
+	})
+
+	// About 1.5MB of log lines, so the file passes the 1MB threshold once
+	payload := strings.Repeat("x", 1024)
+	for i := 0; i < 1500; i++ {
+		log.Info("backup progress", "payload", payload)
+	}
+	log.Sync()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rotated []string
+	for _, entry := range entries {
+		if entry.Name() != "app.log" && strings.HasPrefix(entry.Name(), "app-") && strings.HasSuffix(entry.Name(), ".log") {
+			rotated = append(rotated, entry.Name())
+		}
+	}
+	if len(rotated) != 1 {
+		t.Fatalf("rotated files = %v, want one after passing the size threshold once", rotated)
+	}
+
+	lines := 0
+	for _, name := range []string{"app.log", rotated[0]} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 1024*1024 {
+			t.Errorf("%s is %d bytes, over the 1MB threshold", name, len(data))
+		}
+		lines += strings.Count(string(data), "\n")
+	}
+	if lines != 1500 {
+		t.Errorf("%d lines across the log files, want all 1500", lines)
+	}
+}
+
+// captureStdout replaces os.Stdout with a pipe while build runs, so loggers opened by it write
+// stdout output to the pipe. The returned function closes the pipe and returns its lines.
+func captureStdout(t *testing.T, build func()) func() []string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	build()
+	os.Stdout = stdout
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- data
+	}()
+	return func() []string {
+		t.Helper()
+		writer.Close()
+		data := <-output
+		reader.Close()
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+}
+
+func TestMultipleOutputsReceiveSameLines(t *testing.T) {
+	var (
+		log       *Logger
+		fileLines func() []string
+	)
+	stdoutLines := captureStdout(t, func() {
+		log, fileLines = newFileLogger(t, Config{Level: InfoLevel, Format: FormatJSON, Output: "stdout"})
+	})
+
+	log.Info("backup started", "database", "app")
+	log.Warn("slow upload", "duration", "2m")
+	log.Debug("below the level")
+	log.Sync()
+
+	fromStdout, fromFile := stdoutLines(), fileLines()
+	if len(fromFile) != 2 {
+		t.Fatalf("file got %d lines, want 2: %q", len(fromFile), fromFile)
+	}
+	if strings.Join(fromStdout, "\n") != strings.Join(fromFile, "\n") {
+		t.Errorf("outputs differ:\nstdout: %q\nfile:   %q", fromStdout, fromFile)
+	}
+}
+
+func TestRedactFieldsMaskLoggedValues(t *testing.T) {
+	var log *Logger
+	lines := captureStdout(t, func() {
+		log = NewWithConfig(Config{
+			Level:        DebugLevel,
+			Format:       FormatJSON,
+			Output:       "stdout",
+			RedactFields: []string{"password", "SECRET", "token"},
+		})
+	})
+
+	args := []interface{}{"user", "backup", "mongo_password", "hunter2", "s3_secret_key", "wJalrXUtnFEMI"}
+	log.Debug("debug", args...)
+	log.Info("info", args...)
+	log.Warn("warn", args...)
+	log.Error("error", args...)
+	log.WithField("api_token", "tok-123").Info("with field")
+	log.WithFields(map[string]interface{}{"Password": "hunter2"}).Info("with fields")
+	log.Sync()
+
+	got := lines()
+	if len(got) != 6 {
+		t.Fatalf("logged %d lines, want 6: %q", len(got), got)
+	}
+	for _, line := range got {
+		for _, secret := range []string{"hunter2", "wJalrXUtnFEMI", "tok-123"} {
+			if strings.Contains(line, secret) {
+				t.Errorf("secret %q logged: %s", secret, line)
+			}
+		}
+	}
+	if !strings.Contains(got[0], `"user":"backup"`) || !strings.Contains(got[0], `"mongo_password":"[REDACTED]"`) {
+		t.Errorf("unexpected line %s, want the user kept and the password redacted", got[0])
+	}
+	// The caller's arguments are left untouched
+	if args[3] != "hunter2" {
+		t.Errorf("redaction modified the caller's arguments: %v", args)
+	}
+}
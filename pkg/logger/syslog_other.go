@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package logger
+
+import "errors"
+
+// dialSyslog is not supported on this platform
+func dialSyslog(network, addr, tag string) (leveledWriter, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,42 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"log/syslog"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogWriter sends entries to syslog at the severity of their level
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+// dialSyslog connects to syslog at network/addr, or to the local daemon if addr is empty.
+// Entries are sent with the daemon facility, tagged with tag.
+func dialSyslog(network, addr, tag string) (leveledWriter, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return syslogWriter{w: w}, nil
+}
+
+// WriteLevel sends p at the syslog severity matching level
+func (s syslogWriter) WriteLevel(level zapcore.Level, p []byte) error {
+	msg := string(p)
+	switch level {
+	case zapcore.DebugLevel:
+		return s.w.Debug(msg)
+	case zapcore.InfoLevel:
+		return s.w.Info(msg)
+	case zapcore.WarnLevel:
+		return s.w.Warning(msg)
+	case zapcore.ErrorLevel:
+		return s.w.Err(msg)
+	default:
+		// DPanic, Panic and Fatal
+		return s.w.Crit(msg)
+	}
+}
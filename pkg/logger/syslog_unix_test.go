@@ -0,0 +1,51 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSeverityFollowsEntryLevel(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The minimum level must not decide the severity every entry is sent at
+	log := NewWithConfig(Config{
+		Level:       InfoLevel,
+		Format:      FormatJSON,
+		Output:      "syslog://" + conn.LocalAddr().String(),
+		ServiceName: "dumper",
+	})
+	log.Info("backup started")
+	log.Warn("slow upload")
+	log.Error("backup failed", "error", "connection refused")
+
+	// Priority is facility*8 + severity, with LOG_DAEMON (3) as the facility
+	want := []struct {
+		priority string
+		message  string
+	}{
+		{"<30>", "backup started"}, // LOG_INFO
+		{"<28>", "slow upload"},    // LOG_WARNING
+		{"<27>", "backup failed"},  // LOG_ERR
+	}
+	buf := make([]byte, 64*1024)
+	for _, w := range want {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("waiting for %q: %v", w.message, err)
+		}
+		packet := string(buf[:n])
+		if !strings.HasPrefix(packet, w.priority) || !strings.Contains(packet, w.message) {
+			t.Errorf("syslog packet %q, want %q sent at priority %s", packet, w.message, w.priority)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle limits repetitive log lines, e.g. progress updates, to one per interval per key
+type Throttle struct {
+	interval time.Duration
+	mu       sync.Mutex
+	last     map[string]time.Time
+}
+
+// NewThrottle returns a Throttle allowing one line per key every interval. A zero interval
+// allows every line.
+func NewThrottle(interval time.Duration) *Throttle {
+	return &Throttle{
+		interval: interval,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// Allow reports whether a line with the given key may be logged now, and if so records it.
+// It is safe for concurrent use.
+func (t *Throttle) Allow(key string) bool {
+	if t == nil || t.interval <= 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := t.last[key]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+	t.last[key] = now
+	return true
+}
@@ -0,0 +1,274 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+// azureBlockSize is the block size of uploads. A block blob has at most 50,000 blocks, so
+// this allows archives of up to ~800 GB.
+const azureBlockSize = 16 * 1024 * 1024
+
+// AzureStore stores backups as block blobs in an Azure Blob Storage container
+type AzureStore struct {
+	client    *azblob.Client
+	container string
+	logger    Logger
+
+	metadata    map[string]string // User metadata stored on every archive
+	progress    ProgressHandler   // Receives upload progress (optional)
+	logInterval time.Duration     // Minimum time between upload progress logs (0 = every 10%)
+}
+
+// NewAzureStore creates an Azure Blob Storage store authenticated with the account's shared key
+func NewAzureStore(cfg DumperConfig) (*AzureStore, error) {
+	credential, err := azblob.NewSharedKeyCredential(cfg.AzureAccount, cfg.AzureAccountKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure credentials: %w", err)
+	}
+
+	endpoint := cfg.AzureEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.AzureAccount)
+	}
+	client, err := azblob.NewClientWithSharedKeyCredential(endpoint, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure Azure client: %w", err)
+	}
+
+	return &AzureStore{
+		client:      client,
+		container:   cfg.AzureContainer,
+		logger:      cfg.logger(),
+		metadata:    releaseMetadata(cfg),
+		progress:    cfg.ProgressHandler,
+		logInterval: cfg.ProgressLogInterval,
+	}, nil
+}
+
+// blobMetadata merges per-object metadata into the release metadata. Azure metadata names
+// must be valid C# identifiers, so dashes become underscores.
+func (a *AzureStore) blobMetadata(extra map[string]string) map[string]*string {
+	merged := maps.Clone(a.metadata)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, extra)
+
+	metadata := make(map[string]*string, len(merged))
+	for name, value := range merged {
+		metadata[strings.ReplaceAll(name, "-", "_")] = to.Ptr(value)
+	}
+	return metadata
+}
+
+// UploadFile uploads a file as a block blob
+func (a *AzureStore) UploadFile(ctx context.Context, filePath string, opts UploadOptions) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file for upload: %w", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	a.logger.Info("Uploading file to Azure",
+		"local_path", filePath,
+		"blob", opts.Key,
+		"container", a.container,
+		"size_bytes", fileInfo.Size())
+
+	progressR := &progressReader{
+		reader:    file,
+		totalSize: fileInfo.Size(),
+		logger:    a.logger,
+		s3Key:     opts.Key,
+		progress:  a.progress,
+		throttle:  progressThrottle(a.logInterval),
+	}
+	_, err = a.client.UploadStream(ctx, a.container, opts.Key, progressR, &azblob.UploadStreamOptions{
+		BlockSize: azureBlockSize,
+		Metadata:  a.blobMetadata(opts.Metadata),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload to Azure: %w", err)
+	}
+
+	a.logger.Info("Successfully uploaded to Azure", "blob", opts.Key)
+	return nil
+}
+
+// UploadStream uploads everything read from r as a block blob, returning the number of
+// bytes uploaded. A read error fails the upload before the block list is committed, so no
+// truncated blob is left behind.
+func (a *AzureStore) UploadStream(ctx context.Context, r io.Reader, opts UploadOptions) (int64, error) {
+	a.logger.Info("Streaming upload to Azure",
+		"blob", opts.Key,
+		"container", a.container)
+
+	counter := &countingReader{reader: r}
+	_, err := a.client.UploadStream(ctx, a.container, opts.Key, counter, &azblob.UploadStreamOptions{
+		BlockSize: azureBlockSize,
+		Metadata:  a.blobMetadata(opts.Metadata),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to stream upload to Azure: %w", err)
+	}
+	return counter.n, nil
+}
+
+// UploadBytes uploads a small in-memory blob
+func (a *AzureStore) UploadBytes(ctx context.Context, data []byte, s3Key, contentType string) error {
+	a.logger.Info("Uploading to Azure",
+		"blob", s3Key,
+		"container", a.container,
+		"size_bytes", len(data))
+
+	_, err := a.client.UploadBuffer(ctx, a.container, s3Key, data, &azblob.UploadBufferOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: to.Ptr(contentType)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload to Azure: %w", err)
+	}
+	return nil
+}
+
+// DownloadFile downloads a blob to a local file
+func (a *AzureStore) DownloadFile(ctx context.Context, s3Key, localPath string) error {
+	a.logger.Info("Downloading from Azure",
+		"blob", s3Key,
+		"local_path", localPath,
+		"container", a.container)
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := a.client.DownloadFile(ctx, a.container, s3Key, file, nil); err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return fmt.Errorf("%s: %w", s3Key, ErrObjectNotFound)
+		}
+		return fmt.Errorf("failed to download from Azure: %w", err)
+	}
+
+	a.logger.Info("Successfully downloaded from Azure",
+		"blob", s3Key,
+		"local_path", localPath)
+	return nil
+}
+
+// DownloadBytes downloads a small blob into memory, returning ErrObjectNotFound if it does not exist
+func (a *AzureStore) DownloadBytes(ctx context.Context, s3Key string) ([]byte, error) {
+	result, err := a.client.DownloadStream(ctx, a.container, s3Key, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, fmt.Errorf("%s: %w", s3Key, ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to download from Azure: %w", err)
+	}
+	defer result.Body.Close()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return data, nil
+}
+
+// ListObjects lists all blobs below a prefix with their size and access tier
+func (a *AzureStore) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	pager := a.client.NewListBlobsFlatPager(a.container, &azblob.ListBlobsFlatOptions{Prefix: to.Ptr(prefix)})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %w", err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			info := ObjectInfo{Key: valueOf(item.Name)}
+			if props := item.Properties; props != nil {
+				info.Size = valueOf(props.ContentLength)
+				info.LastModified = valueOf(props.LastModified)
+				info.StorageClass = string(valueOf(props.AccessTier))
+			}
+			objects = append(objects, info)
+		}
+	}
+	return objects, nil
+}
+
+// ListBackups lists all backups below a prefix
+func (a *AzureStore) ListBackups(ctx context.Context, prefix string) ([]BackupInfo, error) {
+	a.logger.Info("Listing backups", "prefix", prefix)
+
+	objects, err := a.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]BackupInfo, 0, len(objects))
+	for _, object := range objects {
+		backups = append(backups, BackupInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		})
+	}
+	return backups, nil
+}
+
+// DeleteObject removes a blob from the container
+func (a *AzureStore) DeleteObject(ctx context.Context, s3Key string) error {
+	if _, err := a.client.DeleteBlob(ctx, a.container, s3Key, nil); err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// DeleteObjects removes blobs one by one, logging every removed key
+func (a *AzureStore) DeleteObjects(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := a.DeleteObject(ctx, key); err != nil {
+			return err
+		}
+		a.logger.Info("Deleted blob from Azure", "blob", key)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+// Read implements io.Reader
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// valueOf dereferences an optional SDK field, returning the zero value for nil
+func valueOf[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
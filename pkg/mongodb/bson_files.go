@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrCorruptDump is returned when a dump file is truncated or holds malformed BSON
+var ErrCorruptDump = errors.New("corrupt dump file")
+
+// maxBSONDocumentSize bounds the length prefix accepted for a single document. Documents
+// are limited to 16MB, mongodump files may hold slightly larger internal documents.
+const maxBSONDocumentSize = 16*1024*1024 + 16*1024
+
+// CollectionCheck is the result of reading one collection file of a dump
+type CollectionCheck struct {
+	Namespace string // database.collection, or the file name for files outside a database
+	Path      string
+	Documents int64 // Documents read, up to the first corrupt one
+	Err       error // Wraps ErrCorruptDump if the file is truncated or malformed
+}
+
+// checkDumpFiles reads every collection file below a mongodump output directory, plain or
+// gzipped, counting and validating its documents. Corrupt files are reported in their
+// CollectionCheck, the error is only set if the directory can't be walked.
+func checkDumpFiles(dumpDir string) ([]CollectionCheck, error) {
+	var checks []CollectionCheck
+	err := filepath.Walk(dumpDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isDumpDataFile(path) {
+			return nil
+		}
+
+		namespace, err := dumpNamespace(dumpDir, path)
+		if err != nil {
+			return err
+		}
+		check := CollectionCheck{Namespace: namespace, Path: path}
+		check.Documents, check.Err = countBSONDocuments(path)
+		checks = append(checks, check)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump directory: %w", err)
+	}
+	return checks, nil
+}
+
+// dumpNamespaces returns the namespaces of the collection files below a mongodump output directory
+func dumpNamespaces(dumpDir string) ([]string, error) {
+	var namespaces []string
+	err := filepath.Walk(dumpDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isDumpDataFile(path) {
+			return nil
+		}
+		namespace, err := dumpNamespace(dumpDir, path)
+		if err != nil {
+			return err
+		}
+		namespaces = append(namespaces, namespace)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump directory: %w", err)
+	}
+	return namespaces, nil
+}
+
+// isGzippedDump reports whether a mongodump output directory holds .bson.gz files, as
+// written by mongodump --gzip. mongorestore only reads them when given --gzip as well.
+func isGzippedDump(dumpDir string) (bool, error) {
+	gzipped := false
+	err := filepath.Walk(dumpDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".bson.gz") {
+			gzipped = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read dump directory: %w", err)
+	}
+	return gzipped, nil
+}
+
+// dumpNamespace returns the database.collection namespace of a collection file, which
+// mongodump writes to <dumpDir>/<database>/<collection>.bson
+func dumpNamespace(dumpDir, path string) (string, error) {
+	rel, err := filepath.Rel(dumpDir, path)
+	if err != nil {
+		return "", err
+	}
+	namespace := strings.TrimSuffix(strings.TrimSuffix(filepath.ToSlash(rel), ".gz"), ".bson")
+	return strings.Replace(namespace, "/", ".", 1), nil
+}
+
+// countBSONDocuments counts the documents of a .bson or .bson.gz file, validating each one.
+// It returns the documents read so far and an ErrCorruptDump error at the first truncated
+// or malformed document.
+func countBSONDocuments(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var r io.Reader = bufio.NewReader(file)
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s: %v", ErrCorruptDump, path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var count int64
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				return count, nil
+			}
+			return count, fmt.Errorf("%w: %s: truncated after %d documents: %v", ErrCorruptDump, path, count, err)
+		}
+
+		size := binary.LittleEndian.Uint32(header[:])
+		if size < 5 || size > maxBSONDocumentSize {
+			return count, fmt.Errorf("%w: %s: invalid document size %d after %d documents", ErrCorruptDump, path, size, count)
+		}
+		doc := make(bson.Raw, size)
+		copy(doc, header[:])
+		if _, err := io.ReadFull(r, doc[4:]); err != nil {
+			return count, fmt.Errorf("%w: %s: truncated after %d documents: %v", ErrCorruptDump, path, count, err)
+		}
+		if err := doc.Validate(); err != nil {
+			return count, fmt.Errorf("%w: %s: malformed document after %d documents: %v", ErrCorruptDump, path, count, err)
+		}
+		count++
+	}
+}
@@ -0,0 +1,172 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/auth"
+)
+
+// errDiskSpaceUnsupported is returned where free disk space cannot be determined
+var errDiskSpaceUnsupported = errors.New("checking free disk space is only supported on Linux")
+
+// DefaultPreflightTimeout bounds the ping run before every dump, see DumperConfig.SkipPreflight
+const DefaultPreflightTimeout = 10 * time.Second
+
+// authFailedCode is the server error code of rejected credentials
+const authFailedCode = 18
+
+var (
+	// ErrMongoUnreachable is returned when the preflight ping cannot reach the server
+	ErrMongoUnreachable = errors.New("cannot reach MongoDB")
+	// ErrMongoAuthFailed is returned when the server rejects the preflight ping's credentials
+	ErrMongoAuthFailed = errors.New("MongoDB authentication failed")
+)
+
+// checkTimeout bounds each preflight check so a hanging dependency fails fast
+const checkTimeout = 30 * time.Second
+
+// CheckResult is the outcome of a single preflight check
+type CheckResult struct {
+	Name     string
+	Critical bool // A failed critical check blocks the backup
+	Err      error
+	Detail   string
+	Duration time.Duration
+}
+
+// OK reports whether the check passed
+func (r CheckResult) OK() bool {
+	return r.Err == nil
+}
+
+// RunChecks verifies S3 is writable, MongoDB is reachable and the temp directory has
+// enough free space, without doing any heavy work. Every check runs even if one fails.
+func (d *Dumper) RunChecks(ctx context.Context) []CheckResult {
+	checks := []struct {
+		name     string
+		critical bool
+		run      func(context.Context) (string, error)
+	}{
+		{"s3_writable", true, d.checkS3Writable},
+		{"mongo_reachable", true, d.checkMongoReachable},
+		{"disk_space", true, d.checkDiskSpace},
+	}
+
+	results := make([]CheckResult, 0, len(checks))
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		start := time.Now()
+		detail, err := check.run(checkCtx)
+		cancel()
+
+		result := CheckResult{
+			Name:     check.name,
+			Critical: check.critical,
+			Err:      err,
+			Detail:   detail,
+			Duration: time.Since(start),
+		}
+		if result.OK() {
+			d.logger.Info("Check passed",
+				"check", result.Name,
+				"detail", result.Detail,
+				"duration", result.Duration)
+		} else {
+			d.logger.Error("Check failed",
+				"check", result.Name,
+				"critical", result.Critical,
+				"error", result.Err)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// CriticalChecksPassed reports whether none of the critical checks failed
+func CriticalChecksPassed(results []CheckResult) bool {
+	for _, r := range results {
+		if r.Critical && !r.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkS3Writable writes and deletes a small probe object under the environment prefix
+func (d *Dumper) checkS3Writable(ctx context.Context) (string, error) {
+	key := fmt.Sprintf("%s.check-%s", d.config.KeyPrefix(), newRunID())
+	if err := d.store.UploadBytes(ctx, []byte("ok"), key, "text/plain"); err != nil {
+		return "", err
+	}
+	if err := d.store.DeleteObject(ctx, key); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("wrote and deleted %s", key), nil
+}
+
+// checkMongoReachable pings the server with the same connection string mongodump uses
+func (d *Dumper) checkMongoReachable(ctx context.Context) (string, error) {
+	err := withMongoClient(ctx, &d.mongoDump.config, func(client *mongo.Client) error {
+		if err := client.Ping(ctx, nil); err != nil {
+			return fmt.Errorf("failed to ping MongoDB: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "ping succeeded", nil
+}
+
+// preflight pings MongoDB before a dump creates any local files, so an unreachable server or
+// wrong credentials fail the backup right away with a clear error instead of after
+// mongodump's own retries
+func (d *Dumper) preflight(ctx context.Context) error {
+	uri := d.mongoDump.config.MongoURI
+	pingCtx, cancel := context.WithTimeout(ctx, DefaultPreflightTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := withMongoClient(pingCtx, &d.mongoDump.config, func(client *mongo.Client) error {
+		return client.Ping(pingCtx, nil)
+	})
+	if err == nil {
+		d.logger.Debug("MongoDB preflight check passed",
+			"hosts", MongoHosts(uri),
+			"duration", time.Since(start))
+		return nil
+	}
+	// A backup cancelled during the ping is not a connectivity problem
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var authErr *auth.Error
+	var serverErr mongo.ServerError
+	if errors.As(err, &authErr) || (errors.As(err, &serverErr) && serverErr.HasErrorCode(authFailedCode)) {
+		return fmt.Errorf("%w at %s: %v", ErrMongoAuthFailed, MongoHosts(uri), err)
+	}
+	return fmt.Errorf("%w at %s: %v", ErrMongoUnreachable, MongoHosts(uri), err)
+}
+
+// checkDiskSpace verifies the temp directory has at least MinFreeSpace available, like the
+// check before every dump. Without MinFreeSpace the check is skipped.
+func (d *Dumper) checkDiskSpace(ctx context.Context) (string, error) {
+	if d.config.MinFreeSpace == 0 {
+		return "skipped: no minimum free space configured", nil
+	}
+	dir, free, err := d.requireFreeSpace()
+	if errors.Is(err, errDiskSpaceUnsupported) {
+		return "skipped: " + err.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d MB free in %s, need at least %d MB",
+		free/1024/1024, dir, d.config.MinFreeSpace/1024/1024), nil
+}
@@ -0,0 +1,69 @@
+package mongodb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultS3BreakerCooldown is how long the S3 circuit stays open before S3 is probed again
+const DefaultS3BreakerCooldown = 5 * time.Minute
+
+// ErrS3CircuitOpen is returned while S3 is considered down after repeated failures
+var ErrS3CircuitOpen = errors.New("S3 circuit open")
+
+// circuitBreaker stops calling S3 after a number of consecutive failures. Once the cooldown
+// has passed, the next call is let through as a probe: success closes the circuit,
+// failure opens it for another cooldown.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int // Consecutive failures that open the circuit (0 = disabled)
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+}
+
+// newCircuitBreaker creates a breaker, a zero threshold disables it
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if cooldown <= 0 {
+		cooldown = DefaultS3BreakerCooldown
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether S3 may be called, and otherwise how long the circuit stays open
+func (b *circuitBreaker) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true, 0
+	}
+	if remaining := b.cooldown - time.Since(b.openedAt); remaining > 0 {
+		return false, remaining
+	}
+	return true, 0
+}
+
+// record updates the breaker with the result of an S3 call and reports whether the
+// circuit is open afterwards
+func (b *circuitBreaker) record(err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 {
+		return false
+	}
+	if err == nil {
+		b.failures = 0
+		return false
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		// Also restarts the cooldown after a failed probe
+		b.openedAt = time.Now()
+		return true
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// expireCooldown moves the breaker's opening time back so its cooldown has passed
+func expireCooldown(b *circuitBreaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openedAt = b.openedAt.Add(-b.cooldown)
+}
+
+func TestCircuitBreakerOpensAndCloses(t *testing.T) {
+	b := newCircuitBreaker(3, time.Minute)
+	failure := errors.New("503 Service Unavailable")
+
+	for i := 1; i < 3; i++ {
+		if b.record(failure) {
+			t.Fatalf("circuit open after %d failures, threshold is 3", i)
+		}
+		if ok, _ := b.allow(); !ok {
+			t.Fatalf("calls blocked after %d failures", i)
+		}
+	}
+	if !b.record(failure) {
+		t.Fatal("circuit not open after 3 failures")
+	}
+	if ok, remaining := b.allow(); ok || remaining <= 0 || remaining > time.Minute {
+		t.Fatalf("allow() = %v, %v while open, want false with the remaining cooldown", ok, remaining)
+	}
+
+	// After the cooldown one probe goes through, a failure opens the circuit again
+	expireCooldown(b)
+	if ok, _ := b.allow(); !ok {
+		t.Fatal("probe blocked after the cooldown")
+	}
+	if !b.record(failure) {
+		t.Fatal("failed probe did not reopen the circuit")
+	}
+	if ok, _ := b.allow(); ok {
+		t.Fatal("calls allowed right after a failed probe")
+	}
+
+	// A successful probe closes it
+	expireCooldown(b)
+	if ok, _ := b.allow(); !ok {
+		t.Fatal("probe blocked after the second cooldown")
+	}
+	if b.record(nil) {
+		t.Fatal("successful probe left the circuit open")
+	}
+	if ok, _ := b.allow(); !ok {
+		t.Fatal("calls blocked after the circuit closed")
+	}
+	if b.record(failure) {
+		t.Error("a single failure after closing opened the circuit again")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, 0)
+	for i := 0; i < 10; i++ {
+		if b.record(errors.New("timeout")) {
+			t.Fatal("disabled breaker opened")
+		}
+	}
+	if ok, _ := b.allow(); !ok {
+		t.Error("disabled breaker blocked a call")
+	}
+}
+
+func TestDumpSkipsUploadWhileCircuitOpen(t *testing.T) {
+	d, store, _ := newFakeRunnerDumper(t, DumperConfig{S3BreakerThreshold: 2}, map[string]int{"app/users": 1})
+	store.uploadErr = errors.New("connection reset")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := d.Dump(ctx); errors.Is(err, ErrS3CircuitOpen) {
+			t.Fatalf("dump %d: circuit open before the threshold", i+1)
+		}
+	}
+	store.uploadErr = nil
+	err := d.Dump(ctx)
+	if !errors.Is(err, ErrS3CircuitOpen) || !errors.Is(err, ErrStorage) {
+		t.Fatalf("Dump with an open circuit = %v, want ErrS3CircuitOpen", err)
+	}
+	if keys := store.keys(); len(keys) != 0 {
+		t.Errorf("uploaded %v while the circuit was open", keys)
+	}
+
+	expireCooldown(d.s3Breaker)
+	if err := d.Dump(ctx); err != nil {
+		t.Fatalf("probe after the cooldown: %v", err)
+	}
+	if err := d.Dump(ctx); err != nil {
+		t.Errorf("Dump after the circuit closed: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package mongodb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// DefaultStaleTempAge is how old leftover dump directories and archives must be before
+// CleanupStaleTemp removes them
+const DefaultStaleTempAge = 24 * time.Hour
+
+// staleTempRegex matches the local names GenerateBackupFilename produces once the archive
+// extension is trimmed: <db>-<env>-<timestamp>, or run-<id> with short local names
+var staleTempRegex = regexp.MustCompile(`^(.+-.+-\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}Z|run-[0-9a-z]+)$`)
+
+// CleanupStaleTemp removes dump directories and archives a crashed backup left in TempDir,
+// returning the removed paths. Only entries named like this tool's backups and last modified
+// more than maxAge ago are touched, so unrelated files and running backups are left alone.
+func (d *Dumper) CleanupStaleTemp(maxAge time.Duration) ([]string, error) {
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("stale temp age must be positive, got %s", maxAge)
+	}
+
+	dir := d.tempDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read temp directory: %w", err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var removed []string
+	for _, entry := range entries {
+		if !staleTempRegex.MatchString(trimArchiveExtension(entry.Name())) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			d.logger.Warn("Failed to remove stale temp file",
+				"path", path,
+				"error", err)
+			continue
+		}
+		d.logger.Info("Removed stale temp file",
+			"path", path,
+			"modified", info.ModTime())
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
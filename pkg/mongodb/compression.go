@@ -0,0 +1,328 @@
+package mongodb
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported compression codecs
+const (
+	CompressionZip  = "zip"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+	CompressionNone = "none" // Plain tar, for dumps mongodump already compressed
+)
+
+// CompressionCodec turns a dump directory into a single archive file and back
+type CompressionCodec interface {
+	// Compress writes an archive of every file below srcDir to dst
+	Compress(srcDir, dst string) error
+	// Decompress extracts an archive written by Compress into dstDir
+	Decompress(src, dstDir string) error
+	// Extension is appended to the backup name for the archive, e.g. ".zip"
+	Extension() string
+}
+
+// DefaultCompressBufferSize is the copy and write buffer size used when creating archives
+const DefaultCompressBufferSize = 32 * 1024
+
+// newCompressionCodec returns the codec for a configured name, zip when empty
+func (d *Dumper) newCompressionCodec(name string) (CompressionCodec, error) {
+	switch strings.ToLower(name) {
+	case "", CompressionZip:
+		return &zipCodec{dumper: d}, nil
+	case CompressionGzip:
+		return &tarCodec{
+			dumper:    d,
+			extension: ".tar.gz",
+			newWriter: func(w io.Writer) (io.WriteCloser, error) {
+				if d.config.CompressionLevel == 0 {
+					return gzip.NewWriter(w), nil
+				}
+				return gzip.NewWriterLevel(w, d.config.CompressionLevel)
+			},
+			newReader: func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+		}, nil
+	case CompressionZstd:
+		level := zstd.SpeedDefault
+		if d.config.ZstdLevel != 0 {
+			level = zstd.EncoderLevel(d.config.ZstdLevel)
+		}
+		return &tarCodec{
+			dumper:    d,
+			extension: ".tar.zst",
+			newWriter: func(w io.Writer) (io.WriteCloser, error) {
+				return zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+			},
+			newReader: func(r io.Reader) (io.ReadCloser, error) {
+				decoder, err := zstd.NewReader(r)
+				if err != nil {
+					return nil, err
+				}
+				return decoder.IOReadCloser(), nil
+			},
+		}, nil
+	case CompressionNone:
+		return &tarCodec{
+			dumper:    d,
+			extension: ".tar",
+			newWriter: func(w io.Writer) (io.WriteCloser, error) {
+				return nopWriteCloser{w}, nil
+			},
+			newReader: func(r io.Reader) (io.ReadCloser, error) {
+				return io.NopCloser(r), nil
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q, supported: zip, gzip, zstd, none", name)
+	}
+}
+
+// nopWriteCloser adds a no-op Close to a writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// codecForPath detects the codec of an archive from its file name or S3 key
+func (d *Dumper) codecForPath(path string) (CompressionCodec, error) {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return d.newCompressionCodec(CompressionZip)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return d.newCompressionCodec(CompressionGzip)
+	case strings.HasSuffix(lower, ".tar.zst"):
+		return d.newCompressionCodec(CompressionZstd)
+	case strings.HasSuffix(lower, ".tar"):
+		return d.newCompressionCodec(CompressionNone)
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q, supported: .zip, .tar.gz, .tar.zst, .tar", filepath.Base(path))
+	}
+}
+
+// createArchiveFile creates or truncates an archive in the temp directory. Like the dump it is
+// made from, it is only readable by the owner unless TempDirMode says otherwise.
+func (d *Dumper) createArchiveFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, d.config.tempFileMode())
+}
+
+// trimArchiveExtension removes a known archive extension from a file name or S3 key
+func trimArchiveExtension(name string) string {
+	for _, ext := range []string{".zip", ".tar.gz", ".tgz", ".tar.zst", ".tar", ".archive", ".archive.gz"} {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
+
+// isDumpDataFile reports whether a dump file holds collection data, plain or gzipped by mongodump
+func isDumpDataFile(path string) bool {
+	return strings.HasSuffix(path, ".bson") || strings.HasSuffix(path, ".bson.gz")
+}
+
+// zipCodec writes Deflate-compressed zip archives, the original backup format
+type zipCodec struct {
+	dumper *Dumper
+}
+
+// Compress writes a zip archive of srcDir
+func (c *zipCodec) Compress(srcDir, dst string) error {
+	if c.dumper.config.CompressionWorkers > 1 {
+		return c.dumper.compressFileParallel(srcDir, dst)
+	}
+	return c.dumper.compressFile(srcDir, dst)
+}
+
+// Decompress extracts a zip archive into dstDir
+func (c *zipCodec) Decompress(src, dstDir string) error {
+	return c.dumper.extractZip(src, dstDir)
+}
+
+// Extension returns ".zip"
+func (c *zipCodec) Extension() string {
+	return ".zip"
+}
+
+// tarCodec writes tar archives through a stream compressor such as gzip or zstd
+type tarCodec struct {
+	dumper    *Dumper
+	extension string
+	newWriter func(io.Writer) (io.WriteCloser, error)
+	newReader func(io.Reader) (io.ReadCloser, error)
+}
+
+// Extension returns the codec's archive extension
+func (c *tarCodec) Extension() string {
+	return c.extension
+}
+
+// Compress writes a compressed tar archive of srcDir
+func (c *tarCodec) Compress(srcDir, dst string) (err error) {
+	file, err := c.dumper.createArchiveFile(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	output := bufio.NewWriterSize(file, c.dumper.compressBufferSize())
+
+	compressor, err := c.newWriter(output)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to create compressor: %w", err)
+	}
+	tarWriter := tar.NewWriter(compressor)
+
+	// The archive is only complete once the tar footer and compressor are flushed to the file
+	defer func() {
+		if closeErr := tarWriter.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to finalize tar archive: %w", closeErr)
+		}
+		if closeErr := compressor.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to finalize compression: %w", closeErr)
+		}
+		if flushErr := output.Flush(); flushErr != nil && err == nil {
+			err = fmt.Errorf("failed to write archive file: %w", flushErr)
+		}
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close archive file: %w", closeErr)
+		}
+	}()
+
+	err = filepath.Walk(srcDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(srcDir, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %w", filePath, err)
+		}
+
+		// Never read through a symlink, either skip it or store the link itself
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if !c.dumper.config.StoreSymlinks {
+				c.dumper.logger.Warn("Skipping symlink in backup directory", "path", filePath)
+				return nil
+			}
+			if link, err = os.Readlink(filePath); err != nil {
+				return fmt.Errorf("failed to read symlink %s: %w", filePath, err)
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("failed to create header for %s: %w", filePath, err)
+		}
+		header.Name = filepath.ToSlash(relPath)
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write tar header for %s: %w", filePath, err)
+		}
+		if link != "" {
+			return nil
+		}
+
+		src, err := os.Open(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to open file %s: %w", filePath, err)
+		}
+		defer src.Close()
+
+		buffer := make([]byte, c.dumper.compressBufferSize())
+		if _, err := io.CopyBuffer(tarWriter, src, buffer); err != nil {
+			return fmt.Errorf("failed to write %s to archive: %w", filePath, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	return nil
+}
+
+// Decompress extracts a compressed tar archive into dstDir, rejecting entries that would
+// escape it. Stored symlinks are skipped, mongorestore only needs the regular dump files.
+func (c *tarCodec) Decompress(src, dstDir string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	decompressor, err := c.newReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to create decompressor: %w", err)
+	}
+	defer decompressor.Close()
+
+	tarReader := tar.NewReader(decompressor)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		target := filepath.Join(dstDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dstDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, c.dumper.config.tempDirMode()); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := extractFile(tarReader, target, c.dumper.config.tempDirMode()); err != nil {
+				return err
+			}
+		default:
+			c.dumper.logger.Warn("Skipping non-regular file in archive", "path", header.Name)
+		}
+	}
+}
+
+// extractFile writes the content of r to target, creating parent directories with dirMode
+// as needed. The file gets dirMode without execute bits.
+func extractFile(r io.Reader, target string, dirMode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), dirMode); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", target, err)
+	}
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, dirMode&^0111)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	defer dst.Close()
+
+	buffer := make([]byte, 32*1024)
+	if _, err := io.CopyBuffer(dst, r, buffer); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", target, err)
+	}
+
+	return nil
+}
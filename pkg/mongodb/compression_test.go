@@ -0,0 +1,166 @@
+package mongodb
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// archiveEntry is a file of an archive, with the target of symlink entries
+type archiveEntry struct {
+	symlink bool
+	link    string
+}
+
+// readArchiveEntries lists the entries of a zip or .tar.gz archive by slash-separated name
+func readArchiveEntries(t *testing.T, path string) map[string]archiveEntry {
+	t.Helper()
+	entries := map[string]archiveEntry{}
+
+	if strings.HasSuffix(path, ".zip") {
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		for _, file := range reader.File {
+			entry := archiveEntry{symlink: file.Mode()&os.ModeSymlink != 0}
+			if entry.symlink {
+				rc, err := file.Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				target, _ := io.ReadAll(rc)
+				rc.Close()
+				entry.link = string(target)
+			}
+			entries[filepath.ToSlash(file.Name)] = entry
+		}
+		return entries
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[header.Name] = archiveEntry{symlink: header.Typeflag == tar.TypeSymlink, link: header.Linkname}
+	}
+}
+
+func TestCompressSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs privileges on Windows")
+	}
+
+	// A symlink to a file outside the dump must never be read through
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("not part of the backup"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "app", "users.bson"), bsonDocuments(2), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(srcDir, "app", "secret.bson")); err != nil {
+		t.Fatal(err)
+	}
+
+	codecs := []struct {
+		name   string
+		config DumperConfig
+	}{
+		{"zip", DumperConfig{Compression: CompressionZip}},
+		{"parallel zip", DumperConfig{Compression: CompressionZip, CompressionWorkers: 2}},
+		{"tar.gz", DumperConfig{Compression: CompressionGzip}},
+	}
+	for _, codec := range codecs {
+		for _, store := range []bool{false, true} {
+			cfg := codec.config
+			cfg.StoreSymlinks = store
+			log := &recordingLogger{}
+			cfg.Log = log
+			d := newTestDumper(t, cfg, newFakeStore())
+
+			archive := filepath.Join(t.TempDir(), "backup"+d.codec.Extension())
+			if err := d.codec.Compress(srcDir, archive); err != nil {
+				t.Fatalf("%s, StoreSymlinks=%v: %v", codec.name, store, err)
+			}
+			entries := readArchiveEntries(t, archive)
+
+			if entry, ok := entries["app/users.bson"]; !ok || entry.symlink {
+				t.Errorf("%s, StoreSymlinks=%v: regular file missing or not regular: %v", codec.name, store, entries)
+			}
+			link, stored := entries["app/secret.bson"]
+			switch {
+			case !store && stored:
+				t.Errorf("%s: symlink archived with StoreSymlinks=false", codec.name)
+			case !store && !log.contains("WARN Skipping symlink in backup directory", "secret.bson"):
+				t.Errorf("%s: skipped symlink not logged:\n%s", codec.name, log.String())
+			case store && (!link.symlink || link.link != outside):
+				t.Errorf("%s: symlink stored as %+v, want a link to %s", codec.name, link, outside)
+			}
+		}
+	}
+}
+
+// writeBenchmarkDump writes a dump of a few collections to a temp directory and returns it
+// with its total size
+func writeBenchmarkDump(b *testing.B) (string, int64) {
+	b.Helper()
+	dir := b.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "app"), 0o755); err != nil {
+		b.Fatal(err)
+	}
+	var size int64
+	for _, collection := range []string{"users", "orders", "events", "sessions"} {
+		data := bsonDocuments(50000)
+		if err := os.WriteFile(filepath.Join(dir, "app", collection+".bson"), data, 0o644); err != nil {
+			b.Fatal(err)
+		}
+		size += int64(len(data))
+	}
+	return dir, size
+}
+
+func BenchmarkCompress(b *testing.B) {
+	srcDir, size := writeBenchmarkDump(b)
+	for _, compression := range []string{CompressionZip, CompressionGzip, CompressionZstd} {
+		for _, bufferSize := range []int{4 * 1024, DefaultCompressBufferSize, 256 * 1024, 1024 * 1024} {
+			b.Run(fmt.Sprintf("%s/buffer=%dKB", compression, bufferSize/1024), func(b *testing.B) {
+				d := newTestDumper(b, DumperConfig{Compression: compression, CompressBufferSize: bufferSize}, newFakeStore())
+				archive := filepath.Join(b.TempDir(), "backup"+d.codec.Extension())
+				b.SetBytes(size)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := d.codec.Compress(srcDir, archive); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -1,21 +1,134 @@
 package mongodb
 
 import (
+	"compress/flate"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
 	"go.uber.org/zap"
 )
 
+// Version identifies the dumper build, set with -ldflags "-X dumper/pkg/mongodb.Version=v1.2.3"
+var Version = "dev"
+
 // ErrMongoDumpNotFound is returned when the mongodump executable is not found in PATH
 var ErrMongoDumpNotFound = errors.New("mongodump executable not found in PATH")
 
+// SuccessMarkerName is the object written below a backup's prefix once it is complete
+const SuccessMarkerName = "_SUCCESS"
+
+// DefaultTempDirMode restricts temporary dump directories to their owner
+const DefaultTempDirMode os.FileMode = 0700
+
+// Object storage providers selected by DumperConfig.Provider
+const (
+	ProviderS3    = "s3"         // Any S3-compatible service, e.g. Backblaze B2 or AWS S3
+	ProviderGCS   = "gcs"        // Google Cloud Storage through its S3-compatible XML API
+	ProviderAzure = "azure"      // Azure Blob Storage
+	ProviderFS    = "filesystem" // A local directory or network mount, see LocalDir
+)
+
+// DefaultGCSEndpoint is Google Cloud Storage's S3-compatible XML API
+const DefaultGCSEndpoint = "https://storage.googleapis.com"
+
+// DefaultModifiedSinceField is the timestamp field used for incremental dumps when none is configured
+const DefaultModifiedSinceField = "updatedAt"
+
+// IncrementalInfoFile is written into the dump directory of incremental dumps
+const IncrementalInfoFile = "incremental.json"
+
 // DumperConfig contains configuration for MongoDB backup
 type DumperConfig struct {
 	// MongoDB connection details
 	MongoURI    string
 	Database    string
 	Environment string // "staging" or "production"
+	ReplicaSet  string // Added to the URI as replicaSet (optional)
+	AuthSource  string // Added to the URI as authSource, or passed with the credentials below (optional)
+
+	// Credentials passed to mongodump as --username, --password and --authenticationMechanism
+	// instead of being embedded in the URI, so they don't end up in logs (optional). They
+	// cannot be combined with credentials in the URI.
+	Username      string
+	Password      string
+	AuthMechanism string // e.g. SCRAM-SHA-256 or MONGODB-X509 (default: negotiated)
+
+	// TLS for MongoDB connections, passed to mongodump as --ssl with --sslCAFile,
+	// --sslPEMKeyFile and --tlsInsecure. The driver's own connections, e.g. the preflight
+	// ping, use the same settings. Setting any of them enables TLS.
+	TLSCAFile      string // PEM file with the CA certificates that verify the server
+	TLSCertKeyFile string // PEM file with the client certificate and its private key (mTLS)
+	TLSInsecure    bool   // Skip verification of the server certificate and host name
+
+	// ReadPreference directs the dump to other replica set members via --readPreference,
+	// e.g. "secondary" or "secondaryPreferred" to keep the load off the primary. It requires
+	// a replica set URI and applies to the driver's reads, such as document counts, as well.
+	// One of primary, primaryPreferred, secondary, secondaryPreferred or nearest.
+	ReadPreference string
+
+	// Collections limits the dump to these collections (requires Database)
+	Collections []string
+
+	// PerDatabase backs up every user database of the server (all but admin, config and
+	// local) as its own archive, DatabaseConcurrency (default DefaultDatabaseConcurrency) at
+	// once, instead of a single full-server dump. Requires no Database to be set.
+	PerDatabase         bool
+	DatabaseConcurrency int
+
+	// VerifyCounts counts the documents of every dumped collection before the dump and fails
+	// the backup if the counts mongodump reports differ by more than CountTolerance, a
+	// fraction of the counted documents (default DefaultCountTolerance), to catch truncated
+	// dumps. Counting can be slow on large collections.
+	VerifyCounts   bool
+	CountTolerance float64
+
+	// MongodumpGzip passes --gzip so mongodump writes .bson.gz files directly, reducing temp
+	// disk usage. The already compressed dump is then archived as an uncompressed tarball.
+	MongodumpGzip bool
+
+	// UseOplog dumps with --oplog for a point-in-time consistent snapshot of a replica set,
+	// writing oplog.bson next to the database directories; restores then replay it. mongodump
+	// only supports this for full-server dumps, so it cannot be combined with a Database.
+	UseOplog bool
+
+	// ExcludeCollections skips these collections of Database via --excludeCollection, for
+	// ephemeral data such as sessions or logs. Cannot be combined with an include list.
+	ExcludeCollections []string
+
+	// ExcludeCollectionPrefixes skips every collection of Database starting with one of these
+	// prefixes, e.g. "tmp_" or "cache_", via --excludeCollectionsWithPrefix. Like
+	// ExcludeCollections it cannot be combined with an include list.
+	ExcludeCollectionPrefixes []string
+
+	// IncludeCollectionRegex adds every collection of Database whose name matches the
+	// pattern, resolved against the live collection list when the dump starts
+	IncludeCollectionRegex string
+
+	// ModifiedSince dumps only documents whose ModifiedSinceField is >= this time.
+	// This only works for collections that carry such a timestamp (ideally indexed),
+	// so it requires Collections or IncludeCollectionRegex. Documents deleted since the
+	// previous backup are not captured by an incremental dump.
+	ModifiedSince      time.Time
+	ModifiedSinceField string // Defaults to DefaultModifiedSinceField
+
+	// Query is an extended JSON filter passed to mongodump as --query, e.g.
+	// {"createdAt": {"$gte": {"$date": "2024-01-01T00:00:00Z"}}}. It is applied to every
+	// collection in Collections, so it requires an explicit collection.
+	Query string
+
+	// Provider selects where backups are stored: ProviderS3 (default), ProviderGCS,
+	// ProviderAzure or ProviderFS. Each provider reads its own settings below.
+	Provider string
 
 	// S3/Backblaze configuration
 	S3Endpoint  string
@@ -24,32 +137,753 @@ type DumperConfig struct {
 	S3AccessKey string
 	S3SecretKey string
 
-	// Local temporary storage
+	// UseDefaultCredentials resolves S3 credentials with the standard AWS chain (environment,
+	// shared config and credentials files, EC2/ECS instance roles) instead of the static
+	// S3AccessKey and S3SecretKey, which must then be empty. AWSProfile selects a shared
+	// config profile and implies the default chain.
+	UseDefaultCredentials bool
+	AWSProfile            string
+
+	// Google Cloud Storage bucket and HMAC key, used through the XML API at GCSEndpoint
+	// (default DefaultGCSEndpoint)
+	GCSBucket       string
+	GCSHMACAccessID string
+	GCSHMACSecret   string
+	GCSEndpoint     string
+
+	// Azure Blob Storage account, shared key and container. AzureEndpoint overrides the
+	// account's blob service URL, e.g. for Azurite.
+	AzureAccount    string
+	AzureAccountKey string
+	AzureContainer  string
+	AzureEndpoint   string
+
+	// LocalDir is the directory the filesystem provider stores backups in, laid out like the
+	// keys in a bucket
+	LocalDir string
+
+	// S3SDKMaxAttempts sets the AWS SDK retryer's max attempts per request (0 = SDK default of 3).
+	// Each attempt re-sends the whole request, so any application-level retry on top of
+	// this multiplies: N application retries x M SDK attempts requests in the worst case.
+	S3SDKMaxAttempts int
+
+	// MaxRetries retries uploads, downloads and listings that failed with a 5xx or network
+	// error, waiting RetryBaseDelay (default DefaultRetryBaseDelay) doubled per attempt plus jitter
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+
+	// S3WarmUp sends a HeadBucket request before each archive upload, so connection setup
+	// is not counted in the upload duration and mb_per_sec
+	S3WarmUp bool
+
+	// StorageClass stores uploaded backups in this S3 storage class, e.g. STANDARD_IA or
+	// GLACIER (default: the provider's default class)
+	StorageClass string
+
+	// ServerSideEncryption asks the provider to encrypt uploaded backups at rest: AES256 or
+	// aws:kms with the key SSEKMSKeyID (default: the bucket's setting)
+	ServerSideEncryption string
+	SSEKMSKeyID          string
+
+	// VerifyChecksum sends each uploaded file's MD5 as Content-MD5, so S3 rejects corrupted
+	// uploads, and compares it with the stored object's ETag afterwards
+	VerifyChecksum bool
+
+	// S3BreakerThreshold opens a circuit breaker after this many consecutive failed uploads
+	// (0 = disabled). While open, uploads are not attempted until S3BreakerCooldown has
+	// passed; S3BreakerSkipBackup also skips the dump so no work is wasted during an outage.
+	S3BreakerThreshold  int
+	S3BreakerCooldown   time.Duration // Defaults to DefaultS3BreakerCooldown
+	S3BreakerSkipBackup bool
+
+	// ParallelDownload downloads archives for restore with concurrent ranged GETs and
+	// verifies the assembled file against the checksum stored in the object metadata
+	ParallelDownload    bool
+	DownloadPartSize    int64 // Bytes per ranged GET (default DefaultDownloadPartSize)
+	DownloadConcurrency int   // Parts downloaded at once (default DefaultDownloadConcurrency)
+
+	// RestoreDryRun validates a backup instead of restoring it: every collection file is read
+	// and counted, then mongorestore runs with --dryRun so nothing is written
+	RestoreDryRun bool
+
+	// RestoreNamespaces limits a restore to the matching namespaces via --nsInclude, e.g.
+	// "app.users" or "app.*", so healthy collections aren't overwritten. '*' matches any
+	// characters.
+	RestoreNamespaces []string
+
+	// RestoreNSFrom and RestoreNSTo rename namespaces while restoring via --nsFrom and --nsTo,
+	// e.g. "prod.*" to "prod_restore_test.*", so a restore can be tested next to the original
+	// data. Both must be set together.
+	RestoreNSFrom string
+	RestoreNSTo   string
+
+	// RetentionAge is how long backups are kept. When set, archives are tagged with
+	// created-date and the computed expire-date so lifecycle and cost tooling can age them,
+	// and PruneBackups deletes older backups.
+	RetentionAge time.Duration
+
+	// RetentionCount is how many of the newest backups PruneBackups keeps (0 = unlimited)
+	RetentionCount int
+
+	// KeyTemplate is a text/template for backup keys with the variables of KeyTemplateData,
+	// e.g. "backups/{{.DB}}/{{.Timestamp}}" (default DefaultKeyTemplate). It must contain
+	// {{.Timestamp}}. Archive extensions and pipelined files are appended to the rendered key.
+	KeyTemplate string
+
+	// KeyLowercase lowercases generated S3 key components for providers that treat keys
+	// case-insensitively. Characters outside [A-Za-z0-9._-] are always replaced with '-'.
+	KeyLowercase bool
+
+	// ReleaseSHA and ReleaseVersion identify the application release whose data is backed up,
+	// stored as archive metadata to correlate data-shape changes with deploys (optional)
+	ReleaseSHA     string
+	ReleaseVersion string
+
+	// PipelineUploads uploads each collection's dump files, uncompressed, below the backup's
+	// S3 prefix as soon as mongodump finishes it, instead of zipping the whole dump first
+	PipelineUploads   bool
+	UploadConcurrency int // Files uploaded at once in pipelined mode (default DefaultUploadConcurrency)
+
+	// StreamToS3 pipes mongodump --archive output straight into a multipart upload, skipping
+	// the local temp directory and archive step. Needs no local disk space for the dump.
+	StreamToS3 bool
+
+	// StorageRates overrides the monthly USD price per GB by storage class used for cost
+	// estimates (defaults to DefaultStorageRates)
+	StorageRates map[string]float64
+
+	// WriteSuccessMarker writes an empty {backup prefix}/_SUCCESS object once everything of a
+	// backup is uploaded; listings then treat backups without the marker as incomplete
+	WriteSuccessMarker bool
+
+	// Compression selects the archive codec: zip (default), gzip (.tar.gz), zstd (.tar.zst)
+	// or none (.tar)
+	Compression string
+	ZstdLevel   int // zstd encoder level from 1 (fastest) to 4 (best), 0 = default
+
+	// CompressionWorkers deflates this many files of a zip archive at once (0 or 1 = serial).
+	// Each worker needs temp space next to the archive for the file it compresses. Tar based
+	// codecs are a single stream and always compress serially.
+	CompressionWorkers int
+
+	// CompressBufferSize is the size of the buffers used to copy dump files into the archive
+	// and to write the archive file (default DefaultCompressBufferSize)
+	CompressBufferSize int
+
+	// CompressionLevel is the Deflate level for zip and gzip, from flate.BestSpeed (1) to
+	// flate.BestCompression (9). 0 keeps flate.DefaultCompression.
+	CompressionLevel int
+
+	// Local temporary storage (default os.TempDir())
 	TempDir string
 
-	// Logger
+	// TempDirMode is the permission of TempDir and the dump and restore directories created
+	// in it (default DefaultTempDirMode). Archives and other files written there get the same
+	// bits without execute, 0600 by default. Dumps hold the full contents of the database, so
+	// only the owner should be able to read them; directories mongodump writes into are
+	// created first, so the files it creates itself are protected by their directory.
+	TempDirMode os.FileMode
+
+	// SkipIfUnchangedQuery skips the backup when a cheap query (max SkipIfUnchangedField plus the
+	// estimated count of SkipIfUnchangedCollection) returns the same token as the previous backup.
+	// The token is stored in S3 as <environment>/<database>.change-token.
+	SkipIfUnchangedQuery      bool
+	SkipIfUnchangedCollection string
+	SkipIfUnchangedField      string // Defaults to DefaultChangeTokenField
+
+	// ShortLocalNames names local dump directories with a short run ID instead of the
+	// descriptive backup name, which is still used for the S3 key
+	ShortLocalNames bool
+
+	// UploadDumpLog uploads the captured mongodump output (URI redacted, last 1MB)
+	// as a <backup>.log object alongside the archive
+	UploadDumpLog bool
+
+	// StoreSymlinks stores symlinks found in the dump directory as symlink entries in the
+	// archive instead of skipping them with a warning (the default)
+	StoreSymlinks bool
+
+	// MongodumpPath is the mongodump executable to run, e.g. one of several installed Database
+	// Tools versions (default: mongodump looked up in PATH)
+	MongodumpPath string
+
+	// mongodump tuning
+	ForceTableScan bool // Pass --forceTableScan to mongodump (slow, bypasses indexes)
+
+	// ParallelCollections is passed as --numParallelCollections, the number of collections
+	// mongodump dumps at once (0 = mongodump's default of 4)
+	ParallelCollections int
+
+	// Nice is the scheduling priority mongodump runs at, from -20 (highest) to 19 (lowest).
+	// 0 leaves it unchanged. Only supported on Linux.
+	Nice int
+
+	// Connection timeouts, applied as connectTimeoutMS, socketTimeoutMS and
+	// serverSelectionTimeoutMS URI options (0 = driver default)
+	ConnectTimeout         time.Duration
+	SocketTimeout          time.Duration
+	ServerSelectionTimeout time.Duration
+
+	// CheckServerVersion makes CheckVersions compare the mongodump version with the server's
+	// buildInfo version and warn if the tools don't support the server
+	CheckServerVersion bool
+
+	// MinFreeSpace is the free space in bytes the temp directory needs before a dump starts
+	// (0 = no check). FreeSpaceFloor aborts a running dump and removes its files once free
+	// space drops below it (0 = not watched). Both are only checked on Linux.
+	MinFreeSpace   uint64
+	FreeSpaceFloor uint64
+
+	// SkipPreflight disables the ping that checks MongoDB is reachable and accepts the
+	// credentials before every dump, bounded by DefaultPreflightTimeout
+	SkipPreflight bool
+
+	// Timeout bounds a whole backup (0 = no limit). When it passes, mongodump and S3 calls are
+	// cancelled and the partial local files are removed.
+	Timeout time.Duration
+
+	// MaxConsecutiveFailures is how many scheduled backups may fail in a row before a
+	// long-running service gives up and exits non-zero (0 = never exit on failures)
+	MaxConsecutiveFailures int
+
+	// HeartbeatInterval is how often a long-running service logs that it is alive (0 = disabled)
+	HeartbeatInterval time.Duration
+
+	// StatsWindow is the number of recent successful backups Stats summarizes
+	// (default DefaultStatsWindow)
+	StatsWindow int
+
+	// RatioDeviation warns when a backup's compression ratio differs from the average of the
+	// recent backups in the stats window by more than this fraction, e.g. 0.3 for 30%. A sudden
+	// change often means a collection was emptied or duplicated. 0 disables the check.
+	RatioDeviation float64
+
+	// Metrics receives the outcome of every backup (optional)
+	Metrics Metrics
+
+	// ProgressHandler receives dump, compression and upload progress in addition to the
+	// progress logs (optional)
+	ProgressHandler ProgressHandler
+
+	// ProgressLogInterval logs dump and upload progress at most this often instead of every
+	// 10%, the 100% line is always logged (0 = every 10%)
+	ProgressLogInterval time.Duration
+
+	// Logger is optional, without it (or Log) nothing is logged
 	Logger *zap.Logger // Keep this as zap.Logger for backward compatibility
+
+	// Log receives the package's logs instead of Logger, e.g. a *logger.Logger or a
+	// consumer's own implementation (optional)
+	Log Logger
+}
+
+// logger returns Log, or Logger adapted to the Logger interface. Without either, logs are
+// discarded.
+func (c *DumperConfig) logger() Logger {
+	if c.Log != nil {
+		return c.Log
+	}
+	return ZapLogger(c.Logger)
 }
 
 // Validate checks if the configuration is valid
 func (c *DumperConfig) Validate() error {
-	// Check for required fields
+	if err := c.validateConnection(); err != nil {
+		return err
+	}
+
+	if err := c.validateProvider(); err != nil {
+		return err
+	}
+
+	if c.S3SDKMaxAttempts < 0 {
+		return errors.New("S3 SDK max attempts must be at least 1")
+	}
+
+	if c.StorageClass != "" && !slices.Contains(types.StorageClass("").Values(), types.StorageClass(c.StorageClass)) {
+		return fmt.Errorf("unsupported storage class %q, supported: %v", c.StorageClass, types.StorageClass("").Values())
+	}
+
+	if c.ServerSideEncryption != "" {
+		sse := types.ServerSideEncryption(c.ServerSideEncryption)
+		if !slices.Contains(sse.Values(), sse) {
+			return fmt.Errorf("unsupported server-side encryption %q, supported: %v", c.ServerSideEncryption, sse.Values())
+		}
+		if sse != types.ServerSideEncryptionAes256 && c.SSEKMSKeyID == "" {
+			return fmt.Errorf("server-side encryption %s requires a KMS key id", c.ServerSideEncryption)
+		}
+		// The ETag of an SSE-KMS object is not the MD5 of its content
+		if sse != types.ServerSideEncryptionAes256 && c.VerifyChecksum {
+			return errors.New("checksum verification is not possible with SSE-KMS")
+		}
+	} else if c.SSEKMSKeyID != "" {
+		return errors.New("a KMS key id requires server-side encryption aws:kms")
+	}
+
+	if c.MaxRetries < 0 || c.RetryBaseDelay < 0 {
+		return errors.New("S3 retries and retry delay cannot be negative")
+	}
+
+	for class, rate := range c.StorageRates {
+		if rate < 0 {
+			return fmt.Errorf("storage rate for %s cannot be negative", class)
+		}
+	}
+
+	if c.S3BreakerThreshold < 0 || c.S3BreakerCooldown < 0 {
+		return errors.New("S3 circuit breaker threshold and cooldown cannot be negative")
+	}
+
+	switch strings.ToLower(c.Compression) {
+	case "", CompressionZip, CompressionGzip, CompressionZstd, CompressionNone:
+	default:
+		return fmt.Errorf("unsupported compression %q, supported: zip, gzip, zstd, none", c.Compression)
+	}
+
+	if c.MongodumpGzip && c.Compression != "" && !strings.EqualFold(c.Compression, CompressionNone) {
+		return errors.New("mongodump gzip already compresses the dump, remove the archive compression")
+	}
+
+	if c.CompressionLevel < 0 || c.CompressionLevel > flate.BestCompression {
+		return fmt.Errorf("compression level must be between %d and %d", flate.BestSpeed, flate.BestCompression)
+	}
+
+	if c.CompressionWorkers < 0 {
+		return errors.New("compression workers cannot be negative")
+	}
+
+	if c.ProgressLogInterval < 0 {
+		return errors.New("progress log interval cannot be negative")
+	}
+
+	if c.CompressBufferSize < 0 {
+		return errors.New("compress buffer size cannot be negative")
+	}
+
+	if c.ZstdLevel < 0 || c.ZstdLevel > 4 {
+		return errors.New("zstd level must be between 1 and 4")
+	}
+
+	if c.UploadConcurrency < 0 {
+		return errors.New("upload concurrency cannot be negative")
+	}
+
+	if c.DownloadPartSize < 0 || c.DownloadConcurrency < 0 {
+		return errors.New("download part size and concurrency cannot be negative")
+	}
+
+	if c.RetentionAge < 0 || c.RetentionCount < 0 {
+		return errors.New("retention age and count cannot be negative")
+	}
+
+	if c.ParallelCollections < 0 {
+		return errors.New("parallel collections must be positive")
+	}
+
+	if c.Nice < -20 || c.Nice > 19 {
+		return errors.New("nice level must be between -20 and 19")
+	}
+
+	if c.MaxConsecutiveFailures < 0 {
+		return errors.New("max consecutive failures cannot be negative")
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("backup timeout cannot be negative")
+	}
+
+	if c.HeartbeatInterval < 0 {
+		return errors.New("heartbeat interval cannot be negative")
+	}
+
+	if c.MinFreeSpace > 0 && c.FreeSpaceFloor > c.MinFreeSpace {
+		return errors.New("the free space floor cannot be above the minimum free space")
+	}
+
+	if c.TempDirMode&^os.ModePerm != 0 || (c.TempDirMode != 0 && c.TempDirMode&0700 != 0700) {
+		return fmt.Errorf("temp directory mode %#o must be a permission including owner rwx (0700)", c.TempDirMode)
+	}
+
+	if c.StatsWindow < 0 {
+		return errors.New("stats window cannot be negative")
+	}
+	if c.RatioDeviation < 0 {
+		return errors.New("compression ratio deviation cannot be negative")
+	}
+
+	if (len(c.Collections) > 0 || c.IncludeCollectionRegex != "") && c.Database == "" {
+		return errors.New("a database is required when dumping specific collections")
+	}
+
+	if c.UseOplog && (c.Database != "" || uriDatabase(c.MongoURI) != "") {
+		return errors.New("oplog backups require dumping the full server, remove the database")
+	}
+
+	if len(c.ExcludeCollections) > 0 || len(c.ExcludeCollectionPrefixes) > 0 {
+		if len(c.Collections) > 0 || c.IncludeCollectionRegex != "" {
+			return errors.New("collections cannot be both included and excluded")
+		}
+		if c.Database == "" {
+			return errors.New("a database is required when excluding collections")
+		}
+	}
+
+	if err := c.validateRestore(); err != nil {
+		return err
+	}
+
+	if c.ReadPreference != "" {
+		if _, err := readpref.ModeFromString(c.ReadPreference); err != nil {
+			return fmt.Errorf("invalid read preference %q, use primary, primaryPreferred, secondary, secondaryPreferred or nearest", c.ReadPreference)
+		}
+		if strings.Contains(strings.ToLower(c.MongoURI), "readpreference=") {
+			return errors.New("the read preference is set both in the URI and separately, use only one")
+		}
+	}
+
+	if err := c.validateKeyTemplate(); err != nil {
+		return err
+	}
+
+	if c.IncludeCollectionRegex != "" {
+		if _, err := regexp.Compile(c.IncludeCollectionRegex); err != nil {
+			return fmt.Errorf("invalid collection include pattern: %w", err)
+		}
+	}
+
+	if !c.ModifiedSince.IsZero() && len(c.Collections) == 0 && c.IncludeCollectionRegex == "" {
+		return errors.New("incremental dumps (ModifiedSince) require an explicit collection list or include pattern")
+	}
+
+	if c.Query != "" {
+		if len(c.Collections) == 0 {
+			return errors.New("a query requires a collection")
+		}
+		if !c.ModifiedSince.IsZero() {
+			return errors.New("a query cannot be combined with incremental dumps (ModifiedSince)")
+		}
+		var filter bson.D
+		if err := bson.UnmarshalExtJSON([]byte(c.Query), false, &filter); err != nil {
+			return fmt.Errorf("invalid query, expected an extended JSON document: %w", err)
+		}
+	}
+
+	if c.VerifyCounts {
+		if c.CountTolerance < 0 || c.CountTolerance >= 1 {
+			return errors.New("count tolerance must be a fraction between 0 and 1")
+		}
+		if !c.ModifiedSince.IsZero() || c.Query != "" {
+			return errors.New("document counts cannot be verified for filtered dumps")
+		}
+		if c.StreamToS3 {
+			return errors.New("document counts cannot be verified when streaming")
+		}
+	}
+
+	if c.StreamToS3 {
+		if c.PipelineUploads {
+			return errors.New("streaming and pipelined uploads cannot be combined")
+		}
+		if len(c.Collections) > 1 || c.IncludeCollectionRegex != "" {
+			return errors.New("streaming supports at most one collection")
+		}
+	}
+
+	if c.DatabaseConcurrency < 0 {
+		return errors.New("database concurrency cannot be negative")
+	}
+
+	if c.PerDatabase {
+		if c.Database != "" || uriDatabase(c.MongoURI) != "" {
+			return errors.New("per-database backups dump every database, remove the database")
+		}
+		if c.UseOplog {
+			return errors.New("oplog backups cannot be split per database")
+		}
+	}
+
+	if c.SkipIfUnchangedQuery && (c.Database == "" || c.SkipIfUnchangedCollection == "") {
+		return errors.New("change detection requires a database and a collection to query")
+	}
+
+	// Verify mongodump is available
+	if err := c.findMongodump(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateConnection checks the settings mongodump and mongorestore connect with
+func (c *DumperConfig) validateConnection() error {
 	if c.MongoURI == "" {
 		return errors.New("MongoDB URI is required")
 	}
+	if c.Password != "" && c.Username == "" {
+		return errors.New("a MongoDB password requires a username")
+	}
+	if c.discreteCredentials() && uriHasCredentials(c.MongoURI) {
+		return errors.New("MongoDB credentials are set both in the URI and as username/password, use only one")
+	}
+	for _, file := range []string{c.TLSCAFile, c.TLSCertKeyFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("invalid MongoDB TLS file: %w", err)
+		}
+	}
+	if c.ConnectTimeout < 0 || c.SocketTimeout < 0 || c.ServerSelectionTimeout < 0 {
+		return errors.New("MongoDB connection timeouts must be positive")
+	}
+	return nil
+}
 
-	if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKey == "" || c.S3SecretKey == "" {
-		return errors.New("S3 configuration is incomplete")
+// validateRestore checks the namespace selection and renaming of restores
+func (c *DumperConfig) validateRestore() error {
+	for _, pattern := range c.RestoreNamespaces {
+		if err := validateNamespacePattern(pattern); err != nil {
+			return err
+		}
 	}
+	return c.validateNamespaceRemap()
+}
 
-	// Verify mongodump is available
-	if _, err := exec.LookPath("mongodump"); err != nil {
+// applyConnectionOptions adds the discrete connection settings to MongoURI as connection
+// string options understood by mongodump and mongorestore
+func (c *DumperConfig) applyConnectionOptions() error {
+	opts := map[string]string{}
+	if c.ReplicaSet != "" {
+		opts["replicaSet"] = c.ReplicaSet
+	}
+	// With discrete credentials the auth source is passed as --authenticationDatabase
+	if c.AuthSource != "" && !c.discreteCredentials() {
+		opts["authSource"] = c.AuthSource
+	}
+	if c.ConnectTimeout > 0 {
+		opts["connectTimeoutMS"] = uriMillis(c.ConnectTimeout)
+	}
+	if c.SocketTimeout > 0 {
+		opts["socketTimeoutMS"] = uriMillis(c.SocketTimeout)
+	}
+	if c.ServerSelectionTimeout > 0 {
+		opts["serverSelectionTimeoutMS"] = uriMillis(c.ServerSelectionTimeout)
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+
+	uri, err := BuildURI(c.MongoURI, opts)
+	if err != nil {
+		return fmt.Errorf("failed to apply connection options: %w", err)
+	}
+	c.MongoURI = uri
+	return nil
+}
+
+// uriMillis formats a duration as a connection string option in milliseconds, rounding up so
+// that a sub-millisecond duration doesn't become 0, which the server reads as no timeout
+func uriMillis(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Millisecond-1)/time.Millisecond), 10)
+}
+
+// excludesCollection reports whether a collection of Database is excluded by name or prefix
+func (c *DumperConfig) excludesCollection(name string) bool {
+	if slices.Contains(c.ExcludeCollections, name) {
+		return true
+	}
+	for _, prefix := range c.ExcludeCollectionPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// restoresNamespace reports whether a restore includes a namespace, which is always the case
+// without RestoreNamespaces
+func (c *DumperConfig) restoresNamespace(namespace string) bool {
+	if len(c.RestoreNamespaces) == 0 {
+		return true
+	}
+	for _, pattern := range c.RestoreNamespaces {
+		if namespacePatternRegex(pattern).MatchString(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// discreteCredentials reports whether credentials are configured outside the URI
+func (c *DumperConfig) discreteCredentials() bool {
+	return c.Username != "" || c.Password != "" || c.AuthMechanism != ""
+}
+
+// credentialArgs returns the discrete credentials as mongodump and mongorestore options. The
+// password is masked by redactedArgs when the arguments are logged.
+func (c *DumperConfig) credentialArgs() []string {
+	if !c.discreteCredentials() {
+		return nil
+	}
+
+	var args []string
+	if c.Username != "" {
+		args = append(args, "--username="+c.Username)
+	}
+	if c.Password != "" {
+		args = append(args, "--password="+c.Password)
+	}
+	if c.AuthSource != "" {
+		args = append(args, "--authenticationDatabase="+c.AuthSource)
+	}
+	if c.AuthMechanism != "" {
+		args = append(args, "--authenticationMechanism="+c.AuthMechanism)
+	}
+	return args
+}
+
+// usesTLS reports whether TLS options are configured outside the URI
+func (c *DumperConfig) usesTLS() bool {
+	return c.TLSCAFile != "" || c.TLSCertKeyFile != "" || c.TLSInsecure
+}
+
+// tlsArgs returns the TLS settings as mongodump and mongorestore options
+func (c *DumperConfig) tlsArgs() []string {
+	if !c.usesTLS() {
+		return nil
+	}
+
+	args := []string{"--ssl"}
+	if c.TLSCAFile != "" {
+		args = append(args, "--sslCAFile="+c.TLSCAFile)
+	}
+	if c.TLSCertKeyFile != "" {
+		args = append(args, "--sslPEMKeyFile="+c.TLSCertKeyFile)
+	}
+	if c.TLSInsecure {
+		args = append(args, "--tlsInsecure")
+	}
+	return args
+}
+
+// connectionArgs returns the mongodump and mongorestore options that connect and
+// authenticate: the URI, discrete credentials and TLS settings
+func (c *DumperConfig) connectionArgs() []string {
+	args := []string{"--uri", c.MongoURI}
+	args = append(args, c.credentialArgs()...)
+	return append(args, c.tlsArgs()...)
+}
+
+// statsWindow returns the configured StatsWindow or DefaultStatsWindow
+func (c *DumperConfig) statsWindow() int {
+	if c.StatsWindow > 0 {
+		return c.StatsWindow
+	}
+	return DefaultStatsWindow
+}
+
+// resolveTempDir defaults an empty TempDir to the system temp directory, so dumps are not
+// written relative to the working directory while the disk checks measure another filesystem
+func (c *DumperConfig) resolveTempDir() {
+	if c.TempDir == "" {
+		c.TempDir = os.TempDir()
+	}
+}
+
+// tempDirMode returns the permission of directories holding dump data
+func (c *DumperConfig) tempDirMode() os.FileMode {
+	if c.TempDirMode != 0 {
+		return c.TempDirMode
+	}
+	return DefaultTempDirMode
+}
+
+// tempFileMode returns the permission of files holding dump data, the directory mode without
+// execute bits
+func (c *DumperConfig) tempFileMode() os.FileMode {
+	return c.tempDirMode() &^ 0111
+}
+
+// mongodumpBinary returns the mongodump executable to run
+func (c *DumperConfig) mongodumpBinary() string {
+	return GetValueOrDefault(c.MongodumpPath, "mongodump")
+}
+
+// findMongodump verifies the mongodump executable exists. A configured MongodumpPath must be
+// an executable file, otherwise mongodump is looked up in PATH.
+func (c *DumperConfig) findMongodump() error {
+	if _, err := exec.LookPath(c.mongodumpBinary()); err != nil {
+		if c.MongodumpPath != "" {
+			return fmt.Errorf("mongodump path %s is not usable: %w", c.MongodumpPath, err)
+		}
 		return ErrMongoDumpNotFound
 	}
+	return nil
+}
+
+// validateProvider checks the settings of the configured storage provider
+func (c *DumperConfig) validateProvider() error {
+	switch c.Provider {
+	case "", ProviderS3:
+		if c.S3Endpoint == "" || c.S3Bucket == "" {
+			return errors.New("S3 configuration is incomplete")
+		}
+		if c.usesDefaultCredentials() {
+			if c.S3AccessKey != "" || c.S3SecretKey != "" {
+				return errors.New("static S3 credentials cannot be combined with the default credential chain")
+			}
+		} else if c.S3AccessKey == "" || c.S3SecretKey == "" {
+			return errors.New("S3 credentials are missing, set an access key and secret key or use the default credential chain")
+		}
+		return nil
+	case ProviderGCS:
+		if c.GCSBucket == "" {
+			return errors.New("GCS bucket is required")
+		}
+		if c.GCSHMACAccessID == "" || c.GCSHMACSecret == "" {
+			return errors.New("GCS credentials are missing, set an HMAC access id and secret")
+		}
+	case ProviderAzure:
+		if c.AzureAccount == "" || c.AzureContainer == "" {
+			return errors.New("Azure account and container are required")
+		}
+		if c.AzureAccountKey == "" {
+			return errors.New("Azure account key is required")
+		}
+	case ProviderFS:
+		if c.LocalDir == "" {
+			return errors.New("local backup directory is required")
+		}
+	default:
+		return fmt.Errorf("unsupported storage provider %q, supported: %s, %s, %s, %s", c.Provider, ProviderS3, ProviderGCS, ProviderAzure, ProviderFS)
+	}
 
+	// Storage classes and SSE are S3 request headers the other providers don't understand
+	if c.StorageClass != "" || c.ServerSideEncryption != "" {
+		return fmt.Errorf("storage class and server-side encryption are only supported by the %s provider", ProviderS3)
+	}
 	return nil
 }
 
+// gcsConfig returns the configuration of the S3 client talking to GCS's XML API
+func (c DumperConfig) gcsConfig() DumperConfig {
+	c.S3Endpoint = GetValueOrDefault(c.GCSEndpoint, DefaultGCSEndpoint)
+	c.S3Region = "auto"
+	c.S3Bucket = c.GCSBucket
+	c.S3AccessKey = c.GCSHMACAccessID
+	c.S3SecretKey = c.GCSHMACSecret
+	c.UseDefaultCredentials = false
+	c.AWSProfile = ""
+	// GCS doesn't support S3 object tagging, so no retention tags are sent
+	c.RetentionAge = 0
+	return c
+}
+
+// usesDefaultCredentials reports whether S3 credentials come from the AWS credential chain
+func (c *DumperConfig) usesDefaultCredentials() bool {
+	return c.UseDefaultCredentials || c.AWSProfile != ""
+}
+
 // GetEnvironment returns the environment or a default value if not specified
 func (c *DumperConfig) GetEnvironment(defaultValue string) string {
 	if c.Environment == "" {
@@ -65,3 +899,9 @@ func (c *DumperConfig) GetDatabase(defaultValue string) string {
 	}
 	return c.Database
 }
+
+// KeyComponent sanitizes a value used as part of a generated S3 key, so generation and
+// listing always agree on the same form
+func (c *DumperConfig) KeyComponent(value string) string {
+	return sanitizeKeyComponent(value, c.KeyLowercase)
+}
@@ -0,0 +1,24 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyConnectionOptionsRoundsUpTimeouts(t *testing.T) {
+	cfg := DumperConfig{
+		MongoURI:               "mongodb://localhost:27017/?w=majority",
+		ConnectTimeout:         500 * time.Microsecond,
+		SocketTimeout:          1500 * time.Microsecond,
+		ServerSelectionTimeout: 30 * time.Second,
+	}
+	if err := cfg.applyConnectionOptions(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A sub-millisecond timeout must not become 0, which means no timeout at all
+	want := "mongodb://localhost:27017/?connectTimeoutMS=1&serverSelectionTimeoutMS=30000&socketTimeoutMS=2&w=majority"
+	if cfg.MongoURI != want {
+		t.Errorf("MongoURI = %q, want %q", cfg.MongoURI, want)
+	}
+}
@@ -0,0 +1,83 @@
+package mongodb
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// DefaultStorageClass is assumed for objects whose listing reports no storage class
+const DefaultStorageClass = "STANDARD"
+
+// DefaultStorageRates are the monthly USD prices per GB by storage class, Backblaze B2's
+// published rate of $6/TB. DumperConfig.StorageRates overrides individual classes.
+var DefaultStorageRates = map[string]float64{
+	DefaultStorageClass: 0.006,
+}
+
+// CostEstimate is the estimated monthly storage cost of one environment's objects in one storage class
+type CostEstimate struct {
+	Environment  string  `json:"environment"`
+	StorageClass string  `json:"storage_class"`
+	Objects      int     `json:"objects"`
+	SizeBytes    int64   `json:"size_bytes"`
+	RatePerGB    float64 `json:"rate_per_gb"`
+	MonthlyCost  float64 `json:"monthly_cost"`
+}
+
+// EstimateCost lists the whole bucket and estimates the monthly storage cost per environment
+// (the first key segment) and storage class
+func (d *Dumper) EstimateCost(ctx context.Context) ([]CostEstimate, error) {
+	objects, err := d.store.ListObjects(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rates := d.storageRates()
+	groups := map[[2]string]*CostEstimate{}
+	for _, obj := range objects {
+		environment, _, _ := strings.Cut(obj.Key, "/")
+		class := GetValueOrDefault(obj.StorageClass, DefaultStorageClass)
+
+		estimate, ok := groups[[2]string{environment, class}]
+		if !ok {
+			rate, known := rates[class]
+			if !known {
+				rate = rates[DefaultStorageClass]
+				d.logger.Warn("No storage rate for storage class, using the default rate",
+					"storage_class", class,
+					"rate_per_gb", rate)
+			}
+			estimate = &CostEstimate{Environment: environment, StorageClass: class, RatePerGB: rate}
+			groups[[2]string{environment, class}] = estimate
+		}
+		estimate.Objects++
+		estimate.SizeBytes += obj.Size
+	}
+
+	estimates := make([]CostEstimate, 0, len(groups))
+	for _, estimate := range groups {
+		estimate.MonthlyCost = float64(estimate.SizeBytes) / 1024 / 1024 / 1024 * estimate.RatePerGB
+		estimates = append(estimates, *estimate)
+	}
+	sort.Slice(estimates, func(i, j int) bool {
+		if estimates[i].Environment != estimates[j].Environment {
+			return estimates[i].Environment < estimates[j].Environment
+		}
+		return estimates[i].StorageClass < estimates[j].StorageClass
+	})
+
+	return estimates, nil
+}
+
+// storageRates returns the default rates with the configured overrides applied
+func (d *Dumper) storageRates() map[string]float64 {
+	rates := make(map[string]float64, len(DefaultStorageRates)+len(d.config.StorageRates))
+	for class, rate := range DefaultStorageRates {
+		rates[class] = rate
+	}
+	for class, rate := range d.config.StorageRates {
+		rates[strings.ToUpper(class)] = rate
+	}
+	return rates
+}
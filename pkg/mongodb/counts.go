@@ -0,0 +1,97 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// DefaultCountTolerance is the fraction by which dumped and counted documents may differ,
+// allowing for writes while the dump runs
+const DefaultCountTolerance = 0.01
+
+// countDocuments counts the documents of every collection the dump will include, keyed
+// "<db>.<collection>". collections is the resolved collection list, nil for all.
+func (d *MongoDumper) countDocuments(ctx context.Context, collections []string) (map[string]int64, error) {
+	counts := map[string]int64{}
+	err := withMongoClient(ctx, &d.config, func(client *mongo.Client) error {
+		databases := []string{GetValueOrDefault(d.config.Database, uriDatabase(d.config.MongoURI))}
+		if databases[0] == "" {
+			names, err := client.ListDatabaseNames(ctx, bson.D{})
+			if err != nil {
+				return fmt.Errorf("failed to list databases: %w", err)
+			}
+			databases = slices.DeleteFunc(names, func(name string) bool {
+				return slices.Contains(systemDatabases, name)
+			})
+		}
+
+		for _, database := range databases {
+			db := client.Database(database)
+			names := collections
+			if names == nil {
+				// Views hold no documents of their own, system collections are not dumped as data
+				var err error
+				names, err = db.ListCollectionNames(ctx, bson.D{{Key: "type", Value: "collection"}})
+				if err != nil {
+					return fmt.Errorf("failed to list collections of %s: %w", database, err)
+				}
+				names = slices.DeleteFunc(names, func(name string) bool {
+					return strings.HasPrefix(name, "system.") || d.config.excludesCollection(name)
+				})
+			}
+
+			for _, name := range names {
+				count, err := db.Collection(name).CountDocuments(ctx, bson.D{})
+				if err != nil {
+					return fmt.Errorf("failed to count documents of %s.%s: %w", database, name, err)
+				}
+				counts[database+"."+name] = count
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.logger.Info("Counted documents before dump", "collections", len(counts))
+	return counts, nil
+}
+
+// verifyCounts compares the counted documents with those mongodump reported, failing with
+// every collection that is missing from the dump or differs beyond the tolerance
+func (d *MongoDumper) verifyCounts(expected map[string]int64) error {
+	tolerance := d.config.CountTolerance
+	if tolerance == 0 {
+		tolerance = DefaultCountTolerance
+	}
+
+	var mismatches []string
+	for collection, want := range expected {
+		got, ok := d.documentCount(collection)
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s (expected %d documents, not dumped)", collection, want))
+			continue
+		}
+		if math.Abs(float64(got-want)) > tolerance*float64(want) {
+			mismatches = append(mismatches, fmt.Sprintf("%s (expected %d documents, dumped %d)", collection, want, got))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return fmt.Errorf("dumped document counts differ by more than %.2f%%: %s", tolerance*100, strings.Join(mismatches, ", "))
+	}
+
+	d.logger.Info("Dumped document counts verified",
+		"collections", len(expected),
+		"tolerance", tolerance)
+	return nil
+}
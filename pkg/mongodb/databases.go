@@ -0,0 +1,144 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// DefaultDatabaseConcurrency is how many databases per-database backups dump at once
+const DefaultDatabaseConcurrency = 2
+
+// systemDatabases are never backed up on their own in per-database mode
+var systemDatabases = []string{"admin", "config", "local"}
+
+// listDatabases returns the sorted names of the server's user databases
+func (d *Dumper) listDatabases(ctx context.Context) ([]string, error) {
+	var names []string
+	err := withMongoClient(ctx, &d.mongoDump.config, func(client *mongo.Client) error {
+		var err error
+		names, err = client.ListDatabaseNames(ctx, bson.D{})
+		return err
+	})
+	if err != nil {
+		return nil, withCategory(ErrMongo, fmt.Errorf("failed to list databases: %w", err))
+	}
+
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return slices.Contains(systemDatabases, name)
+	})
+	sort.Strings(names)
+	return names, nil
+}
+
+// forDatabase returns a Dumper backing up a single database, sharing the S3 client and
+// circuit breaker with d
+func (d *Dumper) forDatabase(database string) (*Dumper, error) {
+	logger := withFields(d.logger, "database", database)
+
+	config := d.config
+	config.Database = database
+	config.Log = logger
+
+	mongoDump := MongoDumper{
+		config:      d.mongoDump.config,
+		logger:      logger,
+		output:      newTailBuffer(maxCapturedOutput),
+		toolVersion: d.mongoDump.toolVersion,
+	}
+	mongoDump.config.Database = database
+
+	child := &Dumper{
+		config:    config,
+		store:     d.store,
+		mongoDump: &mongoDump,
+		runner:    &mongoDump,
+		restorer:  d.restorer,
+		logger:    logger,
+		s3Breaker: d.s3Breaker,
+		samples:   d.samples,
+	}
+
+	var err error
+	child.codec, err = child.newCompressionCodec(child.archiveCompression())
+	if err != nil {
+		return nil, err
+	}
+	return child, nil
+}
+
+// dumpDatabases backs up every user database as its own archive, up to DatabaseConcurrency
+// at once. A failed database doesn't stop the others; all failures are returned joined.
+func (d *Dumper) dumpDatabases(ctx context.Context) error {
+	startTime := time.Now()
+
+	databases, err := d.listDatabases(ctx)
+	if err != nil {
+		return err
+	}
+	if len(databases) == 0 {
+		return errors.New("no databases found to back up")
+	}
+
+	concurrency := d.config.DatabaseConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultDatabaseConcurrency
+	}
+	d.logger.Info("Starting per-database backups",
+		"databases", databases,
+		"concurrency", concurrency)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		failed []string
+		size   int64
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, database := range databases {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			child, err := d.forDatabase(database)
+			if err == nil {
+				err = child.dump(ctx)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				d.logger.Error("Database backup failed", "database", database, "error", err)
+				errs = append(errs, fmt.Errorf("database %s: %w", database, err))
+				failed = append(failed, database)
+				return
+			}
+			size += child.lastBackup.SizeBytes
+		}()
+	}
+	wg.Wait()
+
+	sort.Strings(failed)
+	d.logger.Info("Per-database backups finished",
+		"databases", len(databases),
+		"succeeded", len(databases)-len(failed),
+		"failed", failed,
+		"size_bytes", size,
+		"total_duration", time.Since(startTime))
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d database backups failed: %w", len(errs), len(databases), errors.Join(errs...))
+	}
+	d.lastBackup = BackupResult{S3Key: d.config.KeyPrefix(), SizeBytes: size}
+	return nil
+}
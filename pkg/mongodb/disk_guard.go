@@ -0,0 +1,119 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrInsufficientDiskSpace is returned when the temp directory has less free space than
+// MinFreeSpace before a dump, or drops below FreeSpaceFloor during one
+var ErrInsufficientDiskSpace = errors.New("insufficient free disk space")
+
+// diskWatchInterval is how often free space is checked while a dump runs
+const diskWatchInterval = 10 * time.Second
+
+// tempDir returns the directory dumps are written to. The constructors resolve an empty
+// TempDir, so the disk checks measure the filesystem dumps actually fill.
+func (d *Dumper) tempDir() string {
+	return GetValueOrDefault(d.config.TempDir, os.TempDir())
+}
+
+// requireFreeSpace returns the temp directory and its free space, with an
+// ErrInsufficientDiskSpace error if less than MinFreeSpace is available. It returns
+// errDiskSpaceUnsupported on platforms that can't report free space.
+func (d *Dumper) requireFreeSpace() (dir string, free uint64, err error) {
+	dir = d.tempDir()
+	free, err = freeDiskSpace(dir)
+	if errors.Is(err, errDiskSpaceUnsupported) {
+		return dir, 0, err
+	}
+	if err != nil {
+		return dir, 0, fmt.Errorf("failed to check free disk space in %s: %w", dir, err)
+	}
+	if free < d.config.MinFreeSpace {
+		return dir, free, fmt.Errorf("%w in %s: %d bytes free, %d required",
+			ErrInsufficientDiskSpace, dir, free, d.config.MinFreeSpace)
+	}
+	return dir, free, nil
+}
+
+// checkFreeSpace refuses to start a dump when the temp directory has less than MinFreeSpace
+// available. Platforms that can't report free space only log a warning.
+func (d *Dumper) checkFreeSpace() error {
+	if d.config.MinFreeSpace == 0 {
+		return nil
+	}
+
+	dir, free, err := d.requireFreeSpace()
+	if errors.Is(err, errDiskSpaceUnsupported) {
+		d.logger.Warn("Skipping free disk space check", "reason", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.logger.Debug("Free disk space check passed",
+		"temp_dir", dir,
+		"free_bytes", free,
+		"required_bytes", d.config.MinFreeSpace)
+	return nil
+}
+
+// watchFreeSpace returns a context that is cancelled once free space in the temp directory
+// drops below FreeSpaceFloor, stopping mongodump before it fills the disk. stop ends the watch
+// and returns the ErrInsufficientDiskSpace error if the floor was reached. Without a floor
+// ctx is returned unchanged.
+func (d *Dumper) watchFreeSpace(ctx context.Context) (watched context.Context, stop func() error) {
+	floor := d.config.FreeSpaceFloor
+	if floor == 0 {
+		return ctx, func() error { return nil }
+	}
+
+	dir := d.tempDir()
+	watched, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	var exceeded error
+
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(diskWatchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				free, err := freeDiskSpace(dir)
+				if err != nil {
+					// Unsupported platforms and transient errors don't abort a dump
+					continue
+				}
+				if free < floor {
+					exceeded = fmt.Errorf("%w in %s during the dump: %d bytes free, floor is %d",
+						ErrInsufficientDiskSpace, dir, free, floor)
+					d.logger.Error("Free disk space dropped below the floor, aborting dump",
+						"temp_dir", dir,
+						"free_bytes", free,
+						"floor_bytes", floor)
+					cancel(exceeded)
+					return
+				}
+			case <-done:
+				return
+			case <-watched.Done():
+				return
+			}
+		}
+	}()
+
+	return watched, func() error {
+		close(done)
+		<-finished
+		cancel(nil)
+		return exceeded
+	}
+}
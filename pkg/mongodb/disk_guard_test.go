@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFreeSpaceChecksUseMinFreeSpace(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("free disk space is only reported on Linux")
+	}
+	ctx := context.Background()
+
+	// Without a minimum neither the dump guard nor the preflight check fails
+	d := newTestDumper(t, DumperConfig{}, newFakeStore())
+	if err := d.checkFreeSpace(); err != nil {
+		t.Errorf("checkFreeSpace without MinFreeSpace = %v", err)
+	}
+	if detail, err := d.checkDiskSpace(ctx); err != nil || !strings.HasPrefix(detail, "skipped") {
+		t.Errorf("checkDiskSpace without MinFreeSpace = %q, %v, want skipped", detail, err)
+	}
+
+	// A lowered minimum passes on any host
+	d = newTestDumper(t, DumperConfig{MinFreeSpace: 1}, newFakeStore())
+	if err := d.checkFreeSpace(); err != nil {
+		t.Errorf("checkFreeSpace with 1 byte required = %v", err)
+	}
+	if detail, err := d.checkDiskSpace(ctx); err != nil || !strings.Contains(detail, d.config.TempDir) {
+		t.Errorf("checkDiskSpace with 1 byte required = %q, %v", detail, err)
+	}
+
+	d = newTestDumper(t, DumperConfig{MinFreeSpace: math.MaxUint64}, newFakeStore())
+	if err := d.checkFreeSpace(); !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Errorf("checkFreeSpace with an impossible minimum = %v, want ErrInsufficientDiskSpace", err)
+	}
+	if _, err := d.checkDiskSpace(ctx); !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Errorf("checkDiskSpace with an impossible minimum = %v, want ErrInsufficientDiskSpace", err)
+	}
+}
+
+func TestEmptyTempDirIsResolved(t *testing.T) {
+	fakeMongorestore(t)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	mongodump := writeFakeCommand(t, "mongodump", "exit 0\n")
+
+	d, err := NewDumper(DumperConfig{
+		MongoURI:      "mongodb://localhost:27017",
+		MongodumpPath: mongodump,
+		Provider:      ProviderFS,
+		LocalDir:      t.TempDir(),
+		Environment:   "test",
+		SkipPreflight: true,
+		Log:           &recordingLogger{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.tempDir() != os.TempDir() || d.config.TempDir != tmp {
+		t.Errorf("guarded temp dir = %q, config %q, want both %q", d.tempDir(), d.config.TempDir, tmp)
+	}
+	// The dump is written where the disk checks look
+	_, localPath, _ := d.mongoDump.GenerateBackupFilename()
+	if filepath.Dir(localPath) != tmp {
+		t.Errorf("dump written to %q, want it in %q", localPath, tmp)
+	}
+
+	local, err := NewLocalDumper(DumperConfig{MongoURI: "mongodb://localhost:27017", Log: &recordingLogger{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local.config.TempDir != tmp {
+		t.Errorf("local dumper temp dir = %q, want %q", local.config.TempDir, tmp)
+	}
+}
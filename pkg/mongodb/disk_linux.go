@@ -0,0 +1,17 @@
+//go:build linux
+
+package mongodb
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// freeDiskSpace returns the bytes available to unprivileged users on the filesystem holding path
+func freeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
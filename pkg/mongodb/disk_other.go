@@ -0,0 +1,8 @@
+//go:build !linux
+
+package mongodb
+
+// freeDiskSpace is not supported outside Linux
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
@@ -3,74 +3,269 @@ package mongodb
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"go.uber.org/zap"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
+// localPathHeadroom is reserved for "<db>/<collection>.metadata.json" below the backup directory
+const localPathHeadroom = 100
+
+// maxLocalPathLength is the longest path the platform reliably supports (MAX_PATH on Windows)
+var maxLocalPathLength = func() int {
+	if runtime.GOOS == "windows" {
+		return 260
+	}
+	return 4096
+}()
+
+// doneDumpingRegex matches mongodump's verbose "done dumping <db>.<collection> (N documents)" line.
+// Database names cannot contain dots, collection names can.
+var doneDumpingRegex = regexp.MustCompile(`done dumping ([^.\s]+)\.(\S+) \((\d+)?`)
+
 // MongoDumper handles MongoDB dump operations
 type MongoDumper struct {
 	config DumperConfig
-	logger *zap.Logger
+	logger Logger
+	output *tailBuffer // Combined mongodump output of the last CreateDump
+
+	// toolVersion is the mongodump version once Version has run, recorded in manifests
+	toolVersion string
+
+	// documentCounts holds the document count mongodump reported per "<db>.<collection>"
+	// during the last CreateDump. Both output streams report counts, so it is guarded by countsMu.
+	countsMu       sync.Mutex
+	documentCounts map[string]int64
+
+	// collectionDone is called as soon as mongodump reports a collection as fully written (optional)
+	collectionDone func(database, collection string)
 }
 
 // NewMongoDumper creates a new MongoDB dumper
 func NewMongoDumper(cfg DumperConfig) (*MongoDumper, error) {
 	// Verify mongodump is available
-	if _, err := exec.LookPath("mongodump"); err != nil {
-		return nil, ErrMongoDumpNotFound
+	if err := cfg.findMongodump(); err != nil {
+		return nil, err
+	}
+
+	// Apply discrete connection settings as connection string options understood by mongodump
+	if err := cfg.applyConnectionOptions(); err != nil {
+		return nil, err
+	}
+
+	if cfg.TLSInsecure {
+		cfg.logger().Warn("MongoDB TLS certificate verification is disabled")
+	}
+
+	if cfg.ConnectTimeout > 0 || cfg.SocketTimeout > 0 || cfg.ServerSelectionTimeout > 0 {
+		cfg.logger().Info("Using MongoDB connection timeouts",
+			"connect_timeout", cfg.ConnectTimeout,
+			"socket_timeout", cfg.SocketTimeout,
+			"server_selection_timeout", cfg.ServerSelectionTimeout)
 	}
 
 	return &MongoDumper{
 		config: cfg,
-		logger: cfg.Logger,
+		logger: cfg.logger(),
+		output: newTailBuffer(maxCapturedOutput),
 	}, nil
 }
 
+// DumpRunner produces the dump directory a backup archives. MongoDumper implements it by
+// running mongodump; fakes can write files directly so the rest of a backup runs without
+// MongoDB.
+type DumpRunner interface {
+	// CreateDump writes the dump into the directory outputPath
+	CreateDump(ctx context.Context, outputPath string) error
+	// GenerateBackupFilename returns the backup name, the local dump path and the S3 key prefix
+	GenerateBackupFilename() (string, string, string)
+}
+
 // CreateDump creates a MongoDB dump using mongodump
 func (d *MongoDumper) CreateDump(ctx context.Context, outputPath string) error {
-	d.logger.Info("Starting MongoDB dump", zap.String("output", outputPath))
-
-	// Check if the URI already contains a database name
-	uriContainsDB := strings.Contains(d.config.MongoURI, "?") &&
-		strings.Contains(d.config.MongoURI, "/") &&
-		len(strings.Split(strings.Split(d.config.MongoURI, "?")[0], "/")) > 3
+	d.logger.Info("Starting MongoDB dump", "output", outputPath)
+	d.output.Reset()
+	d.countsMu.Lock()
+	d.documentCounts = map[string]int64{}
+	d.countsMu.Unlock()
 
 	// Create the output directory if it doesn't exist
-	if err := os.MkdirAll(outputPath, 0755); err != nil {
+	if err := os.MkdirAll(outputPath, d.config.tempDirMode()); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Build mongodump arguments - use --out instead of --archive
-	args := []string{"--uri", d.config.MongoURI, "--out", outputPath}
+	// Track start time
+	startTime := time.Now()
 
-	// Only add the --db parameter if a database is specified AND the URI doesn't already contain one
-	if d.config.Database != "" && !uriContainsDB {
-		args = append(args, "--db", d.config.Database)
+	collections, err := d.resolveCollections(ctx)
+	if err != nil {
+		return err
 	}
 
-	// Add progress reporting parameters
-	args = append(args, "--verbose")
+	// Count before dumping, documents written meanwhile are covered by the tolerance
+	var expectedCounts map[string]int64
+	if d.config.VerifyCounts {
+		if expectedCounts, err = d.countDocuments(ctx, collections); err != nil {
+			return err
+		}
+	}
 
-	// Log the command being executed (with the URI redacted)
-	cmdString := fmt.Sprintf("mongodump --uri [REDACTED] --out=%s --verbose", outputPath)
-	if d.config.Database != "" && !uriContainsDB {
-		cmdString += fmt.Sprintf(" --db %s", d.config.Database)
+	// --collection (and --query) only apply to a single collection, so an explicit
+	// collection list means one mongodump run per collection into the same directory
+	if len(collections) > 0 {
+		for _, collection := range collections {
+			if err := d.runMongodump(ctx, outputPath, collection); err != nil {
+				return &DumpError{Database: d.config.Database, Collection: collection, Err: err}
+			}
+		}
+	} else if err := d.runMongodump(ctx, outputPath, ""); err != nil {
+		return &DumpError{Database: d.config.Database, Err: err}
 	}
-	d.logger.Debug("Executing command", zap.String("command", cmdString))
 
-	cmd := exec.CommandContext(ctx, "mongodump", args...)
+	if d.config.VerifyCounts {
+		if err := d.verifyCounts(expectedCounts); err != nil {
+			return err
+		}
+	}
+
+	if !d.config.ModifiedSince.IsZero() {
+		if err := d.writeIncrementalInfo(outputPath); err != nil {
+			return err
+		}
+	}
+
+	duration := time.Since(startTime)
+
+	// Count collections and calculate total size
+	var totalSize int64
+	var collectionCount int
+
+	err = filepath.Walk(outputPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && isDumpDataFile(path) {
+			collectionCount++
+			totalSize += info.Size()
+		}
+		return nil
+	})
+
+	if err != nil {
+		d.logger.Warn("Failed to calculate dump statistics", "error", err)
+	}
+
+	// Get directory size for reporting
+	var sizeStr string
+
+	// Less than 1MB - show in KB
+	if totalSize < 1024*1024 {
+		sizeKB := float64(totalSize) / 1024
+		sizeStr = fmt.Sprintf("%.2f KB", sizeKB)
+		d.logger.Info("MongoDB dump completed successfully",
+			"output_dir", outputPath,
+			"duration", duration,
+			"size_bytes", totalSize,
+			"file_size", sizeStr,
+			"collection_count", collectionCount,
+			"kb_per_sec", sizeKB/duration.Seconds())
+	} else if totalSize < 1024*1024*1024 { // Between 1MB and 1GB - show in MB
+		sizeMB := float64(totalSize) / 1024 / 1024
+		sizeStr = fmt.Sprintf("%.2f MB", sizeMB)
+		d.logger.Info("MongoDB dump completed successfully",
+			"output_dir", outputPath,
+			"duration", duration,
+			"size_bytes", totalSize,
+			"file_size", sizeStr,
+			"collection_count", collectionCount,
+			"mb_per_sec", sizeMB/duration.Seconds())
+	} else { // Larger than 1GB - show in GB with MB in parentheses
+		sizeMB := float64(totalSize) / 1024 / 1024
+		sizeGB := sizeMB / 1024
+		sizeStr = fmt.Sprintf("%.2f GB (%.2f MB)", sizeGB, sizeMB)
+		d.logger.Info("MongoDB dump completed successfully",
+			"output_dir", outputPath,
+			"duration", duration,
+			"size_bytes", totalSize,
+			"file_size", sizeStr,
+			"collection_count", collectionCount,
+			"mb_per_sec", sizeMB/duration.Seconds())
+	}
+
+	return nil
+}
+
+// resolveCollections returns the explicit collection list extended by the live collections
+// matching IncludeCollectionRegex, or nil to dump everything
+func (d *MongoDumper) resolveCollections(ctx context.Context) ([]string, error) {
+	if d.config.IncludeCollectionRegex == "" {
+		return d.config.Collections, nil
+	}
+
+	pattern, err := regexp.Compile(d.config.IncludeCollectionRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collection include pattern: %w", err)
+	}
+
+	var names []string
+	err = withMongoClient(ctx, &d.config, func(client *mongo.Client) error {
+		names, err = client.Database(d.config.Database).ListCollectionNames(ctx, bson.D{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	sort.Strings(names)
+
+	collections := append([]string{}, d.config.Collections...)
+	var matched []string
+	for _, name := range names {
+		if pattern.MatchString(name) && !slices.Contains(collections, name) {
+			matched = append(matched, name)
+		}
+	}
+	collections = append(collections, matched...)
+
+	d.logger.Info("Resolved collections from include pattern",
+		"pattern", d.config.IncludeCollectionRegex,
+		"matched", matched)
+
+	if len(collections) == 0 {
+		return nil, fmt.Errorf("no collections in %s match %q", d.config.Database, d.config.IncludeCollectionRegex)
+	}
+	return collections, nil
+}
+
+// runMongodump executes a single mongodump run into outputPath, optionally limited to one collection
+func (d *MongoDumper) runMongodump(ctx context.Context, outputPath, collection string) error {
+	// Build mongodump arguments - use --out instead of --archive
+	args := d.mongodumpArgs(collection, "--out", outputPath)
+
+	// Log the final arguments so users can confirm what is dumped (with the URI redacted)
+	d.logger.Info("Executing mongodump", "args", redactedArgs(args))
+
+	cmd := exec.CommandContext(ctx, d.config.mongodumpBinary(), args...)
+	configureProcess(cmd)
 
 	// Capture command output for logging
-	var stdoutBuf, stderrBuf strings.Builder
+	stdoutBuf := newTailBuffer(maxCapturedOutput)
+	stderrBuf := newTailBuffer(maxCapturedOutput)
 	stdout, stderr, err := setupCommandOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to set up command output capture: %w", err)
@@ -83,11 +278,19 @@ func (d *MongoDumper) CreateDump(ctx context.Context, outputPath string) error {
 		return fmt.Errorf("failed to start mongodump: %w", err)
 	}
 
+	// Lower the priority so a long dump doesn't starve other processes on the host
+	if d.config.Nice != 0 {
+		if err := setProcessNice(cmd, d.config.Nice); err != nil {
+			d.logger.Warn("Failed to set mongodump nice level", "nice", d.config.Nice, "error", err)
+		}
+	}
+
 	// Process mongodump output with progress tracking
 	progressCh := make(chan struct{})
 	go func() {
 		scanner := bufio.NewScanner(stdout)
 		lastPercentage := 0
+		throttle := progressThrottle(d.config.ProgressLogInterval)
 		progressRegex := regexp.MustCompile(`(\d+)%`)
 		collectionRegex := regexp.MustCompile(`writing ([^ ]+) to`)
 		var currentCollection string
@@ -95,122 +298,224 @@ func (d *MongoDumper) CreateDump(ctx context.Context, outputPath string) error {
 		for scanner.Scan() {
 			line := scanner.Text()
 			stdoutBuf.WriteString(line + "\n")
+			d.output.WriteString(line + "\n")
+			d.collectionDumped(line)
 
 			// Track which collection is being dumped
 			if match := collectionRegex.FindStringSubmatch(line); len(match) > 1 {
 				currentCollection = match[1]
 				d.logger.Info("Dumping collection",
-					zap.String("collection", currentCollection))
+					"collection", currentCollection)
 			}
 
 			// Look for percentage indicators in verbose output
 			if match := progressRegex.FindStringSubmatch(line); len(match) > 1 {
 				if pct, err := strconv.Atoi(match[1]); err == nil {
-					// Only log when percentage changes significantly (at least 10%)
-					if pct >= lastPercentage+10 || pct == 100 {
+					d.config.ProgressHandler.report(ProgressEvent{
+						Phase:      PhaseDump,
+						Percent:    pct,
+						Collection: currentCollection,
+					})
+
+					// Only log when percentage changes significantly (at least 10%), or per
+					// throttle interval
+					if shouldLogProgress(throttle, currentCollection, pct, lastPercentage) {
 						if currentCollection != "" {
 							d.logger.Info("MongoDB dump progress",
-								zap.String("collection", currentCollection),
-								zap.Int("percent_complete", pct),
-								zap.Duration("elapsed", time.Since(startTime)))
+								"collection", currentCollection,
+								"percent_complete", pct,
+								"elapsed", time.Since(startTime))
 						} else {
 							d.logger.Info("MongoDB dump progress",
-								zap.Int("percent_complete", pct),
-								zap.Duration("elapsed", time.Since(startTime)))
+								"percent_complete", pct,
+								"elapsed", time.Since(startTime))
 						}
 						lastPercentage = pct
 					}
 				}
 			}
 
-			d.logger.Debug("mongodump stdout", zap.String("output", line))
+			d.logger.Debug("mongodump stdout", "output", line)
 		}
 		close(progressCh)
 	}()
 
 	// Capture stderr in a separate goroutine
+	stderrCh := make(chan struct{})
 	go func() {
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
 			line := scanner.Text()
 			stderrBuf.WriteString(line + "\n")
-			d.logger.Debug("mongodump stderr", zap.String("output", line))
+			d.output.WriteString(line + "\n")
+			d.collectionDumped(line)
+			d.logger.Debug("mongodump stderr", "output", line)
 		}
+		close(stderrCh)
 	}()
 
+	// Drain both pipes before waiting, as Wait closes them
+	<-progressCh
+	<-stderrCh
+
 	// Wait for command to complete
 	err = cmd.Wait()
-	<-progressCh // Wait for stdout processing to complete
 
 	duration := time.Since(startTime)
 
 	if err != nil {
 		// If there was an error, log the output at ERROR level
 		d.logger.Error("MongoDB dump failed",
-			zap.Error(err),
-			zap.String("stdout", stdoutBuf.String()),
-			zap.String("stderr", stderrBuf.String()),
-			zap.Duration("duration", duration))
+			"error", err,
+			"stdout", stdoutBuf.String(),
+			"stderr", stderrBuf.String(),
+			"duration", duration)
 
 		return fmt.Errorf("mongodump failed: %w - stderr: %s", err, stderrBuf.String())
 	}
 
-	// Count collections and calculate total size
-	var totalSize int64
-	var collectionCount int
+	return nil
+}
 
-	err = filepath.Walk(outputPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// mongodumpArgs builds the mongodump arguments for a run writing to the given output
+// arguments (--out <dir> or --archive), optionally limited to one collection
+func (d *MongoDumper) mongodumpArgs(collection string, output ...string) []string {
+	// Check if the URI already contains a database name
+	uriContainsDB := uriDatabase(d.config.MongoURI) != ""
+
+	args := append(d.config.connectionArgs(), output...)
+
+	// Only add the --db parameter if a database is specified AND the URI doesn't already contain one
+	if d.config.Database != "" && !uriContainsDB {
+		args = append(args, "--db", d.config.Database)
+	}
+
+	if collection != "" {
+		args = append(args, "--collection", collection)
+	}
+
+	if d.config.ReadPreference != "" {
+		args = append(args, "--readPreference="+d.config.ReadPreference)
+	}
+
+	// Restrict to documents modified since the configured time
+	if collection != "" && !d.config.ModifiedSince.IsZero() {
+		args = append(args, "--query", d.modifiedSinceQuery())
+	}
+
+	// Restrict to documents matching the configured filter
+	if collection != "" && d.config.Query != "" {
+		d.logger.Debug("Filtering collection with query",
+			"collection", collection,
+			"query", d.config.Query)
+		args = append(args, "--query", d.config.Query)
+	}
+
+	// Exclusions only apply to whole-database dumps, Validate rejects them with a collection list
+	if collection == "" {
+		for _, excluded := range d.config.ExcludeCollections {
+			args = append(args, "--excludeCollection", excluded)
 		}
-		if !info.IsDir() && filepath.Ext(path) == ".bson" {
-			collectionCount++
-			totalSize += info.Size()
+		if len(d.config.ExcludeCollectionPrefixes) > 0 {
+			var prefixArgs []string
+			for _, prefix := range d.config.ExcludeCollectionPrefixes {
+				prefixArgs = append(prefixArgs, "--excludeCollectionsWithPrefix", prefix)
+			}
+			d.logger.Debug("Excluding collections by prefix", "args", prefixArgs)
+			args = append(args, prefixArgs...)
 		}
-		return nil
-	})
+	}
 
-	if err != nil {
-		d.logger.Warn("Failed to calculate dump statistics", zap.Error(err))
+	// Let mongodump compress each file itself instead of archiving an uncompressed dump
+	if d.config.MongodumpGzip {
+		args = append(args, "--gzip")
 	}
 
-	// Get directory size for reporting
-	var sizeStr string
+	// Capture writes during the dump for a consistent snapshot (full-server dumps only)
+	if d.config.UseOplog {
+		args = append(args, "--oplog")
+	}
 
-	// Less than 1MB - show in KB
-	if totalSize < 1024*1024 {
-		sizeKB := float64(totalSize) / 1024
-		sizeStr = fmt.Sprintf("%.2f KB", sizeKB)
-		d.logger.Info("MongoDB dump completed successfully",
-			zap.String("output_dir", outputPath),
-			zap.Duration("duration", duration),
-			zap.Int64("size_bytes", totalSize),
-			zap.String("file_size", sizeStr),
-			zap.Int("collection_count", collectionCount),
-			zap.Float64("kb_per_sec", sizeKB/duration.Seconds()))
-	} else if totalSize < 1024*1024*1024 { // Between 1MB and 1GB - show in MB
-		sizeMB := float64(totalSize) / 1024 / 1024
-		sizeStr = fmt.Sprintf("%.2f MB", sizeMB)
-		d.logger.Info("MongoDB dump completed successfully",
-			zap.String("output_dir", outputPath),
-			zap.Duration("duration", duration),
-			zap.Int64("size_bytes", totalSize),
-			zap.String("file_size", sizeStr),
-			zap.Int("collection_count", collectionCount),
-			zap.Float64("mb_per_sec", sizeMB/duration.Seconds()))
-	} else { // Larger than 1GB - show in GB with MB in parentheses
-		sizeMB := float64(totalSize) / 1024 / 1024
-		sizeGB := sizeMB / 1024
-		sizeStr = fmt.Sprintf("%.2f GB (%.2f MB)", sizeGB, sizeMB)
-		d.logger.Info("MongoDB dump completed successfully",
-			zap.String("output_dir", outputPath),
-			zap.Duration("duration", duration),
-			zap.Int64("size_bytes", totalSize),
-			zap.String("file_size", sizeStr),
-			zap.Int("collection_count", collectionCount),
-			zap.Float64("mb_per_sec", sizeMB/duration.Seconds()))
+	// Dump several collections at once, mongodump defaults to 4
+	if d.config.ParallelCollections > 0 {
+		args = append(args, "--numParallelCollections="+strconv.Itoa(d.config.ParallelCollections))
+	}
+
+	// Scan collections in natural order instead of walking the _id index
+	if d.config.ForceTableScan {
+		d.logger.Warn("Force table scan enabled, dump may be significantly slower")
+		args = append(args, "--forceTableScan")
+	}
+
+	// Add progress reporting parameters
+	args = append(args, "--verbose")
+
+	return args
+}
+
+// collectionDumped records the document count and calls the collectionDone hook if the
+// output line reports a finished collection
+func (d *MongoDumper) collectionDumped(line string) {
+	match := doneDumpingRegex.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	if count, err := strconv.ParseInt(match[3], 10, 64); err == nil {
+		d.countsMu.Lock()
+		if d.documentCounts != nil {
+			d.documentCounts[match[1]+"."+match[2]] = count
+		}
+		d.countsMu.Unlock()
+	}
+	if d.collectionDone != nil {
+		d.collectionDone(match[1], match[2])
+	}
+}
+
+// documentCount returns the document count mongodump reported for "<db>.<collection>"
+// during the last CreateDump
+func (d *MongoDumper) documentCount(namespace string) (int64, bool) {
+	d.countsMu.Lock()
+	defer d.countsMu.Unlock()
+	count, ok := d.documentCounts[namespace]
+	return count, ok
+}
+
+// DumpLog returns the combined mongodump output of the last CreateDump with the URI redacted.
+// Only the most recent maxCapturedOutput bytes are kept.
+func (d *MongoDumper) DumpLog() []byte {
+	return []byte(strings.ReplaceAll(d.output.String(), d.config.MongoURI, "[REDACTED]"))
+}
+
+// modifiedSinceQuery builds the extended JSON filter selecting documents modified since ModifiedSince
+func (d *MongoDumper) modifiedSinceQuery() string {
+	field := GetValueOrDefault(d.config.ModifiedSinceField, DefaultModifiedSinceField)
+	since := d.config.ModifiedSince.UTC().Format("2006-01-02T15:04:05.000Z")
+	return fmt.Sprintf(`{"%s":{"$gte":{"$date":"%s"}}}`, field, since)
+}
+
+// writeIncrementalInfo records the since-time of an incremental dump inside the dump directory
+// so that it travels with the archive and a restore pipeline can chain increments
+func (d *MongoDumper) writeIncrementalInfo(outputPath string) error {
+	info := map[string]interface{}{
+		"modified_since": d.config.ModifiedSince.UTC().Format(time.RFC3339Nano),
+		"field":          GetValueOrDefault(d.config.ModifiedSinceField, DefaultModifiedSinceField),
+		"collections":    d.config.Collections,
 	}
 
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode incremental dump info: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(outputPath, IncrementalInfoFile), data, d.config.tempFileMode()); err != nil {
+		return fmt.Errorf("failed to write incremental dump info: %w", err)
+	}
+
+	d.logger.Info("Incremental dump recorded",
+		"modified_since", d.config.ModifiedSince,
+		"collections", d.config.Collections)
+
 	return nil
 }
 
@@ -220,36 +525,78 @@ func (d *MongoDumper) streamOutput(r io.Reader, prefix string) {
 	for scanner.Scan() {
 		line := scanner.Text()
 		if strings.TrimSpace(line) != "" {
-			d.logger.Debug(prefix, zap.String("output", line))
+			d.logger.Debug(prefix, "output", line)
 		}
 	}
 
 	if err := scanner.Err(); err != nil && err != io.EOF {
-		d.logger.Error("Error reading command output", zap.Error(err))
+		d.logger.Error("Error reading command output", "error", err)
 	}
 }
 
 // GenerateBackupFilename generates backup paths and S3 keys
 func (d *MongoDumper) GenerateBackupFilename() (string, string, string) {
-	timestamp := time.Now().UTC().Format("2006-01-02T15-04-05Z")
-
-	// Use environment or default to "default"
-	environment := d.config.GetEnvironment("default")
-	if environment == "default" {
+	// Use environment or default to "default", database name or default to "all-databases"
+	data := d.config.keyTemplateData(time.Now())
+	if d.config.Environment == "" {
 		d.logger.Info("No environment specified, using 'default' for backup paths")
 	}
 
-	// Use database name or default to "all-databases"
-	dbName := d.config.GetDatabase("all-databases")
-
 	// Create directory name and S3 key prefix
-	backupDirName := fmt.Sprintf("%s-%s-%s", dbName, environment, timestamp)
+	backupDirName := fmt.Sprintf("%s-%s-%s", data.DB, data.Env, data.Timestamp)
 	localBackupPath := filepath.Join(d.config.TempDir, backupDirName)
-	s3Key := fmt.Sprintf("%s/%s/%s", environment, time.Now().Format("2006-01-02"), backupDirName)
+
+	// The descriptive name stays in the S3 key, locally a short run ID avoids path-length limits
+	if d.config.ShortLocalNames {
+		localBackupPath = filepath.Join(d.config.TempDir, "run-"+newRunID())
+	}
+
+	// Validate already rendered the template once, so this only fails on a changed config
+	s3Key, err := d.config.RenderKey(data)
+	if err != nil {
+		d.logger.Warn("Failed to render key template, using the default layout", "error", err)
+		s3Key = fmt.Sprintf("%s/%s/%s", data.Env, data.Date, backupDirName)
+	}
 
 	return backupDirName, localBackupPath, s3Key
 }
 
+// newRunID returns a short random identifier for local backup directories
+func newRunID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		// Fall back to the clock, uniqueness only matters within the temp dir
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// validateLocalPath checks that files written under a local backup path stay within the
+// platform's path length limit, leaving headroom for the database and collection files
+func validateLocalPath(localPath string) error {
+	if length := len(localPath) + localPathHeadroom; length > maxLocalPathLength {
+		return fmt.Errorf("local backup path %q is too long (%d characters including %d reserved for dump files, limit %d); use a shorter temp directory or enable short local names",
+			localPath, length, localPathHeadroom, maxLocalPathLength)
+	}
+	return nil
+}
+
+// redactedArgs returns a copy of command arguments with --password and the password and
+// secret options of the --uri value masked
+func redactedArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := range redacted {
+		switch {
+		case redacted[i] == "--uri" && i+1 < len(redacted):
+			redacted[i+1] = RedactMongoURI(redacted[i+1])
+		case strings.HasPrefix(redacted[i], "--password="):
+			redacted[i] = "--password=***"
+		}
+	}
+	return redacted
+}
+
 // setupCommandOutput sets up pipes for command stdout and stderr
 func setupCommandOutput(cmd *exec.Cmd) (io.ReadCloser, io.ReadCloser, error) {
 	stdout, err := cmd.StdoutPipe()
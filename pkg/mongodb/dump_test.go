@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateDumpRecordsCountsFromBothStreams(t *testing.T) {
+	// mongodump reports finished collections on either stream depending on its version, so
+	// both scanner goroutines record counts at the same time
+	mongodump := writeFakeCommand(t, "mongodump", `
+i=0
+while [ $i -lt 200 ]; do
+	echo "done dumping app.out$i ($i documents)"
+	echo "done dumping app.err$i ($i documents)" >&2
+	i=$((i+1))
+done
+`)
+	d, err := NewMongoDumper(DumperConfig{MongoURI: "mongodb://localhost", MongodumpPath: mongodump})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.CreateDump(context.Background(), t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 200; i++ {
+		for _, stream := range []string{"out", "err"} {
+			namespace := fmt.Sprintf("app.%s%d", stream, i)
+			if count, ok := d.documentCount(namespace); !ok || count != int64(i) {
+				t.Errorf("documentCount(%q) = %d, %v, want %d", namespace, count, ok, i)
+			}
+		}
+	}
+}
+
+// setMaxLocalPathLength overrides the platform path limit for a test
+func setMaxLocalPathLength(t *testing.T, limit int) {
+	t.Helper()
+	previous := maxLocalPathLength
+	maxLocalPathLength = limit
+	t.Cleanup(func() { maxLocalPathLength = previous })
+}
+
+func TestValidateLocalPathLength(t *testing.T) {
+	setMaxLocalPathLength(t, 260) // MAX_PATH on Windows
+
+	fits := strings.Repeat("a", 260-localPathHeadroom)
+	if err := validateLocalPath(fits); err != nil {
+		t.Errorf("path of exactly the limit rejected: %v", err)
+	}
+	if err := validateLocalPath(fits + "b"); err == nil || !strings.Contains(err.Error(), "short local names") {
+		t.Errorf("path one character over the limit = %v, want an error suggesting short local names", err)
+	}
+}
+
+func TestShortLocalNamesFitPathLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	// Room for a run-<id> directory, not for the descriptive backup name
+	setMaxLocalPathLength(t, len(filepath.Join(tempDir, "run-"+newRunID()))+localPathHeadroom)
+
+	d, store, _ := newFakeRunnerDumper(t, DumperConfig{Database: "app", TempDir: tempDir}, map[string]int{"app/users": 1})
+	if err := d.Dump(context.Background()); err == nil || !strings.Contains(err.Error(), "too long") {
+		t.Fatalf("Dump with a descriptive local name = %v, want a path length error", err)
+	}
+	if keys := store.keys(); len(keys) != 0 {
+		t.Fatalf("Dump over the path limit uploaded %v", keys)
+	}
+
+	d, _, _ = newFakeRunnerDumper(t, DumperConfig{Database: "app", TempDir: tempDir, ShortLocalNames: true}, map[string]int{"app/users": 1})
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatalf("Dump with short local names: %v", err)
+	}
+	// The S3 key keeps the descriptive name
+	if key := d.LastBackup().S3Key; !strings.Contains(key, "app-test-") {
+		t.Errorf("S3 key %q lost the descriptive backup name", key)
+	}
+}
+
+func TestCreateDumpCountsGzippedCollections(t *testing.T) {
+	mongodump := writeFakeCommand(t, "mongodump", `while [ $# -gt 0 ]; do
+	[ "$1" = "--out" ] && out="$2"
+	shift
+done
+mkdir -p "$out/app"
+for collection in users orders; do
+	echo "data" | gzip > "$out/app/$collection.bson.gz"
+	echo "{}" | gzip > "$out/app/$collection.metadata.json.gz"
+done
+`)
+	log := &recordingLogger{}
+	d, err := NewMongoDumper(DumperConfig{MongoURI: "mongodb://localhost", MongodumpPath: mongodump, MongodumpGzip: true, Log: log})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.CreateDump(context.Background(), t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if !log.contains("INFO MongoDB dump completed successfully", "collection_count=2") {
+		t.Errorf("dump of 2 gzipped collections not counted:\n%s", log.String())
+	}
+}
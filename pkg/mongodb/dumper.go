@@ -2,35 +2,71 @@ package mongodb
 
 import (
 	"archive/zip"
+	"bufio"
+	"compress/flate"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
-	"go.uber.org/zap"
+	"github.com/klauspost/compress/zstd"
 )
 
 // Dumper manages MongoDB backups to S3
 type Dumper struct {
 	config    DumperConfig
-	s3Client  *S3Client
+	store     ObjectStore
 	mongoDump *MongoDumper
-	logger    *zap.Logger
+	runner    DumpRunner // Creates the dump of archived backups, mongoDump unless replaced
+	restorer  *MongoRestorer
+	codec     CompressionCodec
+	logger    Logger
+	s3Breaker *circuitBreaker
+
+	lastBackup  BackupResult // Set by a successful Dump
+	lastSuccess atomic.Int64 // Unix nanoseconds of the last successful Dump, read by health checks
+	inProgress  atomic.Bool  // Set while Dump runs, read by shutdown handling
+
+	// samples holds the recent successful backups summarized by Stats, shared with the
+	// per-database dumpers
+	samples *backupSamples
+}
+
+// BackupResult describes what a successful Dump stored
+type BackupResult struct {
+	S3Key     string // Archive key, or the key prefix of a pipelined backup
+	SizeBytes int64  // Bytes uploaded
+
+	// OriginalSizeBytes is the size of the dump before compression, 0 where it is not known
+	OriginalSizeBytes int64
+}
+
+// LastBackup returns what the last Dump uploaded, empty if it uploaded nothing
+func (d *Dumper) LastBackup() BackupResult {
+	return d.lastBackup
 }
 
 // NewDumper creates a new MongoDB dumper
 func NewDumper(cfg DumperConfig) (*Dumper, error) {
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
-		return nil, err
+		return nil, withCategory(ErrConfig, err)
 	}
 
-	// Create S3 client
-	s3Client, err := NewS3Client(cfg)
+	cfg.resolveTempDir()
+
+	// Create the object store of the configured provider
+	store, err := newObjectStore(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+		return nil, fmt.Errorf("failed to create %s client: %w", GetValueOrDefault(cfg.Provider, ProviderS3), err)
 	}
 
 	// Create MongoDB dumper
@@ -39,38 +75,151 @@ func NewDumper(cfg DumperConfig) (*Dumper, error) {
 		return nil, fmt.Errorf("failed to create MongoDB dumper: %w", err)
 	}
 
+	// Restores use the same connection string, including the merged connection options
+	restorer, err := NewMongoRestorer(mongoDump.config)
+	if err != nil {
+		return nil, err
+	}
+
 	// Ensure temp directory exists
-	if cfg.TempDir != "" {
-		if err := os.MkdirAll(cfg.TempDir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create temp directory: %w", err)
-		}
+	if err := os.MkdirAll(cfg.TempDir, cfg.tempDirMode()); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
-	return &Dumper{
+	d := &Dumper{
 		config:    cfg,
-		s3Client:  s3Client,
+		store:     store,
 		mongoDump: mongoDump,
-		logger:    cfg.Logger,
-	}, nil
+		runner:    mongoDump,
+		restorer:  restorer,
+		logger:    cfg.logger(),
+		s3Breaker: newCircuitBreaker(cfg.S3BreakerThreshold, cfg.S3BreakerCooldown),
+		samples:   newBackupSamples(cfg.statsWindow()),
+	}
+
+	d.codec, err = d.newCompressionCodec(d.archiveCompression())
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// archiveCompression returns the codec name used for archives. A dump gzipped by mongodump
+// is only bundled into a tarball, not compressed again.
+func (d *Dumper) archiveCompression() string {
+	if d.config.MongodumpGzip {
+		return CompressionNone
+	}
+	return d.config.Compression
 }
 
-// Dump performs a MongoDB dump and uploads to S3
-func (d *Dumper) Dump(ctx context.Context) error {
+// dump performs a MongoDB dump and uploads to S3
+func (d *Dumper) dump(ctx context.Context) error {
+	d.lastBackup = BackupResult{}
+	if !d.config.SkipPreflight {
+		if err := d.preflight(ctx); err != nil {
+			return withCategory(ErrMongo, err)
+		}
+	}
+	// Cached after the first backup, per-database dumpers copy it
+	if _, err := d.mongoDump.Version(ctx); err != nil {
+		d.logger.Warn("Could not determine mongodump version for the manifest", "error", err)
+	}
+	if d.config.PerDatabase {
+		return d.dumpDatabases(ctx)
+	}
+
 	d.logger.Info("Starting backup process")
 	// Track total operation time
 	startTime := time.Now()
 
 	// Generate backup filename with timestamp
-	_, localBackupPath, s3KeyPrefix := d.mongoDump.GenerateBackupFilename()
+	_, localBackupPath, s3KeyPrefix := d.runner.GenerateBackupFilename()
+
+	// Track the active step so a timeout can report it and remove the partial files
+	phase := "dump"
+	var compressedPath string
+	defer func() {
+		d.cleanupAfterTimeout(ctx, phase, localBackupPath, compressedPath)
+	}()
 	d.logger.Info("Backup details",
-		zap.String("local_path", localBackupPath),
-		zap.String("s3_prefix", s3KeyPrefix))
+		"local_path", localBackupPath,
+		"s3_prefix", s3KeyPrefix,
+		"key_template", GetValueOrDefault(d.config.KeyTemplate, DefaultKeyTemplate))
+
+	if err := validateLocalPath(localBackupPath); err != nil {
+		return err
+	}
+
+	// Don't dump gigabytes during a known S3 outage only to fail at upload
+	if d.config.S3BreakerSkipBackup {
+		if err := d.checkS3Circuit(); err != nil {
+			return err
+		}
+	}
+
+	// Skip the whole dump when the change-detection query reports no changes
+	var changeToken string
+	if d.config.SkipIfUnchangedQuery {
+		var unchanged bool
+		unchanged, changeToken = d.unchangedSinceLastBackup(ctx)
+		if unchanged {
+			d.logger.Info("No changes since the last backup, skipping",
+				"collection", d.config.SkipIfUnchangedCollection)
+			return nil
+		}
+	}
+
+	// Refuse to start a dump that could fill the disk, streams never write to it
+	if !d.config.StreamToS3 {
+		if err := d.checkFreeSpace(); err != nil {
+			return err
+		}
+	}
+
+	// Upload collections while later ones are still dumping, skipping compression, or
+	// stream the dump straight to S3 without touching the local disk
+	if d.config.PipelineUploads || d.config.StreamToS3 {
+		var err error
+		if d.config.StreamToS3 {
+			phase = "stream"
+			err = d.DumpStream(ctx, s3KeyPrefix)
+		} else {
+			phase = "pipelined dump and upload"
+			dumpCtx, stopWatch := d.watchFreeSpace(ctx)
+			err = d.dumpPipelined(dumpCtx, localBackupPath, s3KeyPrefix)
+			if diskErr := stopWatch(); diskErr != nil {
+				d.removePartialDump(localBackupPath)
+				err = diskErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err := d.writeSuccessMarker(ctx, s3KeyPrefix); err != nil {
+			return err
+		}
+		if d.config.SkipIfUnchangedQuery {
+			d.storeChangeToken(ctx, changeToken)
+		}
+		d.logger.Info("Backup process completed successfully",
+			"total_duration", time.Since(startTime),
+			"s3_prefix", s3KeyPrefix)
+		return nil
+	}
 
 	// STEP 1: Execute MongoDB dump - creates a directory with collection files
 	d.logger.Info("STEP 1/4: Starting MongoDB dump")
 	dumpStartTime := time.Now()
-	if err := d.mongoDump.CreateDump(ctx, localBackupPath); err != nil {
-		return fmt.Errorf("failed to create MongoDB dump: %w", err)
+	dumpCtx, stopWatch := d.watchFreeSpace(ctx)
+	dumpErr := d.runner.CreateDump(dumpCtx, localBackupPath)
+	if diskErr := stopWatch(); diskErr != nil {
+		d.removePartialDump(localBackupPath)
+		return diskErr
+	}
+	if dumpErr != nil {
+		return d.dumpError(phase, dumpErr)
 	}
 	dumpDuration := time.Since(dumpStartTime)
 
@@ -84,7 +233,7 @@ func (d *Dumper) Dump(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && filepath.Ext(path) == ".bson" {
+		if !info.IsDir() && isDumpDataFile(path) {
 			collectionCount++
 			originalSize += info.Size()
 		}
@@ -92,7 +241,7 @@ func (d *Dumper) Dump(ctx context.Context) error {
 	})
 
 	if err != nil {
-		d.logger.Warn("Failed to calculate dump statistics", zap.Error(err))
+		d.logger.Warn("Failed to calculate dump statistics", "error", err)
 	}
 
 	// Format size for display based on magnitude
@@ -109,25 +258,37 @@ func (d *Dumper) Dump(ctx context.Context) error {
 	}
 
 	d.logger.Info("STEP 1/4: MongoDB dump completed",
-		zap.Duration("duration", dumpDuration),
-		zap.Int64("size_bytes", originalSize),
-		zap.String("file_size", fileSizeStr),
-		zap.Int("collection_count", collectionCount))
+		"duration", dumpDuration,
+		"size_bytes", originalSize,
+		"file_size", fileSizeStr,
+		"collection_count", collectionCount)
 
 	// STEP 2: Compress the dump directory
-	d.logger.Info("STEP 2/4: Compressing backup directory")
+	d.logger.Info("STEP 2/4: Compressing backup directory",
+		"archive", d.codec.Extension(),
+		"level", d.compressionLevel())
 	compressStartTime := time.Now()
 
-	// Create compressed file path by adding .zip extension
-	compressedPath := localBackupPath + ".zip"
-	compressedS3Key := s3KeyPrefix + ".zip"
+	// The archive extension comes from the configured codec
+	phase = "compress"
+	compressedPath = localBackupPath + d.codec.Extension()
+	compressedS3Key := s3KeyPrefix + d.codec.Extension()
 
-	if err := compressFile(localBackupPath, compressedPath); err != nil {
-		return fmt.Errorf("failed to compress dump directory: %w", err)
+	// The codecs don't track progress, so only the start and end of compression are reported
+	d.config.ProgressHandler.report(ProgressEvent{Phase: PhaseCompress, TotalBytes: originalSize})
+	if err := d.codec.Compress(localBackupPath, compressedPath); err != nil {
+		return &CompressionError{Phase: phase, Path: compressedPath, Err: err}
 	}
+	d.config.ProgressHandler.report(ProgressEvent{Phase: PhaseCompress, Percent: 100, Bytes: originalSize, TotalBytes: originalSize})
 
 	compressDuration := time.Since(compressStartTime)
 
+	// Throughput over the original bytes, guarded against a zero duration for tiny dumps
+	var compressMBPerSec float64
+	if compressDuration > 0 {
+		compressMBPerSec = float64(originalSize) / 1024 / 1024 / compressDuration.Seconds()
+	}
+
 	// Get compressed file size for reporting
 	var compressedSize int64
 	var compressedSizeStr string
@@ -151,81 +312,231 @@ func (d *Dumper) Dump(ctx context.Context) error {
 		}
 
 		d.logger.Info("STEP 2/4: Compression completed",
-			zap.Duration("duration", compressDuration),
-			zap.Int64("size_bytes", compressedSize),
-			zap.String("file_size", compressedSizeStr),
-			zap.Float64("compression_ratio", compressionRatio))
+			"duration", compressDuration,
+			"size_bytes", compressedSize,
+			"file_size", compressedSizeStr,
+			"compression_ratio", compressionRatio,
+			"compress_mb_per_sec", compressMBPerSec)
+		d.checkCompressionRatio(compressionRatio)
 	} else {
 		d.logger.Info("STEP 2/4: Compression completed",
-			zap.Duration("duration", compressDuration),
-			zap.Error(err))
+			"duration", compressDuration,
+			"compress_mb_per_sec", compressMBPerSec,
+			"error", err)
 	}
 
 	// STEP 3: Upload to S3
+	phase = "upload"
 	d.logger.Info("STEP 3/4: Starting S3 upload",
-		zap.String("s3_key", compressedS3Key))
+		"s3_key", compressedS3Key)
 	uploadStartTime := time.Now()
-	if err := d.s3Client.UploadFile(ctx, compressedPath, compressedS3Key); err != nil {
-		return fmt.Errorf("failed to upload dump to S3: %w", err)
+	if err := d.checkS3Circuit(); err != nil {
+		return err
+	}
+	uploadOpts := UploadOptions{
+		Key:      compressedS3Key,
+		Metadata: d.backupMetadata(collectionCount, originalSize),
+	}
+	if err := d.recordS3Result(d.store.UploadFile(ctx, compressedPath, uploadOpts)); err != nil {
+		return &UploadError{Phase: phase, Key: compressedS3Key, Err: err}
+	}
+	d.lastBackup = BackupResult{S3Key: compressedS3Key, SizeBytes: compressedSize, OriginalSizeBytes: originalSize}
+
+	// Describe the backup next to the archive, before the success marker completes it
+	manifest, err := d.archiveManifest(localBackupPath, compressedPath, compressedS3Key, startTime)
+	if err != nil {
+		d.logger.Warn("Failed to build backup manifest", "error", err)
+	} else {
+		manifest.OriginalSizeBytes = originalSize
+		d.writeManifest(ctx, s3KeyPrefix, manifest)
+	}
+	// Keep the full mongodump output next to the archive for auditing
+	if d.config.UploadDumpLog {
+		logKey := s3KeyPrefix + ".log"
+		if err := d.store.UploadBytes(ctx, d.mongoDump.DumpLog(), logKey, "text/plain"); err != nil {
+			d.logger.Warn("Failed to upload mongodump log",
+				"s3_key", logKey,
+				"error", err)
+		}
+	}
+	if err := d.writeSuccessMarker(ctx, s3KeyPrefix); err != nil {
+		return err
+	}
+	if d.config.SkipIfUnchangedQuery {
+		d.storeChangeToken(ctx, changeToken)
 	}
 	uploadDuration := time.Since(uploadStartTime)
 	d.logger.Info("STEP 3/4: S3 upload completed",
-		zap.Duration("duration", uploadDuration))
+		"duration", uploadDuration)
 
 	// STEP 4: Cleanup
+	phase = "cleanup"
 	d.logger.Info("STEP 4/4: Cleaning up temporary files")
 	cleanupStartTime := time.Now()
 
 	// Remove the dump directory and all its contents
 	if err := os.RemoveAll(localBackupPath); err != nil {
 		d.logger.Warn("Failed to remove temporary backup directory",
-			zap.String("path", localBackupPath),
-			zap.Error(err))
+			"path", localBackupPath,
+			"error", err)
 	}
 
 	// Remove the compressed zip file
 	if err := os.Remove(compressedPath); err != nil {
 		d.logger.Warn("Failed to remove compressed backup file",
-			zap.String("path", compressedPath),
-			zap.Error(err))
+			"path", compressedPath,
+			"error", err)
 	}
 
 	cleanupDuration := time.Since(cleanupStartTime)
 	d.logger.Info("STEP 4/4: Cleanup completed",
-		zap.Duration("duration", cleanupDuration))
+		"duration", cleanupDuration)
 
 	// Summary
 	totalDuration := time.Since(startTime)
 	d.logger.Info("Backup process completed successfully",
-		zap.Duration("total_duration", totalDuration),
-		zap.String("s3_key", compressedS3Key),
-		zap.Int("collection_count", collectionCount),
-		zap.Int64("original_size_bytes", originalSize),
-		zap.String("original_size", fileSizeStr),
-		zap.Int64("compressed_size_bytes", compressedSize),
-		zap.String("compressed_size", compressedSizeStr),
-		zap.Float64("compression_ratio", compressionRatio),
-		zap.String("backup_details", fmt.Sprintf("MongoDB dump (%s) + Compression (%s) + S3 upload (%s) + Cleanup (%s)",
+		"total_duration", totalDuration,
+		"s3_key", compressedS3Key,
+		"collection_count", collectionCount,
+		"original_size_bytes", originalSize,
+		"original_size", fileSizeStr,
+		"compressed_size_bytes", compressedSize,
+		"compressed_size", compressedSizeStr,
+		"compression_ratio", compressionRatio,
+		"compress_mb_per_sec", compressMBPerSec,
+		"backup_details", fmt.Sprintf("MongoDB dump (%s) + Compression (%s) + S3 upload (%s) + Cleanup (%s)",
 			dumpDuration.Round(time.Millisecond),
 			compressDuration.Round(time.Millisecond),
 			uploadDuration.Round(time.Millisecond),
-			cleanupDuration.Round(time.Millisecond))))
+			cleanupDuration.Round(time.Millisecond)))
+
+	return nil
+}
+
+// cleanupAfterTimeout logs the step a backup was in when its deadline passed and removes the
+// partial dump directory and archive, which are otherwise only removed after a successful upload
+func (d *Dumper) cleanupAfterTimeout(ctx context.Context, phase, localBackupPath, compressedPath string) {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+
+	d.logger.Error("Backup deadline exceeded",
+		"phase", phase,
+		"timeout", d.config.Timeout)
+
+	if err := os.RemoveAll(localBackupPath); err != nil {
+		d.logger.Warn("Failed to remove partial backup directory",
+			"path", localBackupPath,
+			"error", err)
+	}
+	if compressedPath != "" {
+		if err := os.Remove(compressedPath); err != nil && !os.IsNotExist(err) {
+			d.logger.Warn("Failed to remove partial backup archive",
+				"path", compressedPath,
+				"error", err)
+		}
+	}
+}
+
+// removePartialDump deletes the dump directory of an aborted dump to free its disk space
+func (d *Dumper) removePartialDump(localBackupPath string) {
+	if err := os.RemoveAll(localBackupPath); err != nil {
+		d.logger.Warn("Failed to remove partial backup directory",
+			"path", localBackupPath,
+			"error", err)
+	}
+}
+
+// backupMetadata returns the S3 metadata describing a backup, so lifecycle rules and
+// listings can use it. Counts unknown to the caller (negative) are left out.
+func (d *Dumper) backupMetadata(collectionCount int, originalSize int64) map[string]string {
+	metadata := map[string]string{
+		"database":       d.config.GetDatabase("all-databases"),
+		"environment":    d.config.GetEnvironment("default"),
+		"dumper-version": Version,
+	}
+	if collectionCount >= 0 {
+		metadata["collection-count"] = strconv.Itoa(collectionCount)
+	}
+	if originalSize >= 0 {
+		metadata["original-size"] = strconv.FormatInt(originalSize, 10)
+	}
+	return metadata
+}
 
+// checkS3Circuit returns ErrS3CircuitOpen while the S3 circuit breaker is open
+func (d *Dumper) checkS3Circuit() error {
+	if ok, remaining := d.s3Breaker.allow(); !ok {
+		d.logger.Warn("S3 circuit open, skipping upload",
+			"retry_in", remaining.Round(time.Second))
+		return withCategory(ErrStorage, fmt.Errorf("%w, retrying in %s", ErrS3CircuitOpen, remaining.Round(time.Second)))
+	}
 	return nil
 }
 
+// recordS3Result feeds an upload result to the circuit breaker and returns it unchanged
+func (d *Dumper) recordS3Result(err error) error {
+	if d.s3Breaker.record(err) {
+		d.logger.Warn("S3 circuit open after repeated upload failures",
+			"threshold", d.config.S3BreakerThreshold,
+			"cooldown", d.s3Breaker.cooldown)
+	}
+	return err
+}
+
+// compressBufferSize returns the configured copy and write buffer size for archives
+func (d *Dumper) compressBufferSize() int {
+	if d.config.CompressBufferSize <= 0 {
+		return DefaultCompressBufferSize
+	}
+	return d.config.CompressBufferSize
+}
+
+// compressionLevel returns the effective level of the configured codec for logging
+func (d *Dumper) compressionLevel() int {
+	if strings.EqualFold(d.config.Compression, CompressionZstd) {
+		if d.config.ZstdLevel == 0 {
+			return int(zstd.SpeedDefault)
+		}
+		return d.config.ZstdLevel
+	}
+	if d.config.CompressionLevel == 0 {
+		return flate.DefaultCompression
+	}
+	return d.config.CompressionLevel
+}
+
 // compressFile compresses a directory of files using zip format with minimal memory usage
-func compressFile(sourceDir, target string) error {
+func (d *Dumper) compressFile(sourceDir, target string) (err error) {
 	// Create a file to write the zip to
-	zipFile, err := os.Create(target)
+	zipFile, err := d.createArchiveFile(target)
 	if err != nil {
 		return fmt.Errorf("failed to create zip file: %w", err)
 	}
-	defer zipFile.Close()
 
-	// Create a new zip archive
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	// Create a new zip archive, batching its small writes into larger file writes
+	output := bufio.NewWriterSize(zipFile, d.compressBufferSize())
+	zipWriter := zip.NewWriter(output)
+
+	// The archive is only complete once the central directory is written and flushed to the file
+	defer func() {
+		if closeErr := zipWriter.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to finalize zip archive: %w", closeErr)
+		}
+		if flushErr := output.Flush(); flushErr != nil && err == nil {
+			err = fmt.Errorf("failed to write zip file: %w", flushErr)
+		}
+		if closeErr := zipFile.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close zip file: %w", closeErr)
+		}
+	}()
+
+	// Trade ratio for speed (or the other way round) if a level is configured
+	if level := d.config.CompressionLevel; level != 0 {
+		zipWriter.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		})
+	}
 
 	// Walk through all files in the directory
 	err = filepath.Walk(sourceDir, func(filePath string, info os.FileInfo, err error) error {
@@ -238,6 +549,16 @@ func compressFile(sourceDir, target string) error {
 			return nil
 		}
 
+		// Walk does not follow symlinks, so a link shows up here as a non-directory even
+		// when it points at one; never read through it, either skip it or store the link
+		if info.Mode()&os.ModeSymlink != 0 {
+			if !d.config.StoreSymlinks {
+				d.logger.Warn("Skipping symlink in backup directory", "path", filePath)
+				return nil
+			}
+			return addSymlinkToZip(zipWriter, sourceDir, filePath, info)
+		}
+
 		// Create a local file header
 		header, err := zip.FileInfoHeader(info)
 		if err != nil {
@@ -267,8 +588,8 @@ func compressFile(sourceDir, target string) error {
 		}
 		defer file.Close()
 
-		// Create a buffer for chunked copying
-		buffer := make([]byte, 32*1024) // 32KB buffer instead of loading entire file
+		// Create a buffer for chunked copying instead of loading entire file
+		buffer := make([]byte, d.compressBufferSize())
 
 		// Copy file contents to the zip in chunks
 		_, err = io.CopyBuffer(writer, file, buffer)
@@ -286,31 +607,156 @@ func compressFile(sourceDir, target string) error {
 	return nil
 }
 
-// ListBackups lists all available backups
-func (d *Dumper) ListBackups(ctx context.Context) ([]string, error) {
-	// Get environment with default fallback
-	environment := d.config.GetEnvironment("default")
+// addSymlinkToZip stores a symlink as a zip entry whose content is the link target
+func addSymlinkToZip(zipWriter *zip.Writer, sourceDir, filePath string, info os.FileInfo) error {
+	target, err := os.Readlink(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read symlink %s: %w", filePath, err)
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("failed to create header for %s: %w", filePath, err)
+	}
+
+	relPath, err := filepath.Rel(sourceDir, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path for %s: %w", filePath, err)
+	}
+	header.Name = relPath
+	header.Method = zip.Store
+
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to create zip entry for %s: %w", filePath, err)
+	}
 
-	return d.s3Client.ListBackups(ctx, environment+"/")
+	if _, err := io.WriteString(writer, target); err != nil {
+		return fmt.Errorf("failed to write symlink %s to zip: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// ListBackups lists all available backups, newest first by the timestamp in their key
+func (d *Dumper) ListBackups(ctx context.Context) ([]BackupInfo, error) {
+	// List under the same sanitized prefix the backup keys were generated with
+	backups, err := d.store.ListBackups(ctx, d.config.KeyPrefix())
+	if err != nil {
+		return nil, withCategory(ErrStorage, err)
+	}
+	depth := d.config.keyDepth()
+	if d.config.WriteSuccessMarker {
+		backups = completeBackups(backups, depth)
+	}
+
+	// Keys without a parsable timestamp fall back to the object's modification time
+	for i, backup := range backups {
+		backups[i].CreatedAt = backup.LastModified
+		if t, ok := backupTimestamp(backupPrefix(backup.Key, depth)); ok {
+			backups[i].CreatedAt = t
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// ErrNoBackups is returned by LatestBackup when there is no backup to restore
+var ErrNoBackups = errors.New("no backups found")
+
+// LatestBackup returns the newest backup archive that can be restored. Manifests, logs,
+// markers and streamed archives are skipped. It returns ErrNoBackups if there is none.
+func (d *Dumper) LatestBackup(ctx context.Context) (BackupInfo, error) {
+	backups, err := d.ListBackups(ctx)
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	for _, backup := range backups {
+		if _, err := d.codecForPath(backup.Key); err == nil {
+			return backup, nil
+		}
+	}
+	return BackupInfo{}, fmt.Errorf("%w under %q", ErrNoBackups, d.config.KeyPrefix())
+}
+
+// writeSuccessMarker marks a backup as complete, it must be the last object written
+func (d *Dumper) writeSuccessMarker(ctx context.Context, s3KeyPrefix string) error {
+	if !d.config.WriteSuccessMarker {
+		return nil
+	}
+
+	markerKey := s3KeyPrefix + "/" + SuccessMarkerName
+	if err := d.store.UploadBytes(ctx, nil, markerKey, "application/octet-stream"); err != nil {
+		return &UploadError{Phase: "success marker", Key: markerKey, Err: err}
+	}
+	return nil
+}
+
+// backupPrefix returns the prefix a backup object belongs to, e.g. <env>/<date>/<name> for
+// the default layout, whose rendered keys contain depth '/'. It returns "" for objects
+// outside that layout such as change tokens.
+func backupPrefix(key string, depth int) string {
+	parts := strings.SplitN(key, "/", depth+2)
+	if len(parts) < depth+1 {
+		return ""
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(parts[depth], ".log"), ManifestSuffix)
+	parts[depth] = trimArchiveExtension(name)
+	return strings.Join(parts[:depth+1], "/")
+}
+
+// completeBackups drops the objects of backups without a success marker, and the markers themselves
+func completeBackups(backups []BackupInfo, depth int) []BackupInfo {
+	complete := map[string]bool{}
+	for _, backup := range backups {
+		if path.Base(backup.Key) == SuccessMarkerName {
+			complete[path.Dir(backup.Key)] = true
+		}
+	}
+
+	var filtered []BackupInfo
+	for _, backup := range backups {
+		if path.Base(backup.Key) == SuccessMarkerName {
+			continue
+		}
+		if prefix := backupPrefix(backup.Key, depth); prefix != "" && !complete[prefix] {
+			continue
+		}
+		filtered = append(filtered, backup)
+	}
+	return filtered
 }
 
 // RestoreBackup downloads and restores a backup from S3
 func (d *Dumper) RestoreBackup(ctx context.Context, s3Key string) error {
-	d.logger.Info("Starting backup restoration", zap.String("s3_key", s3Key))
+	d.logger.Info("Starting backup restoration", "s3_key", s3Key)
 
 	// Create a temporary file for the download
-	tempFile := filepath.Join(d.config.TempDir, filepath.Base(s3Key))
+	tempFile := filepath.Join(d.tempDir(), filepath.Base(s3Key))
 
 	// Download the backup file
-	if err := d.s3Client.DownloadFile(ctx, s3Key, tempFile); err != nil {
-		return fmt.Errorf("failed to download backup: %w", err)
+	if err := d.store.DownloadFile(ctx, s3Key, tempFile); err != nil {
+		return withCategory(ErrStorage, fmt.Errorf("failed to download backup: %w", err))
+	}
+
+	if err := d.verifyManifest(ctx, s3Key, tempFile); err != nil {
+		d.logger.Warn("Keeping downloaded backup that failed validation", "path", tempFile)
+		return err
+	}
+
+	if err := d.restoreArchive(ctx, tempFile); err != nil {
+		d.logger.Warn("Keeping downloaded backup after failed restore", "path", tempFile)
+		return fmt.Errorf("failed to restore backup: %w", err)
 	}
 
-	// Cleanup temporary file
+	// Cleanup temporary file only once the restore succeeded
 	if err := os.Remove(tempFile); err != nil {
 		d.logger.Warn("Failed to remove temporary backup file",
-			zap.String("path", tempFile),
-			zap.Error(err))
+			"path", tempFile,
+			"error", err)
 	}
 
 	return nil
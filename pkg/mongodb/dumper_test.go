@@ -0,0 +1,347 @@
+package mongodb
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fakeRunner is a DumpRunner writing a few dummy collection files instead of running
+// mongodump. Backup names come from the embedded MongoDumper.
+type fakeRunner struct {
+	*MongoDumper
+	collections map[string]int // Documents per "<db>/<collection>"
+	gzip        bool           // Write .bson.gz files like mongodump --gzip
+	err         error          // Returned instead of writing a dump
+}
+
+func (r *fakeRunner) CreateDump(ctx context.Context, outputPath string) error {
+	if r.err != nil {
+		return r.err
+	}
+	for name, documents := range r.collections {
+		base := filepath.Join(outputPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+			return err
+		}
+		files := map[string][]byte{
+			base + ".bson":          bsonDocuments(documents),
+			base + ".metadata.json": []byte(`{"indexes":[]}`),
+		}
+		for path, data := range files {
+			if r.gzip {
+				path, data = path+".gz", gzipBytes(data)
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bsonDocuments returns n BSON documents as mongodump writes them to a .bson file
+func bsonDocuments(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		doc, err := bson.Marshal(bson.D{{Key: "_id", Value: i}, {Key: "name", Value: "document " + strconv.Itoa(i)}})
+		if err != nil {
+			panic(err)
+		}
+		buf.Write(doc)
+	}
+	return buf.Bytes()
+}
+
+// gzipBytes gzips data like mongodump --gzip does per file
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	gz.Close()
+	return buf.Bytes()
+}
+
+// newFakeRunnerDumper returns a Dumper on a fake store whose dumps come from a fakeRunner
+func newFakeRunnerDumper(t *testing.T, cfg DumperConfig, collections map[string]int) (*Dumper, *fakeStore, *fakeRunner) {
+	t.Helper()
+	store := newFakeStore()
+	d := newTestDumper(t, cfg, store)
+	runner := &fakeRunner{MongoDumper: d.mongoDump, collections: collections, gzip: cfg.MongodumpGzip}
+	d.runner = runner
+	return d, store, runner
+}
+
+// zipEntries returns the names of the files in a zip archive
+func zipEntries(t *testing.T, data []byte) []string {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, filepath.ToSlash(file.Name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDumpCompressesAndUploads(t *testing.T) {
+	d, store, _ := newFakeRunnerDumper(t, DumperConfig{Database: "app"}, map[string]int{
+		"app/users":  3,
+		"app/orders": 5,
+	})
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	result := d.LastBackup()
+	if !strings.HasPrefix(result.S3Key, "test/") || !strings.HasSuffix(result.S3Key, ".zip") {
+		t.Fatalf("archive key = %q, want test/.../*.zip", result.S3Key)
+	}
+	archive, ok := store.object(result.S3Key)
+	if !ok {
+		t.Fatalf("archive %s not uploaded, store has %v", result.S3Key, store.keys())
+	}
+	if result.SizeBytes != int64(len(archive.data)) {
+		t.Errorf("SizeBytes = %d, uploaded %d bytes", result.SizeBytes, len(archive.data))
+	}
+
+	wantOriginal := int64(len(bsonDocuments(3)) + len(bsonDocuments(5)))
+	if result.OriginalSizeBytes != wantOriginal {
+		t.Errorf("OriginalSizeBytes = %d, want %d", result.OriginalSizeBytes, wantOriginal)
+	}
+	if got := archive.metadata["collection-count"]; got != "2" {
+		t.Errorf("collection-count metadata = %q, want 2", got)
+	}
+	if got := archive.metadata["original-size"]; got != strconv.FormatInt(wantOriginal, 10) {
+		t.Errorf("original-size metadata = %q, want %d", got, wantOriginal)
+	}
+
+	want := []string{"app/orders.bson", "app/orders.metadata.json", "app/users.bson", "app/users.metadata.json"}
+	if got := zipEntries(t, archive.data); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("archive entries = %v, want %v", got, want)
+	}
+	if _, ok := store.object(manifestKey(result.S3Key)); !ok {
+		t.Errorf("manifest not uploaded, store has %v", store.keys())
+	}
+
+	// The dump directory and the archive are removed after the upload
+	entries, err := os.ReadDir(d.tempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("temp directory not cleaned up: %v", entries)
+	}
+}
+
+func TestDumpReportsRunnerAndUploadErrors(t *testing.T) {
+	d, store, runner := newFakeRunnerDumper(t, DumperConfig{}, map[string]int{"app/users": 1})
+	runner.err = errors.New("mongodump exited with status 1")
+	err := d.Dump(context.Background())
+	var dumpErr *DumpError
+	if !errors.As(err, &dumpErr) || !errors.Is(err, ErrMongo) {
+		t.Errorf("Dump with a failing runner = %v, want a DumpError matching ErrMongo", err)
+	}
+	if keys := store.keys(); len(keys) != 0 {
+		t.Errorf("failed dump uploaded %v", keys)
+	}
+
+	runner.err = nil
+	store.uploadErr = errors.New("connection reset")
+	err = d.Dump(context.Background())
+	var uploadErr *UploadError
+	if !errors.As(err, &uploadErr) || !errors.Is(err, ErrStorage) {
+		t.Errorf("Dump with a failing store = %v, want an UploadError matching ErrStorage", err)
+	}
+}
+
+func TestManifestRecordsRelease(t *testing.T) {
+	d, store, _ := newFakeRunnerDumper(t, DumperConfig{
+		Database:       "app",
+		ReleaseSHA:     "4f2a9c1",
+		ReleaseVersion: "v1.8.0",
+	}, map[string]int{"app/users": 1})
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	object, ok := store.object(manifestKey(d.LastBackup().S3Key))
+	if !ok {
+		t.Fatalf("manifest not uploaded, store has %v", store.keys())
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(object.data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.ReleaseSHA != "4f2a9c1" || manifest.ReleaseVersion != "v1.8.0" {
+		t.Errorf("manifest release = %q %q, want 4f2a9c1 v1.8.0", manifest.ReleaseSHA, manifest.ReleaseVersion)
+	}
+}
+
+func TestManifestRecordsModifiedSince(t *testing.T) {
+	since := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	d, store, _ := newFakeRunnerDumper(t, DumperConfig{
+		Database:      "app",
+		Collections:   []string{"users"},
+		ModifiedSince: since,
+	}, map[string]int{"app/users": 2})
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	object, ok := store.object(manifestKey(d.LastBackup().S3Key))
+	if !ok {
+		t.Fatalf("manifest not uploaded, store has %v", store.keys())
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(object.data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if !manifest.ModifiedSince.Equal(since) || manifest.ModifiedSinceField != DefaultModifiedSinceField {
+		t.Errorf("manifest modified since %v on %q, want %v on %q",
+			manifest.ModifiedSince, manifest.ModifiedSinceField, since, DefaultModifiedSinceField)
+	}
+
+	// Full backups leave the fields out
+	d.config.ModifiedSince = time.Time{}
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	object, _ = store.object(manifestKey(d.LastBackup().S3Key))
+	if strings.Contains(string(object.data), "modified_since") {
+		t.Errorf("full backup manifest has incremental fields:\n%s", object.data)
+	}
+}
+
+func TestSuccessMarkerWrittenLast(t *testing.T) {
+	d, store, _ := newFakeRunnerDumper(t, DumperConfig{WriteSuccessMarker: true, UploadDumpLog: true},
+		map[string]int{"app/users": 1})
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := trimArchiveExtension(d.LastBackup().S3Key)
+	order := store.writeOrder()
+	want := []string{prefix + ".zip", prefix + ManifestSuffix, prefix + ".log", prefix + "/" + SuccessMarkerName}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("write order = %v, want %v", order, want)
+	}
+
+	backups, err := d.ListBackups(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Errorf("ListBackups of a complete backup = %v, want its archive, manifest and log", backups)
+	}
+}
+
+func TestSuccessMarkerOnlyAfterFullSuccess(t *testing.T) {
+	d, store, runner := newFakeRunnerDumper(t, DumperConfig{WriteSuccessMarker: true}, map[string]int{"app/users": 1})
+	ctx := context.Background()
+	hasMarker := func() bool {
+		for _, key := range store.keys() {
+			if strings.HasSuffix(key, "/"+SuccessMarkerName) {
+				return true
+			}
+		}
+		return false
+	}
+
+	runner.err = errors.New("mongodump exited with status 1")
+	if err := d.Dump(ctx); err == nil || hasMarker() {
+		t.Errorf("failed dump: err = %v, marker written %v", err, hasMarker())
+	}
+	runner.err = nil
+
+	store.failUpload = func(key string) bool { return strings.HasSuffix(key, ".zip") }
+	if err := d.Dump(ctx); err == nil || hasMarker() {
+		t.Errorf("failed archive upload: err = %v, marker written %v", err, hasMarker())
+	}
+
+	// A backup whose marker could not be written fails and stays invisible to listings
+	store.failUpload = func(key string) bool { return strings.HasSuffix(key, SuccessMarkerName) }
+	var uploadErr *UploadError
+	if err := d.Dump(ctx); !errors.As(err, &uploadErr) || uploadErr.Phase != "success marker" {
+		t.Errorf("failed marker upload = %v, want an UploadError of the success marker", err)
+	}
+	backups, err := d.ListBackups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 0 {
+		t.Errorf("ListBackups without a success marker = %v, want none", backups)
+	}
+}
+
+func TestSanitizedKeysRoundTrip(t *testing.T) {
+	cfg := DumperConfig{Environment: "Prod EU", Database: "Sales/Orders", KeyLowercase: true}
+	d, store, _ := newFakeRunnerDumper(t, cfg, map[string]int{"app/users": 1})
+
+	// An older backup under the same sanitized prefix, to be pruned
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	oldKey := fmt.Sprintf("prod-eu/%s/sales-orders-prod-eu-%s.zip", old.Format("2006-01-02"), old.Format(backupTimestampLayout))
+	store.put(oldKey, []byte("archive"), nil, old)
+
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	key := d.LastBackup().S3Key
+	if !strings.HasPrefix(key, "prod-eu/") || !strings.Contains(key, "/sales-orders-prod-eu-") {
+		t.Fatalf("backup key = %q, want a lowercased, sanitized prod-eu/.../sales-orders-prod-eu-... key", key)
+	}
+
+	latest, err := d.LatestBackup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Key != key {
+		t.Errorf("LatestBackup = %q, want the new backup %q", latest.Key, key)
+	}
+
+	if err := d.PruneBackups(context.Background(), 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.object(oldKey); ok {
+		t.Error("older backup under the sanitized prefix was not pruned")
+	}
+	if _, ok := store.object(key); !ok {
+		t.Error("newest backup was pruned")
+	}
+}
+
+func TestGzippedDumpCountsCollections(t *testing.T) {
+	log := &recordingLogger{}
+	d, store, _ := newFakeRunnerDumper(t, DumperConfig{Database: "app", MongodumpGzip: true, Log: log},
+		map[string]int{"app/users": 3, "app/orders": 5})
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"STEP 1/4: MongoDB dump completed", "Backup process completed successfully"} {
+		if !log.contains("INFO "+msg, "collection_count=2") {
+			t.Errorf("%q does not count the 2 .bson.gz collections:\n%s", msg, log.String())
+		}
+	}
+	if key := d.LastBackup().S3Key; !strings.HasSuffix(key, ".tar") {
+		t.Errorf("gzipped dump stored as %q, want a .tar that isn't compressed again", key)
+	} else if _, ok := store.object(key); !ok {
+		t.Errorf("archive %s not uploaded", key)
+	}
+}
@@ -0,0 +1,129 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error categories. Errors returned by the Dumper are tagged with one of them where the
+// failing step is known, so callers can tell failures apart with errors.Is, e.g. to pick an
+// exit code. The tag doesn't change the error message.
+var (
+	// ErrConfig marks an invalid configuration
+	ErrConfig = errors.New("invalid configuration")
+	// ErrMongo marks a failure talking to MongoDB or running mongodump and mongorestore
+	ErrMongo = errors.New("MongoDB operation failed")
+	// ErrCompression marks a failure creating or extracting a backup archive
+	ErrCompression = errors.New("compression failed")
+	// ErrStorage marks a failure uploading to or downloading from the object store
+	ErrStorage = errors.New("object storage operation failed")
+)
+
+// categorizedError tags an error with one of the error categories
+type categorizedError struct {
+	category error
+	err      error
+}
+
+// Error returns the message of the tagged error
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns both the category and the tagged error, so errors.Is and errors.As match either
+func (e *categorizedError) Unwrap() []error {
+	return []error{e.category, e.err}
+}
+
+// withCategory tags err with a category, returning nil for a nil error
+func withCategory(category, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorizedError{category: category, err: err}
+}
+
+// DumpError is returned when mongodump or the steps around it fail
+type DumpError struct {
+	Phase      string // Backup step that failed, e.g. "dump", "stream" or "pipelined dump and upload"
+	Database   string // Database being dumped, empty for the whole server
+	Collection string // Collection being dumped, empty if mongodump ran for a database or server
+	Err        error
+}
+
+// Error describes the failed dump and its cause
+func (e *DumpError) Error() string {
+	target := e.Database
+	if e.Collection != "" {
+		target += "." + e.Collection
+	}
+	if target == "" {
+		return fmt.Sprintf("failed to create MongoDB dump: %v", e.Err)
+	}
+	return fmt.Sprintf("failed to create MongoDB dump of %s: %v", target, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *DumpError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes a DumpError match ErrMongo
+func (e *DumpError) Is(target error) bool {
+	return target == ErrMongo
+}
+
+// UploadError is returned when storing a backup object fails
+type UploadError struct {
+	Phase string // Backup step that failed, e.g. "upload" or "stream"
+	Key   string // Object key, or the key prefix of a pipelined backup
+	Err   error
+}
+
+// Error describes the failed upload and its cause
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("failed to upload %s: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes an UploadError match ErrStorage
+func (e *UploadError) Is(target error) bool {
+	return target == ErrStorage
+}
+
+// CompressionError is returned when creating or extracting a backup archive fails
+type CompressionError struct {
+	Phase string // "compress" or "extract"
+	Path  string // Archive path
+	Err   error
+}
+
+// Error describes the failed archive operation and its cause
+func (e *CompressionError) Error() string {
+	return fmt.Sprintf("failed to %s %s: %v", e.Phase, e.Path, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *CompressionError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes a CompressionError match ErrCompression
+func (e *CompressionError) Is(target error) bool {
+	return target == ErrCompression
+}
+
+// dumpError returns err as a DumpError of phase. A DumpError from mongodump keeps the
+// collection it names and only gains the phase.
+func (d *Dumper) dumpError(phase string, err error) error {
+	var dumpErr *DumpError
+	if errors.As(err, &dumpErr) {
+		dumpErr.Phase = phase
+		return err
+	}
+	return &DumpError{Phase: phase, Database: d.config.Database, Err: err}
+}
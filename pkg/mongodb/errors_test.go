@@ -0,0 +1,92 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// errorCategories are all categories, an error must match exactly one of them
+var errorCategories = []error{ErrConfig, ErrMongo, ErrCompression, ErrStorage}
+
+// checkCategory reports an error unless err matches category and no other one
+func checkCategory(t *testing.T, what string, err, category error) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("%s succeeded, want a %q error", what, category)
+		return
+	}
+	for _, c := range errorCategories {
+		if got := errors.Is(err, c); got != (c == category) {
+			t.Errorf("%s: errors.Is(%q) = %v for %v", what, c, got, err)
+		}
+	}
+}
+
+func TestErrorCategories(t *testing.T) {
+	cause := errors.New("connection refused")
+	tests := []struct {
+		err      error
+		category error
+		message  string
+	}{
+		{withCategory(ErrConfig, cause), ErrConfig, "connection refused"},
+		{&DumpError{Database: "app", Collection: "users", Err: cause}, ErrMongo,
+			"failed to create MongoDB dump of app.users: connection refused"},
+		{&UploadError{Key: "prod/backup.zip", Err: cause}, ErrStorage,
+			"failed to upload prod/backup.zip: connection refused"},
+		{&CompressionError{Phase: "extract", Path: "backup.zip", Err: cause}, ErrCompression,
+			"failed to extract backup.zip: connection refused"},
+	}
+	for _, tt := range tests {
+		checkCategory(t, tt.message, tt.err, tt.category)
+		// The category survives further wrapping and doesn't hide the cause
+		wrapped := fmt.Errorf("backup failed: %w", tt.err)
+		checkCategory(t, "wrapped "+tt.message, wrapped, tt.category)
+		if !errors.Is(wrapped, cause) {
+			t.Errorf("%s: cause lost", tt.message)
+		}
+		if tt.err.Error() != tt.message {
+			t.Errorf("Error() = %q, want %q", tt.err.Error(), tt.message)
+		}
+	}
+
+	if withCategory(ErrConfig, nil) != nil {
+		t.Error("withCategory of a nil error is not nil")
+	}
+}
+
+func TestDumperErrorCategories(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewDumper(DumperConfig{})
+	checkCategory(t, "NewDumper without a MongoDB URI", err, ErrConfig)
+
+	d, store, runner := newFakeRunnerDumper(t, DumperConfig{Database: "app"}, map[string]int{"app/users": 1})
+	runner.err = errors.New("exit status 1")
+	err = d.Dump(ctx)
+	checkCategory(t, "Dump with a failing mongodump", err, ErrMongo)
+	var dumpErr *DumpError
+	if !errors.As(err, &dumpErr) || dumpErr.Database != "app" {
+		t.Errorf("Dump error %v is not a DumpError of app", err)
+	}
+
+	runner.err = nil
+	store.uploadErr = errors.New("503 Service Unavailable")
+	err = d.Dump(ctx)
+	checkCategory(t, "Dump with a failing upload", err, ErrStorage)
+	var uploadErr *UploadError
+	if !errors.As(err, &uploadErr) || uploadErr.Key == "" {
+		t.Errorf("Dump error %v is not an UploadError naming the key", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "corrupt.zip")
+	if err := os.WriteFile(archive, []byte("not a zip archive"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err = d.RestoreFromFile(ctx, archive)
+	checkCategory(t, "RestoreFromFile of a corrupt archive", err, ErrCompression)
+}
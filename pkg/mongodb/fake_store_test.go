@@ -0,0 +1,264 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeObject is an object held by fakeStore
+type fakeObject struct {
+	data         []byte
+	metadata     map[string]string
+	lastModified time.Time
+}
+
+// fakeStore is an in-memory ObjectStore. It records the order objects were written in, and
+// uploadErr, if set, fails every upload, failUpload only those of the keys it returns true for.
+// onUpload, if set, is called with the key of every stored upload.
+type fakeStore struct {
+	mu         sync.Mutex
+	objects    map[string]fakeObject
+	written    []string // Keys in the order they were uploaded
+	uploadErr  error
+	failUpload func(key string) bool
+	onUpload   func(key string)
+}
+
+var _ ObjectStore = (*fakeStore)(nil)
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{objects: map[string]fakeObject{}}
+}
+
+// put stores an object directly, e.g. to seed backups for listing and pruning
+func (s *fakeStore) put(key string, data []byte, metadata map[string]string, lastModified time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = fakeObject{data: data, metadata: metadata, lastModified: lastModified}
+	s.written = append(s.written, key)
+}
+
+func (s *fakeStore) upload(key string, data []byte, metadata map[string]string) error {
+	if s.uploadErr != nil {
+		return s.uploadErr
+	}
+	if s.failUpload != nil && s.failUpload(key) {
+		return fmt.Errorf("upload of %s rejected", key)
+	}
+	s.put(key, data, metadata, time.Now())
+	if s.onUpload != nil {
+		s.onUpload(key)
+	}
+	return nil
+}
+
+// object returns a stored object
+func (s *fakeStore) object(key string) (fakeObject, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	object, ok := s.objects[key]
+	return object, ok
+}
+
+// keys returns the stored keys, sorted
+func (s *fakeStore) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeOrder returns the keys in the order they were uploaded
+func (s *fakeStore) writeOrder() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.written...)
+}
+
+func (s *fakeStore) UploadFile(ctx context.Context, filePath string, opts UploadOptions) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return s.upload(opts.Key, data, opts.Metadata)
+}
+
+func (s *fakeStore) UploadStream(ctx context.Context, r io.Reader, opts UploadOptions) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), s.upload(opts.Key, data, opts.Metadata)
+}
+
+func (s *fakeStore) UploadBytes(ctx context.Context, data []byte, s3Key, contentType string) error {
+	return s.upload(s3Key, append([]byte{}, data...), nil)
+}
+
+func (s *fakeStore) DownloadFile(ctx context.Context, s3Key, localPath string) error {
+	data, err := s.DownloadBytes(ctx, s3Key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(localPath, data, 0o600)
+}
+
+func (s *fakeStore) DownloadBytes(ctx context.Context, s3Key string) ([]byte, error) {
+	object, ok := s.object(s3Key)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", s3Key, ErrObjectNotFound)
+	}
+	return object.data, nil
+}
+
+func (s *fakeStore) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	for _, key := range s.keys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		object, _ := s.object(key)
+		objects = append(objects, ObjectInfo{Key: key, Size: int64(len(object.data)), LastModified: object.lastModified})
+	}
+	return objects, nil
+}
+
+func (s *fakeStore) ListBackups(ctx context.Context, prefix string) ([]BackupInfo, error) {
+	objects, _ := s.ListObjects(ctx, prefix)
+	backups := make([]BackupInfo, 0, len(objects))
+	for _, object := range objects {
+		backups = append(backups, BackupInfo{Key: object.Key, Size: object.Size, LastModified: object.LastModified})
+	}
+	return backups, nil
+}
+
+func (s *fakeStore) DeleteObject(ctx context.Context, s3Key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, s3Key)
+	return nil
+}
+
+func (s *fakeStore) DeleteObjects(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := s.DeleteObject(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newTestDumper builds a Dumper on store without looking up mongodump or mongorestore. The
+// runner is the MongoDumper, tests replace it to back up without MongoDB.
+func newTestDumper(t testing.TB, cfg DumperConfig, store ObjectStore) *Dumper {
+	t.Helper()
+	if cfg.TempDir == "" {
+		cfg.TempDir = t.TempDir()
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = "test"
+	}
+	cfg.SkipPreflight = true
+
+	mongoDump := &MongoDumper{
+		config:      cfg,
+		logger:      cfg.logger(),
+		output:      newTailBuffer(maxCapturedOutput),
+		toolVersion: "100.9.4",
+	}
+	d := &Dumper{
+		config:    cfg,
+		store:     store,
+		mongoDump: mongoDump,
+		runner:    mongoDump,
+		restorer:  &MongoRestorer{config: cfg, logger: cfg.logger()},
+		logger:    cfg.logger(),
+		s3Breaker: newCircuitBreaker(cfg.S3BreakerThreshold, cfg.S3BreakerCooldown),
+		samples:   newBackupSamples(cfg.statsWindow()),
+	}
+	var err error
+	if d.codec, err = d.newCompressionCodec(d.archiveCompression()); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestPruneBackupsKeepsNewest(t *testing.T) {
+	store := newFakeStore()
+	now := time.Now().UTC()
+	var names []string
+	for i := 0; i < 4; i++ {
+		created := now.Add(-time.Duration(i) * 24 * time.Hour)
+		name := fmt.Sprintf("test/%s/app-test-%s", created.Format("2006-01-02"), created.Format(backupTimestampLayout))
+		names = append(names, name)
+		store.put(name+".zip", []byte("archive"), nil, created)
+		store.put(name+ManifestSuffix, []byte("{}"), nil, created)
+	}
+	store.put("test/unrelated.txt", []byte("keep"), nil, now)
+
+	d := newTestDumper(t, DumperConfig{}, store)
+	if err := d.PruneBackups(context.Background(), 2, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, name := range names {
+		_, ok := store.object(name + ".zip")
+		_, manifest := store.object(name + ManifestSuffix)
+		if want := i < 2; ok != want || manifest != want {
+			t.Errorf("backup %d: archive kept %v, manifest kept %v, want %v", i, ok, manifest, want)
+		}
+	}
+	if _, ok := store.object("test/unrelated.txt"); !ok {
+		t.Error("object without a timestamp was pruned")
+	}
+}
+
+func TestPruneBackupsNeverDeletesNewest(t *testing.T) {
+	store := newFakeStore()
+	old := time.Now().UTC().Add(-90 * 24 * time.Hour)
+	key := fmt.Sprintf("test/%s/app-test-%s.zip", old.Format("2006-01-02"), old.Format(backupTimestampLayout))
+	store.put(key, []byte("archive"), nil, old)
+
+	d := newTestDumper(t, DumperConfig{}, store)
+	if err := d.PruneBackups(context.Background(), 0, 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.object(key); !ok {
+		t.Error("the only backup was pruned")
+	}
+}
+
+func TestLatestBackupSkipsNonArchives(t *testing.T) {
+	store := newFakeStore()
+	now := time.Now().UTC()
+	older := now.Add(-time.Hour)
+	olderName := fmt.Sprintf("test/%s/app-test-%s", older.Format("2006-01-02"), older.Format(backupTimestampLayout))
+	newerName := fmt.Sprintf("test/%s/app-test-%s", now.Format("2006-01-02"), now.Format(backupTimestampLayout))
+	store.put(olderName+".zip", []byte("archive"), nil, older)
+	store.put(newerName+ManifestSuffix, []byte("{}"), nil, now)
+
+	d := newTestDumper(t, DumperConfig{}, store)
+	latest, err := d.LatestBackup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Key != olderName+".zip" {
+		t.Errorf("LatestBackup = %q, want %q", latest.Key, olderName+".zip")
+	}
+
+	if _, err := newTestDumper(t, DumperConfig{}, newFakeStore()).LatestBackup(context.Background()); !errors.Is(err, ErrNoBackups) {
+		t.Errorf("LatestBackup of an empty store = %v, want ErrNoBackups", err)
+	}
+}
@@ -0,0 +1,238 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fsTempPrefix starts the names of files uploads are written to before being renamed into
+// place. Listings skip them, so a crashed upload never shows up as a backup.
+const fsTempPrefix = ".upload-"
+
+// FilesystemStore stores backups as files below a local directory, e.g. an NFS mount. Keys
+// map to paths, so the directory mirrors the layout backups have in a bucket.
+type FilesystemStore struct {
+	root   string
+	logger Logger
+}
+
+// NewFilesystemStore creates a store writing below cfg.LocalDir, creating it if needed
+func NewFilesystemStore(cfg DumperConfig) (*FilesystemStore, error) {
+	if err := os.MkdirAll(cfg.LocalDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &FilesystemStore{
+		root:   cfg.LocalDir,
+		logger: cfg.logger(),
+	}, nil
+}
+
+// path returns the file a key is stored in. Keys are cleaned as absolute paths first, so
+// ".." can't escape the root.
+func (f *FilesystemStore) path(key string) string {
+	return filepath.Join(f.root, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+// write stores everything read from r under key. The data goes to a temporary file next to
+// the target that is only renamed into place once complete, so readers never see a
+// partial backup.
+func (f *FilesystemStore) write(key string, r io.Reader) (int64, error) {
+	target := f.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), fsTempPrefix+"*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	// Make sure the data is on disk before it becomes visible, NFS included
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to sync %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return 0, fmt.Errorf("failed to move %s into place: %w", key, err)
+	}
+	return n, nil
+}
+
+// UploadFile copies a file into the backup directory
+func (f *FilesystemStore) UploadFile(ctx context.Context, filePath string, opts UploadOptions) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file for upload: %w", err)
+	}
+	defer file.Close()
+
+	f.logger.Info("Copying backup to local directory",
+		"local_path", filePath,
+		"destination", f.path(opts.Key))
+
+	size, err := f.write(opts.Key, file)
+	if err != nil {
+		return err
+	}
+
+	f.logger.Info("Successfully copied backup", "key", opts.Key, "size_bytes", size)
+	return nil
+}
+
+// UploadStream writes everything read from r, returning the number of bytes written. A read
+// error leaves no file behind.
+func (f *FilesystemStore) UploadStream(ctx context.Context, r io.Reader, opts UploadOptions) (int64, error) {
+	f.logger.Info("Streaming backup to local directory", "destination", f.path(opts.Key))
+	return f.write(opts.Key, r)
+}
+
+// UploadBytes writes a small in-memory object
+func (f *FilesystemStore) UploadBytes(ctx context.Context, data []byte, s3Key, contentType string) error {
+	_, err := f.write(s3Key, bytes.NewReader(data))
+	return err
+}
+
+// DownloadFile copies a stored backup to localPath
+func (f *FilesystemStore) DownloadFile(ctx context.Context, s3Key, localPath string) error {
+	src, err := os.Open(f.path(s3Key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%s: %w", s3Key, ErrObjectNotFound)
+		}
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	return dst.Close()
+}
+
+// DownloadBytes reads a small stored object, returning ErrObjectNotFound if it does not exist
+func (f *FilesystemStore) DownloadBytes(ctx context.Context, s3Key string) ([]byte, error) {
+	data, err := os.ReadFile(f.path(s3Key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s: %w", s3Key, ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", s3Key, err)
+	}
+	return data, nil
+}
+
+// ListObjects walks the backup directory for files whose key starts with prefix
+func (f *FilesystemStore) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	// Only walk the deepest directory the prefix is certain to be in
+	start := f.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		start = f.path(prefix[:i])
+	}
+
+	var objects []ObjectInfo
+	err := filepath.WalkDir(start, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// A prefix that was never written is an empty listing
+			if file == start && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), fsTempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(f.root, file)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	return objects, nil
+}
+
+// ListBackups lists all backups below a prefix
+func (f *FilesystemStore) ListBackups(ctx context.Context, prefix string) ([]BackupInfo, error) {
+	f.logger.Info("Listing backups", "prefix", prefix)
+
+	objects, err := f.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]BackupInfo, 0, len(objects))
+	for _, object := range objects {
+		backups = append(backups, BackupInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		})
+	}
+	return backups, nil
+}
+
+// DeleteObject removes a stored file, then any directories left empty up to the root
+func (f *FilesystemStore) DeleteObject(ctx context.Context, s3Key string) error {
+	file := f.path(s3Key)
+	if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", s3Key, err)
+	}
+
+	for dir := filepath.Dir(file); dir != filepath.Clean(f.root); dir = filepath.Dir(dir) {
+		// Remove fails on the first directory that still has files, which ends the cleanup
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// DeleteObjects removes stored files, logging every removed key
+func (f *FilesystemStore) DeleteObjects(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := f.DeleteObject(ctx, key); err != nil {
+			return err
+		}
+		f.logger.Info("Deleted backup file", "key", key)
+	}
+	return nil
+}
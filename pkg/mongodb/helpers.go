@@ -3,9 +3,15 @@ package mongodb
 import (
 	"fmt"
 	"io"
+	"net/url"
 	"os/exec"
+	"strings"
+	"sync"
 )
 
+// maxCapturedOutput bounds how much command output is kept in memory per stream
+const maxCapturedOutput = 1024 * 1024 // 1MB
+
 // Helper functions
 
 // GetValueOrDefault returns the value or a default if empty
@@ -30,3 +36,201 @@ func CaptureCommandOutput(cmd *exec.Cmd) (io.ReadCloser, io.ReadCloser, error) {
 
 	return stdout, stderr, nil
 }
+
+// BuildURI merges query options into a MongoDB connection string, overriding any existing
+// value for the same option rather than duplicating it, e.g. to add replicaSet or authSource
+func BuildURI(base string, opts map[string]string) (string, error) {
+	if !strings.HasPrefix(base, "mongodb://") && !strings.HasPrefix(base, "mongodb+srv://") {
+		return "", fmt.Errorf("invalid MongoDB URI: must start with mongodb:// or mongodb+srv://")
+	}
+	if len(opts) == 0 {
+		return base, nil
+	}
+
+	uri, rawQuery, _ := strings.Cut(base, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URI options: %w", err)
+	}
+
+	for key, value := range opts {
+		// Option names are case-insensitive, so drop any differently-cased duplicate
+		for existing := range query {
+			if strings.EqualFold(existing, key) {
+				query.Del(existing)
+			}
+		}
+		query.Set(key, value)
+	}
+
+	// Options must follow a "/" after the host list, e.g. mongodb://host/?opt=1
+	if _, hosts, _ := strings.Cut(uri, "://"); !strings.Contains(hosts, "/") {
+		uri += "/"
+	}
+
+	return uri + "?" + query.Encode(), nil
+}
+
+// RedactedURIParams are masked in connection string options by RedactMongoURI. Like the
+// logger's RedactFields they match case-insensitively anywhere in the option name.
+var RedactedURIParams = []string{"password", "secret", "token", "authMechanismProperties"}
+
+// RedactMongoURI masks the password and secret options of a connection string, keeping the
+// scheme, user, hosts, database and other options readable, e.g.
+// mongodb://user:***@h1:27017,h2:27017/db?authSource=admin&tlsCertificateKeyFilePassword=***.
+// Strings without a scheme are fully redacted.
+func RedactMongoURI(uri string) string {
+	if uri == "" {
+		return ""
+	}
+
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return "[REDACTED_URI]"
+	}
+
+	// The last '@' ends the credentials, an unescaped '@' or '/' in a password would
+	// otherwise leak part of it. An '@' in the options only masks more than necessary.
+	var credentials string
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		user, _, hasPassword := strings.Cut(rest[:at], ":")
+		credentials = user + "@"
+		if hasPassword {
+			credentials = user + ":***@"
+		}
+		rest = rest[at+1:]
+	}
+
+	// Options are masked in place so the remaining ones keep their original encoding
+	hosts, query, hasQuery := strings.Cut(rest, "?")
+	if hasQuery {
+		params := strings.Split(query, "&")
+		for i, param := range params {
+			key, _, _ := strings.Cut(param, "=")
+			if name, err := url.QueryUnescape(key); err == nil {
+				key = name
+			}
+			if isRedactedURIParam(key) {
+				params[i] = key + "=***"
+			}
+		}
+		hosts += "?" + strings.Join(params, "&")
+	}
+
+	return scheme + "://" + credentials + hosts
+}
+
+// MongoHosts returns the host:port list of a connection string without credentials, database
+// or options, for error messages
+func MongoHosts(uri string) string {
+	_, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return "[REDACTED_URI]"
+	}
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		rest = rest[at+1:]
+	}
+	if end := strings.IndexAny(rest, "/?"); end >= 0 {
+		rest = rest[:end]
+	}
+	return rest
+}
+
+// uriHasCredentials reports whether a connection string embeds a user name
+func uriHasCredentials(uri string) bool {
+	_, rest, _ := strings.Cut(uri, "://")
+	rest, _, _ = strings.Cut(rest, "?")
+	return strings.Contains(rest, "@")
+}
+
+// isRedactedURIParam reports whether a connection string option holds a secret
+func isRedactedURIParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, field := range RedactedURIParams {
+		if strings.Contains(name, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+// uriDatabase returns the database name from the path of a MongoDB connection string, if any
+func uriDatabase(uri string) string {
+	base, _, _ := strings.Cut(uri, "?")
+	_, hosts, ok := strings.Cut(base, "://")
+	if !ok {
+		return ""
+	}
+	_, database, _ := strings.Cut(hosts, "/")
+	return database
+}
+
+// tailBuffer is a concurrency-safe buffer that keeps only the last limit bytes written,
+// so capturing the output of a long-running command cannot grow memory without bound
+type tailBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+// newTailBuffer creates a tailBuffer keeping at most limit bytes
+func newTailBuffer(limit int) *tailBuffer {
+	return &tailBuffer{limit: limit}
+}
+
+// Write implements io.Writer, discarding the oldest bytes once the limit is exceeded
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.limit; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// WriteString appends a string to the buffer
+func (b *tailBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// String returns the buffered output, marking it when older output was discarded
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.truncated {
+		return "[earlier output truncated]\n" + string(b.buf)
+	}
+	return string(b.buf)
+}
+
+// Reset discards all buffered output
+func (b *tailBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = b.buf[:0]
+	b.truncated = false
+}
+
+// sanitizeKeyComponent replaces characters that some S3-compatible providers reject or
+// mangle with '-', and optionally lowercases the result
+func sanitizeKeyComponent(value string, lowercase bool) string {
+	if lowercase {
+		value = strings.ToLower(value)
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '-'
+		}
+	}, value)
+}
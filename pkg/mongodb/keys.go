@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultKeyTemplate is the S3 key layout used when no KeyTemplate is configured
+const DefaultKeyTemplate = "{{.Env}}/{{.Date}}/{{.DB}}-{{.Env}}-{{.Timestamp}}"
+
+// KeyTemplateData holds the variables available to a KeyTemplate. Env and DB are sanitized
+// key components.
+type KeyTemplateData struct {
+	Env       string // Environment, "default" if not set
+	DB        string // Database, "all-databases" if not set
+	Date      string // Local date, 2006-01-02
+	Timestamp string // UTC time, 2006-01-02T15-04-05Z
+}
+
+// keyPlaceholder marks template variables that vary between backups when rendering the
+// static part of the key layout
+const keyPlaceholder = "\x00"
+
+// keyTemplateData returns the template variables of a backup taken at now
+func (c *DumperConfig) keyTemplateData(now time.Time) KeyTemplateData {
+	return KeyTemplateData{
+		Env:       c.KeyComponent(c.GetEnvironment("default")),
+		DB:        c.KeyComponent(c.GetDatabase("all-databases")),
+		Date:      now.Format("2006-01-02"),
+		Timestamp: now.UTC().Format(backupTimestampLayout),
+	}
+}
+
+// RenderKey renders the configured key template (DefaultKeyTemplate if empty)
+func (c *DumperConfig) RenderKey(data KeyTemplateData) (string, error) {
+	tmpl, err := template.New("key").Option("missingkey=error").Parse(GetValueOrDefault(c.KeyTemplate, DefaultKeyTemplate))
+	if err != nil {
+		return "", fmt.Errorf("invalid key template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid key template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// validateKeyTemplate checks that the key template renders, and that every backup gets its
+// own key with a timestamp that listings and retention can parse
+func (c *DumperConfig) validateKeyTemplate() error {
+	data := c.keyTemplateData(time.Now())
+	key, err := c.RenderKey(data)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(key, data.Timestamp) {
+		return errors.New("key template must contain {{.Timestamp}}")
+	}
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, "//") {
+		return fmt.Errorf("key template renders an invalid key %q", key)
+	}
+	return nil
+}
+
+// keyDepth returns how many '/' separate the components of a rendered backup key
+func (c *DumperConfig) keyDepth() int {
+	key, err := c.RenderKey(c.keyTemplateData(time.Now()))
+	if err != nil {
+		return strings.Count(DefaultKeyTemplate, "/")
+	}
+	return strings.Count(key, "/")
+}
+
+// KeyPrefix returns the static part of the key layout every backup key of this config lives
+// under, e.g. the sanitized environment followed by '/' for the default layout
+func (c *DumperConfig) KeyPrefix() string {
+	data := c.keyTemplateData(time.Now())
+	data.DB, data.Date, data.Timestamp = keyPlaceholder, keyPlaceholder, keyPlaceholder
+
+	key, err := c.RenderKey(data)
+	if err != nil {
+		return data.Env + "/"
+	}
+	static, _, _ := strings.Cut(key, keyPlaceholder)
+	return static[:strings.LastIndex(static, "/")+1]
+}
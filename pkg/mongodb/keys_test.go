@@ -0,0 +1,108 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderKey(t *testing.T) {
+	data := KeyTemplateData{Env: "prod", DB: "app", Date: "2024-03-01", Timestamp: "2024-03-01T02-00-00Z"}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", "prod/2024-03-01/app-prod-2024-03-01T02-00-00Z"},
+		{"backups/{{.Env}}/{{.DB}}/{{.Timestamp}}", "backups/prod/app/2024-03-01T02-00-00Z"},
+		{"{{.Timestamp}}", "2024-03-01T02-00-00Z"},
+	}
+	for _, tt := range tests {
+		cfg := DumperConfig{KeyTemplate: tt.template}
+		got, err := cfg.RenderKey(data)
+		if err != nil {
+			t.Errorf("RenderKey with %q: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RenderKey with %q = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestValidateKeyTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string // Error substring, empty if valid
+	}{
+		{"", ""},
+		{"{{.Env}}/{{.DB}}/{{.Timestamp}}", ""},
+		{"{{.Env}}/{{.Date}}/{{.DB}}", "must contain {{.Timestamp}}"},
+		{"{{.Env}}/{{.Timestamp", "invalid key template"},
+		{"{{.Env}}/{{.Host}}/{{.Timestamp}}", "invalid key template"},
+		{"/{{.Env}}/{{.Timestamp}}", "invalid key"},
+		{"{{.Env}}/{{.Timestamp}}/", "invalid key"},
+		{"{{.Env}}//{{.Timestamp}}", "invalid key"},
+	}
+	for _, tt := range tests {
+		cfg := DumperConfig{Environment: "prod", KeyTemplate: tt.template}
+		err := cfg.validateKeyTemplate()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("key template %q rejected: %v", tt.template, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("key template %q = %v, want an error containing %q", tt.template, err, tt.want)
+		}
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", "prod-eu/"},
+		{"backups/{{.Env}}/{{.DB}}/{{.Timestamp}}", "backups/prod-eu/"},
+		{"backups/{{.Date}}/{{.Timestamp}}", "backups/"},
+		{"{{.Timestamp}}-{{.Env}}", ""},
+	}
+	for _, tt := range tests {
+		cfg := DumperConfig{Environment: "Prod EU", KeyLowercase: true, KeyTemplate: tt.template}
+		if got := cfg.KeyPrefix(); got != tt.want {
+			t.Errorf("KeyPrefix with %q = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestKeyTemplateBackupsAreListed(t *testing.T) {
+	d, store, _ := newFakeRunnerDumper(t, DumperConfig{
+		Database:    "app",
+		KeyTemplate: "backups/{{.Env}}/{{.DB}}/{{.Timestamp}}",
+	}, map[string]int{"app/users": 1})
+
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	oldKey := "backups/test/app/" + old.Format(backupTimestampLayout) + ".zip"
+	store.put(oldKey, []byte("archive"), nil, old)
+
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	key := d.LastBackup().S3Key
+	if !strings.HasPrefix(key, "backups/test/app/") || !strings.HasSuffix(key, ".zip") {
+		t.Fatalf("backup key = %q, want it below backups/test/app/", key)
+	}
+
+	latest, err := d.LatestBackup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Key != key {
+		t.Errorf("LatestBackup = %q, want %q", latest.Key, key)
+	}
+	if err := d.PruneBackups(context.Background(), 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.object(oldKey); ok {
+		t.Error("older backup of the key layout was not pruned")
+	}
+}
@@ -0,0 +1,70 @@
+package mongodb
+
+import (
+	"dumper/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Logger is what the package logs through: a message plus alternating keys and values.
+// *logger.Logger implements it, a *zap.Logger is adapted with ZapLogger, and consumers can
+// plug in their own logging.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// ZapLogger adapts a *zap.Logger to Logger. A nil logger discards everything.
+func ZapLogger(l *zap.Logger) Logger {
+	if l == nil {
+		l = logger.Nop()
+	}
+	// Skip the adapter frame so callers are reported correctly
+	return zapLogger{l.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+// zapLogger logs key-value pairs through a sugared zap logger
+type zapLogger struct {
+	s *zap.SugaredLogger
+}
+
+func (l zapLogger) Debug(msg string, keysAndValues ...interface{}) { l.s.Debugw(msg, keysAndValues...) }
+func (l zapLogger) Info(msg string, keysAndValues ...interface{})  { l.s.Infow(msg, keysAndValues...) }
+func (l zapLogger) Warn(msg string, keysAndValues ...interface{})  { l.s.Warnw(msg, keysAndValues...) }
+func (l zapLogger) Error(msg string, keysAndValues ...interface{}) { l.s.Errorw(msg, keysAndValues...) }
+
+// fieldLogger adds fixed key-value pairs to every line of another Logger
+type fieldLogger struct {
+	next   Logger
+	fields []interface{}
+}
+
+func (l fieldLogger) with(keysAndValues []interface{}) []interface{} {
+	return append(append([]interface{}{}, l.fields...), keysAndValues...)
+}
+
+func (l fieldLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.next.Debug(msg, l.with(keysAndValues)...)
+}
+
+func (l fieldLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.next.Info(msg, l.with(keysAndValues)...)
+}
+
+func (l fieldLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.next.Warn(msg, l.with(keysAndValues)...)
+}
+
+func (l fieldLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.next.Error(msg, l.with(keysAndValues)...)
+}
+
+// withFields returns a Logger adding keysAndValues to every line of l
+func withFields(l Logger, keysAndValues ...interface{}) Logger {
+	if z, ok := l.(zapLogger); ok {
+		return zapLogger{z.s.With(keysAndValues...)}
+	}
+	return fieldLogger{next: l, fields: keysAndValues}
+}
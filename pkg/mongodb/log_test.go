@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestZapLoggerNilDiscards(t *testing.T) {
+	log := ZapLogger(nil)
+	log.Debug("debug", "key", "value")
+	log.Info("info")
+	log.Warn("warn", "odd")
+	log.Error("error", "key", nil)
+}
+
+func TestDumperWithoutLogger(t *testing.T) {
+	fakeCommandOnPath(t, "mongorestore", "exit 0\n")
+	mongodump := writeFakeCommand(t, "mongodump", `if [ "$1" = "--version" ]; then
+	echo "mongodump version: 100.9.4"
+	exit 0
+fi
+while [ $# -gt 0 ]; do
+	[ "$1" = "--out" ] && out="$2"
+	shift
+done
+mkdir -p "$out/app"
+echo "users" > "$out/app/users.bson"
+echo "done dumping app.users (1 document)" >&2
+`)
+	localDir := t.TempDir()
+
+	// Neither Logger nor Log is set: nothing may dereference a nil logger
+	d, err := NewDumper(DumperConfig{
+		MongoURI:      "mongodb://localhost:27017",
+		MongodumpPath: mongodump,
+		Provider:      ProviderFS,
+		LocalDir:      localDir,
+		TempDir:       t.TempDir(),
+		Environment:   "test",
+		SkipPreflight: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(localDir, filepath.FromSlash(d.LastBackup().S3Key))); err != nil {
+		t.Errorf("backup not stored: %v", err)
+	}
+	if err := d.PruneBackups(context.Background(), 1, 0); err != nil {
+		t.Errorf("PruneBackups: %v", err)
+	}
+}
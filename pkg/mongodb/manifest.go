@@ -0,0 +1,205 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestSuffix is appended to a backup's key prefix for its manifest object
+const ManifestSuffix = ".manifest.json"
+
+// Manifest describes an archive backup, stored next to it so operators can see what a
+// backup contains without downloading it, and restores can validate the archive first
+type Manifest struct {
+	Archive           string             `json:"archive"`
+	Environment       string             `json:"environment"`
+	Compression       string             `json:"compression"` // Archive extension, e.g. ".tar.zst"
+	SizeBytes         int64              `json:"size_bytes"`
+	OriginalSizeBytes int64              `json:"original_size_bytes"`
+	SHA256            string             `json:"sha256"`
+	DumperVersion     string             `json:"dumper_version"`
+	MongodumpVersion  string             `json:"mongodump_version,omitempty"`
+	StartedAt         time.Time          `json:"started_at"`
+	FinishedAt        time.Time          `json:"finished_at"`
+	Databases         []ManifestDatabase `json:"databases"`
+	ReleaseSHA        string             `json:"release_sha,omitempty"`     // Application release the data belongs to
+	ReleaseVersion    string             `json:"release_version,omitempty"` // (optional)
+	// Incremental backups only: documents modified since this time were dumped. The next
+	// increment chains on by using this backup's StartedAt as its ModifiedSince.
+	ModifiedSince      time.Time `json:"modified_since,omitzero"`
+	ModifiedSinceField string    `json:"modified_since_field,omitempty"`
+}
+
+// ManifestDatabase lists the dumped collections of one database
+type ManifestDatabase struct {
+	Name        string               `json:"name"`
+	Collections []ManifestCollection `json:"collections"`
+}
+
+// ManifestCollection describes one dumped collection
+type ManifestCollection struct {
+	Name      string `json:"name"`
+	Documents int64  `json:"documents"` // -1 if mongodump did not report the count
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// manifestKey returns the manifest key of an archive key or backup prefix
+func manifestKey(s3Key string) string {
+	return trimArchiveExtension(s3Key) + ManifestSuffix
+}
+
+// manifestDatabases lists the collections dumped into dumpDir with their data file sizes and
+// the document counts mongodump reported
+func (d *Dumper) manifestDatabases(dumpDir string) ([]ManifestDatabase, error) {
+	collections := map[string][]ManifestCollection{}
+	err := filepath.Walk(dumpDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isDumpDataFile(path) {
+			return nil
+		}
+
+		// Only <db>/<collection>.bson files, not a top-level oplog.bson
+		relPath, err := filepath.Rel(dumpDir, path)
+		if err != nil {
+			return err
+		}
+		database, file, ok := strings.Cut(filepath.ToSlash(relPath), "/")
+		if !ok {
+			return nil
+		}
+		name := strings.TrimSuffix(strings.TrimSuffix(file, ".gz"), ".bson")
+
+		documents, ok := d.mongoDump.documentCount(database + "." + name)
+		if !ok {
+			documents = -1
+		}
+		collections[database] = append(collections[database], ManifestCollection{
+			Name:      name,
+			Documents: documents,
+			SizeBytes: info.Size(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	databases := make([]ManifestDatabase, 0, len(collections))
+	for name, colls := range collections {
+		sort.Slice(colls, func(i, j int) bool { return colls[i].Name < colls[j].Name })
+		databases = append(databases, ManifestDatabase{Name: name, Collections: colls})
+	}
+	sort.Slice(databases, func(i, j int) bool { return databases[i].Name < databases[j].Name })
+	return databases, nil
+}
+
+// writeManifest uploads the manifest next to the backup. The backup itself is already
+// stored, so a failure is only logged.
+func (d *Dumper) writeManifest(ctx context.Context, s3KeyPrefix string, manifest Manifest) {
+	key := s3KeyPrefix + ManifestSuffix
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = d.store.UploadBytes(ctx, data, key, "application/json")
+	}
+	if err != nil {
+		d.logger.Warn("Failed to upload backup manifest",
+			"s3_key", key,
+			"error", err)
+		return
+	}
+	d.logger.Info("Backup manifest uploaded",
+		"s3_key", key,
+		"databases", len(manifest.Databases))
+}
+
+// verifyManifest checks a downloaded archive against the manifest stored next to it.
+// Backups without a manifest, such as those taken by older versions, are not checked.
+func (d *Dumper) verifyManifest(ctx context.Context, s3Key, archivePath string) error {
+	key := manifestKey(s3Key)
+	data, err := d.store.DownloadBytes(ctx, key)
+	if errors.Is(err, ErrObjectNotFound) {
+		d.logger.Info("Backup has no manifest, skipping validation", "s3_key", s3Key)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download backup manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("invalid backup manifest %s: %w", key, err)
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup archive: %w", err)
+	}
+	defer file.Close()
+
+	checksum, err := fileSHA256(file)
+	if err != nil {
+		return err
+	}
+	if checksum != manifest.SHA256 {
+		return fmt.Errorf("%w: archive %s has SHA-256 %s, manifest expects %s", ErrChecksumMismatch, s3Key, checksum, manifest.SHA256)
+	}
+
+	d.logger.Info("Backup validated against manifest",
+		"s3_key", s3Key,
+		"databases", len(manifest.Databases),
+		"dumper_version", manifest.DumperVersion,
+		"finished_at", manifest.FinishedAt)
+	return nil
+}
+
+// archiveManifest builds the manifest of an uploaded archive backup
+func (d *Dumper) archiveManifest(dumpDir, archivePath, s3Key string, startedAt time.Time) (Manifest, error) {
+	databases, err := d.manifestDatabases(dumpDir)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to open backup archive: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to stat backup archive: %w", err)
+	}
+	checksum, err := fileSHA256(file)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{
+		Archive:          s3Key,
+		Environment:      d.config.GetEnvironment("default"),
+		Compression:      d.codec.Extension(),
+		SizeBytes:        info.Size(),
+		SHA256:           checksum,
+		DumperVersion:    Version,
+		MongodumpVersion: d.mongoDump.toolVersion,
+		StartedAt:        startedAt.UTC(),
+		FinishedAt:       time.Now().UTC(),
+		Databases:        databases,
+		ReleaseSHA:       d.config.ReleaseSHA,
+		ReleaseVersion:   d.config.ReleaseVersion,
+	}
+	if !d.config.ModifiedSince.IsZero() {
+		manifest.ModifiedSince = d.config.ModifiedSince.UTC()
+		manifest.ModifiedSinceField = GetValueOrDefault(d.config.ModifiedSinceField, DefaultModifiedSinceField)
+	}
+	return manifest, nil
+}
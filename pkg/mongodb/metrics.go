@@ -0,0 +1,76 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Metrics receives the outcome of each backup, e.g. to export it to Prometheus.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// BackupStarted is called when a backup begins
+	BackupStarted()
+	// BackupSucceeded is called after a successful backup, sizeBytes is 0 if nothing was uploaded
+	BackupSucceeded(duration time.Duration, sizeBytes int64)
+	// BackupFailed is called after a failed backup
+	BackupFailed(duration time.Duration)
+}
+
+// Dump performs a MongoDB dump and uploads to S3, recording the outcome for LastSuccess and
+// the configured metrics
+func (d *Dumper) Dump(ctx context.Context) error {
+	d.inProgress.Store(true)
+	defer d.inProgress.Store(false)
+
+	if d.config.Metrics != nil {
+		d.config.Metrics.BackupStarted()
+	}
+
+	// mongodump runs with CommandContext and every S3 call takes ctx, so both are cancelled
+	if d.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.config.Timeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	err := d.dump(ctx)
+	if err != nil && d.config.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("backup timed out after %s: %w", d.config.Timeout, err)
+	}
+	if err == nil {
+		d.lastSuccess.Store(time.Now().UnixNano())
+		d.samples.add(backupSample{
+			duration:          time.Since(startTime),
+			sizeBytes:         d.lastBackup.SizeBytes,
+			originalSizeBytes: d.lastBackup.OriginalSizeBytes,
+		})
+	}
+
+	if d.config.Metrics != nil {
+		if err != nil {
+			d.config.Metrics.BackupFailed(time.Since(startTime))
+		} else {
+			d.config.Metrics.BackupSucceeded(time.Since(startTime), d.lastBackup.SizeBytes)
+		}
+	}
+	return err
+}
+
+// InProgress reports whether a Dump is running. It is safe to call concurrently, e.g. from a
+// signal handler deciding whether shutdown should wait.
+func (d *Dumper) InProgress() bool {
+	return d.inProgress.Load()
+}
+
+// LastSuccess returns when the last successful backup finished, zero if none did yet.
+// It is safe to call while a backup is running.
+func (d *Dumper) LastSuccess() time.Time {
+	nanos := d.lastSuccess.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
@@ -0,0 +1,96 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+// connectMongo opens a driver connection using the same connection string and credentials as
+// mongodump. The caller must Disconnect the returned client.
+func connectMongo(cfg *DumperConfig) (*mongo.Client, error) {
+	opts := options.Client().ApplyURI(cfg.MongoURI)
+	if cfg.discreteCredentials() {
+		opts.SetAuth(options.Credential{
+			Username:      cfg.Username,
+			Password:      cfg.Password,
+			PasswordSet:   cfg.Password != "",
+			AuthSource:    cfg.AuthSource,
+			AuthMechanism: cfg.AuthMechanism,
+		})
+	}
+	if cfg.usesTLS() {
+		tlsConfig, err := mongoTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+	if cfg.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(cfg.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		readPref, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetReadPreference(readPref)
+	}
+
+	client, err := mongo.Connect(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	return client, nil
+}
+
+// withMongoClient connects to MongoDB, runs fn and disconnects again
+func withMongoClient(ctx context.Context, cfg *DumperConfig, fn func(*mongo.Client) error) error {
+	client, err := connectMongo(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(context.Background())
+
+	return fn(client)
+}
+
+// mongoTLSConfig builds the driver's TLS configuration from the same files mongodump is given
+func mongoTLSConfig(cfg *DumperConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.TLSInsecure}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MongoDB TLS CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("MongoDB TLS CA file contains no PEM certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	// Like mongodump's --sslPEMKeyFile, one file holds both the certificate and the key
+	if cfg.TLSCertKeyFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCertKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MongoDB TLS certificate file: %w", err)
+		}
+		cert, err := tls.X509KeyPair(pem, pem)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MongoDB TLS certificate file: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,193 @@
+package mongodb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultUploadConcurrency is how many files pipelined uploads send at once
+const DefaultUploadConcurrency = 4
+
+// pipelineUploader uploads dump files while mongodump is still writing later collections,
+// with at most a fixed number of uploads in flight
+type pipelineUploader struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	store     ObjectStore
+	logger    Logger
+	localDir  string
+	keyPrefix string
+	metadata  map[string]string // Backup metadata stored on every file
+	sem       chan struct{}
+	wg        sync.WaitGroup
+
+	mu       sync.Mutex
+	uploaded map[string]bool // Relative paths already queued
+	bytes    int64           // Bytes of completed uploads
+	err      error           // First upload error
+}
+
+// newPipelineUploader creates an uploader for the files below localDir
+func (d *Dumper) newPipelineUploader(ctx context.Context, localDir, keyPrefix string) *pipelineUploader {
+	ctx, cancel := context.WithCancel(ctx)
+	concurrency := d.config.UploadConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultUploadConcurrency
+	}
+
+	return &pipelineUploader{
+		ctx:       ctx,
+		cancel:    cancel,
+		store:     d.store,
+		logger:    d.logger,
+		localDir:  localDir,
+		keyPrefix: keyPrefix,
+		metadata:  d.backupMetadata(-1, -1),
+		sem:       make(chan struct{}, concurrency),
+		uploaded:  map[string]bool{},
+	}
+}
+
+// enqueue starts uploading a file relative to the dump directory unless it was queued before.
+// It never blocks, so it is safe to call from the goroutine reading mongodump's output.
+func (u *pipelineUploader) enqueue(relPath string) {
+	u.mu.Lock()
+	if u.uploaded[relPath] {
+		u.mu.Unlock()
+		return
+	}
+	u.uploaded[relPath] = true
+	u.mu.Unlock()
+
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+
+		select {
+		case u.sem <- struct{}{}:
+		case <-u.ctx.Done():
+			return
+		}
+		defer func() { <-u.sem }()
+
+		localPath := filepath.Join(u.localDir, relPath)
+		s3Key := u.keyPrefix + "/" + filepath.ToSlash(relPath)
+		if err := u.store.UploadFile(u.ctx, localPath, UploadOptions{Key: s3Key, Metadata: u.metadata}); err != nil {
+			u.fail(err)
+			return
+		}
+		if info, err := os.Stat(localPath); err == nil {
+			u.mu.Lock()
+			u.bytes += info.Size()
+			u.mu.Unlock()
+		}
+	}()
+}
+
+// fail records the first upload error and stops the remaining uploads
+func (u *pipelineUploader) fail(err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.err == nil {
+		u.err = err
+		u.cancel()
+	}
+}
+
+// collectionDone queues the files of a collection mongodump reported as complete
+func (u *pipelineUploader) collectionDone(database, collection string) {
+	u.logger.Info("Collection dumped, starting upload",
+		"database", database,
+		"collection", collection)
+
+	for _, suffix := range []string{".bson", ".metadata.json", ".bson.gz", ".metadata.json.gz"} {
+		relPath := filepath.Join(database, collection+suffix)
+		if _, err := os.Stat(filepath.Join(u.localDir, relPath)); err == nil {
+			u.enqueue(relPath)
+		}
+	}
+}
+
+// uploadRemaining queues every file not uploaded yet, such as those without a completion line
+func (u *pipelineUploader) uploadRemaining() error {
+	return filepath.Walk(u.localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(u.localDir, path)
+		if err != nil {
+			return err
+		}
+		u.enqueue(relPath)
+		return nil
+	})
+}
+
+// wait blocks until all queued uploads finished and returns the first error
+func (u *pipelineUploader) wait() error {
+	u.wg.Wait()
+	u.cancel()
+	return u.err
+}
+
+// dumpPipelined dumps into localBackupPath and uploads each collection's files below
+// s3KeyPrefix as soon as mongodump reports it complete, overlapping dump and upload.
+// Files are uploaded uncompressed, one object per dump file.
+func (d *Dumper) dumpPipelined(ctx context.Context, localBackupPath, s3KeyPrefix string) error {
+	d.logger.Info("STEP 1/2: Starting MongoDB dump with pipelined uploads",
+		"s3_prefix", s3KeyPrefix)
+	startTime := time.Now()
+
+	if err := d.checkS3Circuit(); err != nil {
+		return err
+	}
+
+	uploader := d.newPipelineUploader(ctx, localBackupPath, s3KeyPrefix)
+	d.mongoDump.collectionDone = uploader.collectionDone
+	defer func() { d.mongoDump.collectionDone = nil }()
+
+	dumpErr := d.mongoDump.CreateDump(ctx, localBackupPath)
+	if dumpErr == nil {
+		dumpErr = uploader.uploadRemaining()
+	}
+	if dumpErr != nil {
+		// Stop uploads of a dump that will never be complete
+		uploader.cancel()
+		_ = uploader.wait()
+		return d.dumpError("pipelined dump and upload", dumpErr)
+	}
+	if err := d.recordS3Result(uploader.wait()); err != nil {
+		return &UploadError{Phase: "pipelined dump and upload", Key: s3KeyPrefix, Err: err}
+	}
+	d.lastBackup = BackupResult{S3Key: s3KeyPrefix, SizeBytes: uploader.bytes}
+
+	// Keep the full mongodump output next to the dump for auditing
+	if d.config.UploadDumpLog {
+		logKey := s3KeyPrefix + ".log"
+		if err := d.store.UploadBytes(ctx, d.mongoDump.DumpLog(), logKey, "text/plain"); err != nil {
+			d.logger.Warn("Failed to upload mongodump log",
+				"s3_key", logKey,
+				"error", err)
+		}
+	}
+
+	d.logger.Info("STEP 1/2: MongoDB dump and upload completed",
+		"duration", time.Since(startTime),
+		"files_uploaded", len(uploader.uploaded))
+
+	// STEP 2: Cleanup
+	d.logger.Info("STEP 2/2: Cleaning up temporary files")
+	if err := os.RemoveAll(localBackupPath); err != nil {
+		d.logger.Warn("Failed to remove temporary backup directory",
+			"path", localBackupPath,
+			"error", err)
+	}
+
+	return nil
+}
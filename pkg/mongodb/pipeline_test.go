@@ -0,0 +1,93 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPipelinedUploadsOverlapTheDump(t *testing.T) {
+	uploaded := filepath.Join(t.TempDir(), "users-uploaded")
+	// Only dumps the second collection once the first one was uploaded, which never
+	// happens unless uploads start on mongodump's completion line
+	mongodump := writeFakeCommand(t, "mongodump", `while [ $# -gt 0 ]; do
+	[ "$1" = "--out" ] && out="$2"
+	shift
+done
+mkdir -p "$out/app"
+echo "users" > "$out/app/users.bson"
+echo "{}" > "$out/app/users.metadata.json"
+echo "done dumping app.users (1 document)" >&2
+
+i=0
+while [ ! -e "`+uploaded+`" ]; do
+	i=$((i+1))
+	if [ $i -gt 500 ]; then
+		echo "app.users was not uploaded while dumping" >&2
+		exit 1
+	fi
+	sleep 0.01
+done
+
+echo "orders" > "$out/app/orders.bson"
+echo "{}" > "$out/app/orders.metadata.json"
+echo "done dumping app.orders (1 document)" >&2
+echo "prelude" > "$out/prelude.json"
+`)
+
+	store := newFakeStore()
+	store.onUpload = func(key string) {
+		if strings.HasSuffix(key, "/app/users.bson") {
+			os.WriteFile(uploaded, nil, 0o600)
+		}
+	}
+	d := newTestDumper(t, DumperConfig{
+		MongoURI:          "mongodb://localhost",
+		MongodumpPath:     mongodump,
+		PipelineUploads:   true,
+		UploadConcurrency: 1,
+	}, store)
+
+	if err := d.Dump(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	prefix := d.LastBackup().S3Key
+	want := []string{"app/orders.bson", "app/orders.metadata.json", "app/users.bson", "app/users.metadata.json", "prelude.json"}
+	var got []string
+	for _, key := range store.keys() {
+		if rel, ok := strings.CutPrefix(key, prefix+"/"); ok {
+			got = append(got, rel)
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("pipelined files = %v, want %v (store has %v)", got, want, store.keys())
+	}
+}
+
+func TestPipelinedUploadFailureFailsTheBackup(t *testing.T) {
+	mongodump := writeFakeCommand(t, "mongodump", `while [ $# -gt 0 ]; do
+	[ "$1" = "--out" ] && out="$2"
+	shift
+done
+mkdir -p "$out/app"
+echo "users" > "$out/app/users.bson"
+echo "done dumping app.users (1 document)" >&2
+`)
+	store := newFakeStore()
+	store.failUpload = func(key string) bool { return strings.HasSuffix(key, "/app/users.bson") }
+	d := newTestDumper(t, DumperConfig{
+		MongoURI:        "mongodb://localhost",
+		MongodumpPath:   mongodump,
+		PipelineUploads: true,
+	}, store)
+
+	err := d.Dump(context.Background())
+	var uploadErr *UploadError
+	if !errors.As(err, &uploadErr) || uploadErr.Phase != "pipelined dump and upload" || !errors.Is(err, ErrStorage) {
+		t.Fatalf("Dump with a failing upload = %v, want an UploadError of the pipelined phase", err)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package mongodb
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// processKillGrace is how long the process group gets to exit after SIGTERM before it is killed
+const processKillGrace = 10 * time.Second
+
+// configureProcess starts the command in its own process group and makes context
+// cancellation terminate the whole group, so helpers spawned by the tool don't outlive it
+// and keep writing to the dump directory during cleanup
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative pid signals every process in the group
+		pgid := -cmd.Process.Pid
+		if err := syscall.Kill(pgid, syscall.SIGTERM); err != nil {
+			if errors.Is(err, syscall.ESRCH) {
+				return nil
+			}
+			return syscall.Kill(pgid, syscall.SIGKILL)
+		}
+
+		// Escalate for anything that ignores SIGTERM; ESRCH once the group is gone is fine
+		time.AfterFunc(processKillGrace, func() {
+			_ = syscall.Kill(pgid, syscall.SIGKILL)
+		})
+		return nil
+	}
+	// Don't let Wait block forever on pipes held open by a stuck process
+	cmd.WaitDelay = processKillGrace + 5*time.Second
+}
+
+// setProcessNice sets the scheduling priority of the started command's process group
+func setProcessNice(cmd *exec.Cmd, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PGRP, cmd.Process.Pid, nice)
+}
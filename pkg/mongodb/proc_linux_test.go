@@ -0,0 +1,121 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// procStat returns the process group, nice level and state of a process from /proc
+func procStat(t *testing.T, stat string) (pgrp, nice int, state string) {
+	t.Helper()
+	// The command name in parentheses may contain spaces, the fields after it don't
+	end := strings.LastIndexByte(stat, ')')
+	fields := strings.Fields(stat[end+1:])
+	if end < 0 || len(fields) < 17 {
+		t.Fatalf("unexpected /proc stat line %q", stat)
+	}
+	pgrp, _ = strconv.Atoi(fields[2])
+	nice, _ = strconv.Atoi(fields[16])
+	return pgrp, nice, fields[0]
+}
+
+func TestMongodumpRunsInOwnProcessGroup(t *testing.T) {
+	statFile := filepath.Join(t.TempDir(), "stat")
+	// The nice level is set right after the start, give it a moment before reading it
+	mongodump := writeFakeCommand(t, "mongodump", `sleep 0.5
+echo "$$" > "`+statFile+`.pid"
+cat /proc/$$/stat > "`+statFile+`"
+`)
+	d, err := NewMongoDumper(DumperConfig{MongoURI: "mongodb://localhost", MongodumpPath: mongodump, Nice: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.CreateDump(context.Background(), t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := os.ReadFile(statFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pidData, err := os.ReadFile(statFile + ".pid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(pidData)))
+
+	pgrp, nice, _ := procStat(t, string(stat))
+	if pgrp != pid {
+		t.Errorf("mongodump process group = %d, want its own group %d", pgrp, pid)
+	}
+	if pgrp == syscall.Getpgrp() {
+		t.Error("mongodump runs in the test's process group")
+	}
+	if nice != 10 {
+		t.Errorf("mongodump nice level = %d, want 10", nice)
+	}
+}
+
+func TestCancelLeavesNoOrphans(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	// Like a tool spawning helpers: the child would outlive a kill of the direct process
+	mongodump := writeFakeCommand(t, "mongodump", `sleep 60 &
+echo $! > "`+pidFile+`"
+wait
+`)
+	d, err := NewMongoDumper(DumperConfig{MongoURI: "mongodb://localhost", MongodumpPath: mongodump})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.CreateDump(ctx, t.TempDir()) }()
+
+	var childPid int
+	deadline := time.Now().Add(5 * time.Second)
+	for childPid == 0 && time.Now().Before(deadline) {
+		if data, err := os.ReadFile(pidFile); err == nil {
+			childPid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if childPid == 0 {
+		cancel()
+		t.Fatal("fake mongodump did not start its child")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("cancelled CreateDump succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		syscall.Kill(childPid, syscall.SIGKILL)
+		t.Fatal("CreateDump did not return after cancellation")
+	}
+
+	// Exited processes may stay zombies until reaped, they no longer run
+	for time.Now().Before(deadline.Add(5 * time.Second)) {
+		stat, err := os.ReadFile("/proc/" + strconv.Itoa(childPid) + "/stat")
+		if errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		if err == nil {
+			if _, _, state := procStat(t, string(stat)); state == "Z" || state == "X" {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	syscall.Kill(childPid, syscall.SIGKILL)
+	t.Errorf("child process %d of mongodump still running after cancellation", childPid)
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package mongodb
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// configureProcess is a no-op outside Linux, cancellation kills only the direct child
+func configureProcess(cmd *exec.Cmd) {}
+
+// setProcessNice is not supported outside Linux
+func setProcessNice(cmd *exec.Cmd, nice int) error {
+	return errors.New("setting the process nice level is only supported on Linux")
+}
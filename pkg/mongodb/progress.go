@@ -0,0 +1,58 @@
+package mongodb
+
+import (
+	"dumper/pkg/logger"
+	"time"
+)
+
+// ProgressPhase is the backup step a ProgressEvent belongs to
+type ProgressPhase string
+
+// Backup phases reported to a ProgressHandler
+const (
+	PhaseDump     ProgressPhase = "dump"
+	PhaseCompress ProgressPhase = "compress"
+	PhaseUpload   ProgressPhase = "upload"
+)
+
+// ProgressEvent reports the progress of a backup phase
+type ProgressEvent struct {
+	Phase      ProgressPhase
+	Percent    int    // 0 to 100 within the phase, or within Collection while dumping
+	Bytes      int64  // Bytes processed so far, 0 if unknown
+	TotalBytes int64  // Total bytes of the phase, 0 if unknown
+	Collection string // Collection being dumped (dump phase only)
+	S3Key      string // Object being uploaded (upload phase only)
+}
+
+// ProgressHandler receives progress events, e.g. to drive a progress bar. It is called
+// synchronously from the goroutines doing the work, possibly concurrently, so it must be
+// fast and safe for concurrent use.
+type ProgressHandler func(ProgressEvent)
+
+// report calls the handler if one is set
+func (h ProgressHandler) report(event ProgressEvent) {
+	if h != nil {
+		h(event)
+	}
+}
+
+// progressThrottle returns the throttle for progress logs, nil to log every 10%
+func progressThrottle(interval time.Duration) *logger.Throttle {
+	if interval <= 0 {
+		return nil
+	}
+	return logger.NewThrottle(interval)
+}
+
+// shouldLogProgress decides whether a progress line at pct is logged: always at 100%,
+// otherwise once per interval per key with a throttle, or every 10% since lastLogged without
+func shouldLogProgress(throttle *logger.Throttle, key string, pct, lastLogged int) bool {
+	if pct == 100 {
+		return true
+	}
+	if throttle != nil {
+		return throttle.Allow(key)
+	}
+	return pct >= lastLogged+10
+}
@@ -0,0 +1,434 @@
+package mongodb
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrMongoRestoreNotFound is returned when mongorestore is not installed
+var ErrMongoRestoreNotFound = errors.New("mongorestore not found in PATH, please install MongoDB Database Tools")
+
+// Patterns of mongorestore's verbose output
+var (
+	restoreProgressRegex = regexp.MustCompile(`\]\s+(\S+)\s+\S+/\S+\s+\((\d+)(?:\.\d+)?%\)`)
+	restoreFinishedRegex = regexp.MustCompile(`finished restoring (\S+) \((\d+) documents?, (\d+) failures?\)`)
+)
+
+// MongoRestorer handles MongoDB restore operations
+type MongoRestorer struct {
+	config DumperConfig
+	logger Logger
+}
+
+// NewMongoRestorer creates a restorer using the connection string of the given configuration
+func NewMongoRestorer(cfg DumperConfig) (*MongoRestorer, error) {
+	if _, err := exec.LookPath("mongorestore"); err != nil {
+		return nil, ErrMongoRestoreNotFound
+	}
+
+	return &MongoRestorer{
+		config: cfg,
+		logger: cfg.logger(),
+	}, nil
+}
+
+// NewLocalDumper creates a Dumper that only restores local archives with RestoreFromFile.
+// It has no object store and doesn't need mongodump, so storage settings are not validated.
+func NewLocalDumper(cfg DumperConfig) (*Dumper, error) {
+	if err := cfg.validateConnection(); err != nil {
+		return nil, withCategory(ErrConfig, err)
+	}
+	if err := cfg.validateRestore(); err != nil {
+		return nil, withCategory(ErrConfig, err)
+	}
+	if err := cfg.applyConnectionOptions(); err != nil {
+		return nil, withCategory(ErrConfig, err)
+	}
+
+	cfg.resolveTempDir()
+
+	restorer, err := NewMongoRestorer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(cfg.TempDir, cfg.tempDirMode()); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	return &Dumper{
+		config:    cfg,
+		restorer:  restorer,
+		logger:    cfg.logger(),
+		s3Breaker: newCircuitBreaker(cfg.S3BreakerThreshold, cfg.S3BreakerCooldown),
+		samples:   newBackupSamples(cfg.statsWindow()),
+	}, nil
+}
+
+// Restore runs mongorestore against a dump directory as written by mongodump --out
+func (r *MongoRestorer) Restore(ctx context.Context, dumpDir string) error {
+	r.logger.Info("Starting MongoDB restore", "input", dumpDir, "dry_run", r.config.RestoreDryRun)
+
+	args := append(r.config.connectionArgs(), "--dir", dumpDir, "--verbose")
+	gzipped, err := isGzippedDump(dumpDir)
+	if err != nil {
+		return err
+	}
+	if gzipped {
+		args = append(args, "--gzip")
+	}
+	if r.config.RestoreDryRun {
+		args = append(args, "--dryRun")
+	}
+	for _, pattern := range r.config.RestoreNamespaces {
+		args = append(args, "--nsInclude", pattern)
+	}
+	if r.config.RestoreNSFrom != "" {
+		r.logger.Info("Remapping restored namespaces",
+			"from", r.config.RestoreNSFrom,
+			"to", r.config.RestoreNSTo)
+		args = append(args, "--nsFrom", r.config.RestoreNSFrom, "--nsTo", r.config.RestoreNSTo)
+	}
+
+	// Dumps taken with --oplog are only consistent once their oplog is replayed. The oplog
+	// covers every namespace, so it isn't replayed into a selective restore.
+	oplogFile := "oplog.bson"
+	if gzipped {
+		oplogFile += ".gz"
+	}
+	if _, err := os.Stat(filepath.Join(dumpDir, oplogFile)); err == nil {
+		if len(r.config.RestoreNamespaces) > 0 {
+			r.logger.Warn("Not replaying the oplog of a selective restore, the collections are restored as of the dump's start")
+		} else {
+			args = append(args, "--oplogReplay")
+		}
+	}
+	r.logger.Info("Executing mongorestore", "args", redactedArgs(args))
+
+	cmd := exec.CommandContext(ctx, "mongorestore", args...)
+	configureProcess(cmd)
+
+	stdoutBuf := newTailBuffer(maxCapturedOutput)
+	stderrBuf := newTailBuffer(maxCapturedOutput)
+	stdout, stderr, err := setupCommandOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to set up command output capture: %w", err)
+	}
+
+	startTime := time.Now()
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start mongorestore: %w", err)
+	}
+
+	if r.config.Nice != 0 {
+		if err := setProcessNice(cmd, r.config.Nice); err != nil {
+			r.logger.Warn("Failed to set mongorestore nice level", "nice", r.config.Nice, "error", err)
+		}
+	}
+
+	// mongorestore reports progress on stderr, keep both streams for the error message
+	progress := &restoreProgress{logger: r.logger, startTime: startTime, lastPct: map[string]int{}}
+	stdoutCh := make(chan struct{})
+	go func() {
+		r.captureOutput(stdout, stdoutBuf, progress, "mongorestore stdout")
+		close(stdoutCh)
+	}()
+	stderrCh := make(chan struct{})
+	go func() {
+		r.captureOutput(stderr, stderrBuf, progress, "mongorestore stderr")
+		close(stderrCh)
+	}()
+
+	// Drain both pipes before waiting, as Wait closes them
+	<-stdoutCh
+	<-stderrCh
+
+	err = cmd.Wait()
+	duration := time.Since(startTime)
+
+	if err != nil {
+		r.logger.Error("MongoDB restore failed",
+			"error", err,
+			"stdout", stdoutBuf.String(),
+			"stderr", stderrBuf.String(),
+			"duration", duration)
+
+		return fmt.Errorf("mongorestore failed: %w - stderr: %s", err, stderrBuf.String())
+	}
+
+	r.logger.Info("MongoDB restore completed", "duration", duration, "dry_run", r.config.RestoreDryRun)
+	return nil
+}
+
+// captureOutput copies command output line by line into a buffer and the debug log,
+// reporting restore progress along the way
+func (r *MongoRestorer) captureOutput(reader io.Reader, buf *tailBuffer, progress *restoreProgress, prefix string) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		buf.WriteString(line + "\n")
+		progress.observe(line)
+		r.logger.Debug(prefix, "output", line)
+	}
+}
+
+// restoreProgress logs per-collection restore progress parsed from mongorestore's output
+type restoreProgress struct {
+	mu        sync.Mutex
+	logger    Logger
+	startTime time.Time
+	lastPct   map[string]int // Last logged percentage per namespace
+}
+
+// observe logs progress at 10% steps per collection and each finished collection
+func (p *restoreProgress) observe(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if match := restoreFinishedRegex.FindStringSubmatch(line); len(match) > 3 {
+		documents, _ := strconv.Atoi(match[2])
+		failures, _ := strconv.Atoi(match[3])
+		p.logger.Info("Restored collection",
+			"collection", match[1],
+			"documents", documents,
+			"failures", failures,
+			"elapsed", time.Since(p.startTime))
+		return
+	}
+
+	if match := restoreProgressRegex.FindStringSubmatch(line); len(match) > 2 {
+		pct, err := strconv.Atoi(match[2])
+		if err != nil {
+			return
+		}
+		last, seen := p.lastPct[match[1]]
+		if !seen || pct >= last+10 || (pct == 100 && last != 100) {
+			p.logger.Info("MongoDB restore progress",
+				"collection", match[1],
+				"percent_complete", pct,
+				"elapsed", time.Since(p.startTime))
+			p.lastPct[match[1]] = pct
+		}
+	}
+}
+
+// RestoreFromFile restores a local backup archive without touching S3. The archive format
+// is detected from the file extension.
+func (d *Dumper) RestoreFromFile(ctx context.Context, localPath string) error {
+	d.logger.Info("Starting restore from local archive", "path", localPath)
+	startTime := time.Now()
+
+	if err := d.restoreArchive(ctx, localPath); err != nil {
+		return err
+	}
+
+	d.logger.Info("Restore from local archive completed",
+		"path", localPath,
+		"total_duration", time.Since(startTime))
+	return nil
+}
+
+// restoreArchive extracts an archive into the temp directory and runs mongorestore on it.
+// The extracted files are only removed after a successful restore, so a failed one can be inspected.
+func (d *Dumper) restoreArchive(ctx context.Context, archivePath string) error {
+	codec, err := d.codecForPath(archivePath)
+	if err != nil {
+		return err
+	}
+
+	dumpDir := filepath.Join(d.tempDir(), "restore-"+newRunID())
+	if err := codec.Decompress(archivePath, dumpDir); err != nil {
+		return &CompressionError{Phase: "extract", Path: archivePath, Err: err}
+	}
+
+	if len(d.config.RestoreNamespaces) > 0 {
+		if err := d.matchRestoreNamespaces(dumpDir); err != nil {
+			d.logger.Warn("Keeping extracted backup after failed restore", "path", dumpDir)
+			return err
+		}
+	}
+
+	if d.config.RestoreDryRun {
+		if err := d.checkRestoreFiles(dumpDir); err != nil {
+			d.logger.Warn("Keeping extracted backup that failed validation", "path", dumpDir)
+			return err
+		}
+	}
+
+	if err := d.restorer.Restore(ctx, dumpDir); err != nil {
+		d.logger.Warn("Keeping extracted backup after failed restore", "path", dumpDir)
+		return withCategory(ErrMongo, err)
+	}
+
+	if err := os.RemoveAll(dumpDir); err != nil {
+		d.logger.Warn("Failed to remove temporary restore directory",
+			"path", dumpDir,
+			"error", err)
+	}
+	return nil
+}
+
+// extractZip extracts a backup archive into destDir, rejecting entries that would escape it.
+// Stored symlinks are skipped, mongorestore only needs the regular dump files.
+func (d *Dumper) extractZip(archivePath, destDir string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer reader.Close()
+
+	for _, entry := range reader.File {
+		target := filepath.Join(destDir, entry.Name)
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal path in archive: %s", entry.Name)
+		}
+
+		if entry.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, d.config.tempDirMode()); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", target, err)
+			}
+			continue
+		}
+		if entry.Mode()&os.ModeSymlink != 0 {
+			d.logger.Warn("Skipping symlink in archive", "path", entry.Name)
+			continue
+		}
+
+		if err := extractZipEntry(entry, target, d.config.tempDirMode()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractZipEntry writes a single archive entry to target, see extractFile
+func extractZipEntry(entry *zip.File, target string, dirMode os.FileMode) error {
+	src, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s in zip: %w", entry.Name, err)
+	}
+	defer src.Close()
+
+	return extractFile(src, target, dirMode)
+}
+
+// checkRestoreFiles reads every collection file of an extracted dump, logging the documents
+// a restore would insert per collection. It fails if any file is corrupt.
+func (d *Dumper) checkRestoreFiles(dumpDir string) error {
+	checks, err := checkDumpFiles(dumpDir)
+	if err != nil {
+		return err
+	}
+
+	var collections int
+	var total int64
+	var corrupt []string
+	for _, check := range checks {
+		if !d.config.restoresNamespace(check.Namespace) {
+			continue
+		}
+		if check.Err != nil {
+			d.logger.Error("Corrupt collection file",
+				"collection", check.Namespace,
+				"documents_read", check.Documents,
+				"error", check.Err)
+			corrupt = append(corrupt, check.Namespace)
+			continue
+		}
+		d.logger.Info("Would restore collection",
+			"collection", check.Namespace,
+			"documents", check.Documents)
+		collections++
+		total += check.Documents
+	}
+	if len(corrupt) > 0 {
+		return fmt.Errorf("%w: %d collection files: %s",
+			ErrCorruptDump, len(corrupt), strings.Join(corrupt, ", "))
+	}
+
+	d.logger.Info("Restore dry run summary",
+		"collections", collections,
+		"documents", total)
+	return nil
+}
+
+// matchRestoreNamespaces logs the namespaces of an extracted dump that RestoreNamespaces
+// selects, failing if there are none, as mongorestore would silently restore nothing
+func (d *Dumper) matchRestoreNamespaces(dumpDir string) error {
+	namespaces, err := dumpNamespaces(dumpDir)
+	if err != nil {
+		return err
+	}
+
+	var matched []string
+	for _, namespace := range namespaces {
+		if d.config.restoresNamespace(namespace) {
+			matched = append(matched, namespace)
+		}
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("no collection of the backup matches %s, it has %d collections",
+			strings.Join(d.config.RestoreNamespaces, ", "), len(namespaces))
+	}
+
+	d.logger.Info("Restoring selected collections",
+		"patterns", d.config.RestoreNamespaces,
+		"matched", matched)
+	return nil
+}
+
+// validateNamespacePattern checks an --nsInclude pattern: a database and a collection part
+// separated by a dot, each non-empty
+func validateNamespacePattern(pattern string) error {
+	database, collection, ok := strings.Cut(pattern, ".")
+	if !ok || database == "" || collection == "" {
+		return fmt.Errorf("invalid restore namespace %q, use <database>.<collection> such as app.users or app.*", pattern)
+	}
+	if strings.ContainsAny(database, `/\ "$`) {
+		return fmt.Errorf("invalid database name in restore namespace %q", pattern)
+	}
+	return nil
+}
+
+// validateNamespaceRemap checks that RestoreNSFrom and RestoreNSTo are set together, are
+// valid namespace patterns and have the same number of wildcards, as mongorestore maps the
+// part each '*' matched to the '*' at the same position
+func (c *DumperConfig) validateNamespaceRemap() error {
+	if c.RestoreNSFrom == "" && c.RestoreNSTo == "" {
+		return nil
+	}
+	if c.RestoreNSFrom == "" || c.RestoreNSTo == "" {
+		return errors.New("restore namespace remapping requires both a source and a target namespace")
+	}
+	if err := validateNamespacePattern(c.RestoreNSFrom); err != nil {
+		return err
+	}
+	if err := validateNamespacePattern(c.RestoreNSTo); err != nil {
+		return err
+	}
+	if strings.Count(c.RestoreNSFrom, "*") != strings.Count(c.RestoreNSTo, "*") {
+		return fmt.Errorf("restore namespaces %q and %q must have the same number of '*' wildcards",
+			c.RestoreNSFrom, c.RestoreNSTo)
+	}
+	return nil
+}
+
+// namespacePatternRegex compiles an --nsInclude pattern, in which '*' matches any characters
+func namespacePatternRegex(pattern string) *regexp.Regexp {
+	quoted := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	return regexp.MustCompile("^" + quoted + "$")
+}
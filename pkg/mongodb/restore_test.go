@@ -0,0 +1,116 @@
+package mongodb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeMongorestore installs a mongorestore on PATH that writes its arguments, one per line,
+// to the returned file
+func fakeMongorestore(t *testing.T) string {
+	t.Helper()
+	argsFile := filepath.Join(t.TempDir(), "mongorestore.args")
+	fakeCommandOnPath(t, "mongorestore", `for arg in "$@"; do echo "$arg"; done > "`+argsFile+`"
+`)
+	return argsFile
+}
+
+// writeTestArchive writes an archive of a fake dump of the given collections, a zip or, for
+// a dump gzipped by mongodump, a tar
+func writeTestArchive(t *testing.T, gzipped bool, collections map[string]int) string {
+	t.Helper()
+	d, _, runner := newFakeRunnerDumper(t, DumperConfig{MongodumpGzip: gzipped}, collections)
+	dumpDir := filepath.Join(t.TempDir(), "dump")
+	if err := runner.CreateDump(context.Background(), dumpDir); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "backup"+d.codec.Extension())
+	if err := d.codec.Compress(dumpDir, archive); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestRestoreFromFileWithoutStorage(t *testing.T) {
+	argsFile := fakeMongorestore(t)
+	archive := writeTestArchive(t, false, map[string]int{"app/users": 2})
+
+	// No provider settings at all, a local restore must not need them
+	d, err := NewLocalDumper(DumperConfig{
+		MongoURI: "mongodb://localhost:27017",
+		TempDir:  t.TempDir(),
+		Log:      &recordingLogger{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RestoreFromFile(context.Background(), archive); err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(args), "--uri\nmongodb://localhost:27017\n") {
+		t.Errorf("mongorestore args = %q, want the MongoDB URI", args)
+	}
+}
+
+func TestNewLocalDumperValidatesConnection(t *testing.T) {
+	fakeMongorestore(t)
+	if _, err := NewLocalDumper(DumperConfig{TempDir: t.TempDir()}); err == nil {
+		t.Error("NewLocalDumper without a MongoDB URI succeeded")
+	}
+}
+
+func TestRestoreGzippedDump(t *testing.T) {
+	for _, gzipped := range []bool{false, true} {
+		argsFile := fakeMongorestore(t)
+		archive := writeTestArchive(t, gzipped, map[string]int{"app/users": 2, "app/orders": 1})
+		d, err := NewLocalDumper(DumperConfig{
+			MongoURI: "mongodb://localhost:27017",
+			TempDir:  t.TempDir(),
+			Log:      &recordingLogger{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.RestoreFromFile(context.Background(), archive); err != nil {
+			t.Fatalf("gzipped=%v: %v", gzipped, err)
+		}
+
+		args, err := os.ReadFile(argsFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := slices.Contains(strings.Split(string(args), "\n"), "--gzip"); got != gzipped {
+			t.Errorf("gzipped=%v: mongorestore --gzip passed = %v, args %q", gzipped, got, args)
+		}
+	}
+}
+
+func TestIsGzippedDump(t *testing.T) {
+	for _, gzipped := range []bool{false, true} {
+		dumpDir := t.TempDir()
+		runner := &fakeRunner{collections: map[string]int{"app/users": 1}, gzip: gzipped}
+		if err := runner.CreateDump(context.Background(), dumpDir); err != nil {
+			t.Fatal(err)
+		}
+		got, err := isGzippedDump(dumpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != gzipped {
+			t.Errorf("isGzippedDump of a dump with gzip=%v = %v", gzipped, got)
+		}
+	}
+
+	if _, err := isGzippedDump(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("isGzippedDump of a missing directory succeeded")
+	}
+}
@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// backupTimestampLayout is the timestamp GenerateBackupFilename embeds in backup names
+const backupTimestampLayout = "2006-01-02T15-04-05Z"
+
+// backupTimestampRegex finds the embedded timestamp in a backup name
+var backupTimestampRegex = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}Z`)
+
+// storedBackup groups all objects of one backup (archive, log, marker, pipelined files)
+type storedBackup struct {
+	prefix    string
+	createdAt time.Time
+	keys      []string
+}
+
+// PruneBackups deletes backups under the environment prefix beyond the newest keepLast or
+// older than olderThan (0 disables either limit). The newest backup is never deleted, so a
+// misconfigured policy cannot empty the bucket. Objects without a parsable timestamp are kept.
+func (d *Dumper) PruneBackups(ctx context.Context, keepLast int, olderThan time.Duration) error {
+	if keepLast <= 0 && olderThan <= 0 {
+		return nil
+	}
+
+	objects, err := d.store.ListBackups(ctx, d.config.KeyPrefix())
+	if err != nil {
+		return withCategory(ErrStorage, fmt.Errorf("failed to list backups for pruning: %w", err))
+	}
+
+	keys := make([]string, len(objects))
+	for i, object := range objects {
+		keys[i] = object.Key
+	}
+	backups := groupBackups(keys, d.config.keyDepth())
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].createdAt.After(backups[j].createdAt)
+	})
+
+	now := time.Now()
+	var expired []string
+	pruned := 0
+	for i, backup := range backups {
+		if i == 0 {
+			continue
+		}
+		tooMany := keepLast > 0 && i >= keepLast
+		tooOld := olderThan > 0 && now.Sub(backup.createdAt) > olderThan
+		if tooMany || tooOld {
+			d.logger.Info("Pruning backup",
+				"backup", backup.prefix,
+				"created_at", backup.createdAt,
+				"objects", len(backup.keys))
+			expired = append(expired, backup.keys...)
+			pruned++
+		}
+	}
+
+	if len(expired) == 0 {
+		d.logger.Info("No backups to prune", "backups", len(backups))
+		return nil
+	}
+
+	if err := d.store.DeleteObjects(ctx, expired); err != nil {
+		return withCategory(ErrStorage, fmt.Errorf("failed to prune backups: %w", err))
+	}
+
+	d.logger.Info("Pruned old backups",
+		"objects_deleted", len(expired),
+		"backups_pruned", pruned,
+		"backups_kept", len(backups)-pruned)
+	return nil
+}
+
+// groupBackups groups object keys by backup and parses each backup's creation time
+func groupBackups(keys []string, depth int) []*storedBackup {
+	byPrefix := map[string]*storedBackup{}
+	var backups []*storedBackup
+	for _, key := range keys {
+		prefix := backupPrefix(key, depth)
+		if prefix == "" {
+			continue
+		}
+
+		backup, ok := byPrefix[prefix]
+		if !ok {
+			createdAt, ok := backupTimestamp(prefix)
+			if !ok {
+				continue
+			}
+			backup = &storedBackup{prefix: prefix, createdAt: createdAt}
+			byPrefix[prefix] = backup
+			backups = append(backups, backup)
+		}
+		backup.keys = append(backup.keys, key)
+	}
+	return backups
+}
+
+// backupTimestamp parses the timestamp embedded in a backup name
+func backupTimestamp(name string) (time.Time, bool) {
+	matches := backupTimestampRegex.FindAllString(name, -1)
+	if len(matches) == 0 {
+		return time.Time{}, false
+	}
+	// The timestamp is the last component of the name
+	createdAt, err := time.Parse(backupTimestampLayout, matches[len(matches)-1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
@@ -1,24 +1,108 @@
 package mongodb
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"dumper/pkg/logger"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math/rand/v2"
+	"net"
+	"net/url"
 	"os"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"go.uber.org/zap"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// ErrObjectNotFound is returned when a requested S3 object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrChecksumMismatch is returned when a stored object does not match the uploaded file
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // S3Client handles S3 operations
 type S3Client struct {
-	client *s3.Client
-	bucket string
-	logger *zap.Logger
+	client  *s3.Client
+	bucket  string
+	logger  Logger
+	secrets []string // Credentials scrubbed from SDK error messages
+
+	// Parallel ranged downloads
+	parallelDownload    bool
+	downloadPartSize    int64
+	downloadConcurrency int
+
+	retentionAge time.Duration // Drives the created-date/expire-date tags (0 = untagged)
+	warmUp       bool          // HeadBucket before timed uploads
+	verify       bool          // Content-MD5 on uploads plus an ETag check afterwards
+	storageClass string        // Storage class of uploaded backups (empty = provider default)
+	sse          string        // Server-side encryption of uploaded backups (empty = bucket default)
+	sseKMSKeyID  string
+
+	metadata map[string]string // User metadata stored on every archive
+
+	singleDeletes bool // Delete objects one by one, for providers without DeleteObjects (GCS)
+
+	progress    ProgressHandler // Receives upload progress (optional)
+	logInterval time.Duration   // Minimum time between upload progress logs (0 = every 10%)
+
+	maxRetries     int           // Application-level retries of transient failures
+	retryBaseDelay time.Duration // Delay before the first retry, doubled per attempt
+}
+
+// scrubbedError wraps an SDK error whose message had credentials removed
+type scrubbedError struct {
+	msg string
+	err error
+}
+
+// Error returns the scrubbed message
+func (e *scrubbedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the original SDK error so errors.As keeps working
+func (e *scrubbedError) Unwrap() error {
+	return e.err
+}
+
+// scrubCredentials masks every occurrence of the given secrets in an error message.
+// SDK errors can echo request details, so they must never reach the logs unscrubbed.
+func scrubCredentials(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	scrubbed := msg
+	for _, secret := range secrets {
+		if secret != "" {
+			scrubbed = strings.ReplaceAll(scrubbed, secret, "[REDACTED]")
+		}
+	}
+
+	if scrubbed == msg {
+		return err
+	}
+	return &scrubbedError{msg: scrubbed, err: err}
+}
+
+// scrub removes the client's credentials from an SDK error
+func (s *S3Client) scrub(err error) error {
+	return scrubCredentials(err, s.secrets...)
 }
 
 // progressReader is used to track upload progress
@@ -27,8 +111,11 @@ type progressReader struct {
 	totalSize     int64
 	bytesRead     int64
 	lastLoggedPct int
-	logger        *zap.Logger
+	lastReported  int
+	logger        Logger
 	s3Key         string
+	progress      ProgressHandler
+	throttle      *logger.Throttle // Limits progress logs in time instead of every 10% (optional)
 }
 
 // Read implements io.Reader and tracks progress
@@ -39,8 +126,20 @@ func (r *progressReader) Read(p []byte) (int, error) {
 		// Calculate percentage
 		pct := int((float64(r.bytesRead) / float64(r.totalSize)) * 100)
 
-		// Log progress at 10% intervals or 100%
-		if pct >= r.lastLoggedPct+10 || pct == 100 {
+		// Report every percent, the logs only every 10%
+		if r.progress != nil && pct != r.lastReported {
+			r.progress(ProgressEvent{
+				Phase:      PhaseUpload,
+				Percent:    pct,
+				Bytes:      r.bytesRead,
+				TotalBytes: r.totalSize,
+				S3Key:      r.s3Key,
+			})
+			r.lastReported = pct
+		}
+
+		// Log progress at 10% intervals or per throttle interval, and at 100%
+		if pct != r.lastLoggedPct && shouldLogProgress(r.throttle, r.s3Key, pct, r.lastLoggedPct) {
 			// Format sizes in human-readable form based on size
 			var sizeStr string
 			bytesUploaded := float64(r.bytesRead)
@@ -65,11 +164,11 @@ func (r *progressReader) Read(p []byte) (int, error) {
 			}
 
 			r.logger.Info("Upload progress",
-				zap.String("s3_key", r.s3Key),
-				zap.Int("percent_complete", pct),
-				zap.Int64("bytes_uploaded", r.bytesRead),
-				zap.Int64("total_size", r.totalSize),
-				zap.String("human_readable_size", sizeStr))
+				"s3_key", r.s3Key,
+				"percent_complete", pct,
+				"bytes_uploaded", r.bytesRead,
+				"total_size", r.totalSize,
+				"human_readable_size", sizeStr)
 			r.lastLoggedPct = pct
 		}
 	}
@@ -82,6 +181,7 @@ func (r *progressReader) Seek(offset int64, whence int) (int64, error) {
 	if offset == 0 && whence == io.SeekStart {
 		r.bytesRead = 0
 		r.lastLoggedPct = 0
+		r.lastReported = 0
 	}
 	return r.reader.Seek(offset, whence)
 }
@@ -93,13 +193,67 @@ func NewS3Client(cfg DumperConfig) (*S3Client, error) {
 		return nil, err
 	}
 
+	retryBaseDelay := cfg.RetryBaseDelay
+	if retryBaseDelay == 0 {
+		retryBaseDelay = DefaultRetryBaseDelay
+	}
+
 	return &S3Client{
-		client: s3Client,
-		bucket: cfg.S3Bucket,
-		logger: cfg.Logger,
+		client:  s3Client,
+		bucket:  cfg.S3Bucket,
+		logger:  cfg.logger(),
+		secrets: []string{cfg.S3SecretKey, cfg.S3AccessKey},
+
+		parallelDownload:    cfg.ParallelDownload,
+		downloadPartSize:    cfg.DownloadPartSize,
+		downloadConcurrency: cfg.DownloadConcurrency,
+
+		retentionAge: cfg.RetentionAge,
+		warmUp:       cfg.S3WarmUp,
+		verify:       cfg.VerifyChecksum,
+		storageClass: cfg.StorageClass,
+		sse:          cfg.ServerSideEncryption,
+		sseKMSKeyID:  cfg.SSEKMSKeyID,
+
+		metadata:    releaseMetadata(cfg),
+		progress:    cfg.ProgressHandler,
+		logInterval: cfg.ProgressLogInterval,
+
+		singleDeletes: cfg.Provider == ProviderGCS,
+
+		maxRetries:     cfg.MaxRetries,
+		retryBaseDelay: retryBaseDelay,
 	}, nil
 }
 
+// objectMetadata merges per-object metadata into the client's release metadata
+func (s *S3Client) objectMetadata(extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return s.metadata
+	}
+	metadata := maps.Clone(s.metadata)
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	maps.Copy(metadata, extra)
+	return metadata
+}
+
+// releaseMetadata returns the application release metadata stored on archives, or nil if none is set
+func releaseMetadata(cfg DumperConfig) map[string]string {
+	metadata := map[string]string{}
+	if cfg.ReleaseSHA != "" {
+		metadata["release-sha"] = cfg.ReleaseSHA
+	}
+	if cfg.ReleaseVersion != "" {
+		metadata["release-version"] = cfg.ReleaseVersion
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
 // newS3ClientInternal configures and creates an S3 client
 func newS3ClientInternal(cfg DumperConfig) (*s3.Client, error) {
 	// Configure AWS SDK to use Backblaze B2's S3-compatible API
@@ -112,17 +266,32 @@ func newS3ClientInternal(cfg DumperConfig) (*s3.Client, error) {
 		}, nil
 	})
 
-	s3Cfg, err := config.LoadDefaultConfig(context.Background(),
+	loadOptions := []func(*config.LoadOptions) error{
 		config.WithEndpointResolverWithOptions(s3Resolver),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+		config.WithRegion(cfg.S3Region),
+	}
+
+	// Static keys by default (Backblaze), otherwise the SDK's default credential chain
+	if cfg.usesDefaultCredentials() {
+		if cfg.AWSProfile != "" {
+			loadOptions = append(loadOptions, config.WithSharedConfigProfile(cfg.AWSProfile))
+		}
+	} else {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
 			cfg.S3AccessKey,
 			cfg.S3SecretKey,
 			"",
-		)),
-		config.WithRegion(cfg.S3Region),
-	)
+		)))
+	}
+
+	// Tune the SDK's own retryer, which retries each request independently of our code
+	if cfg.S3SDKMaxAttempts > 0 {
+		loadOptions = append(loadOptions, config.WithRetryMaxAttempts(cfg.S3SDKMaxAttempts))
+	}
+
+	s3Cfg, err := config.LoadDefaultConfig(context.Background(), loadOptions...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to configure S3 client: %w", err)
+		return nil, fmt.Errorf("failed to configure S3 client: %w", scrubCredentials(err, cfg.S3SecretKey, cfg.S3AccessKey))
 	}
 
 	// Create client with B2-specific options
@@ -131,8 +300,16 @@ func newS3ClientInternal(cfg DumperConfig) (*s3.Client, error) {
 	}), nil
 }
 
+// UploadOptions describes the object UploadFile creates
+type UploadOptions struct {
+	Key      string            // Destination S3 key
+	Metadata map[string]string // User metadata added to the release metadata (optional)
+}
+
 // UploadFile uploads a file to S3/Backblaze
-func (s *S3Client) UploadFile(ctx context.Context, filePath, s3Key string) error {
+func (s *S3Client) UploadFile(ctx context.Context, filePath string, opts UploadOptions) error {
+	s3Key := opts.Key
+
 	// Get file info for size
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -157,11 +334,12 @@ func (s *S3Client) UploadFile(ctx context.Context, filePath, s3Key string) error
 	}
 
 	s.logger.Info("Uploading to S3",
-		zap.String("local_path", filePath),
-		zap.String("s3_key", s3Key),
-		zap.String("bucket", s.bucket),
-		zap.Int64("size_bytes", fileSizeBytes),
-		zap.String("file_size", fileSizeStr))
+		"local_path", filePath,
+		"s3_key", s3Key,
+		"bucket", s.bucket,
+		"size_bytes", fileSizeBytes,
+		"file_size", fileSizeStr,
+		"storage_class", GetValueOrDefault(s.storageClass, "default"))
 
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -177,19 +355,63 @@ func (s *S3Client) UploadFile(ctx context.Context, filePath, s3Key string) error
 		lastLoggedPct: 0,
 		logger:        s.logger,
 		s3Key:         s3Key,
+		progress:      s.progress,
+		throttle:      progressThrottle(s.logInterval),
+	}
+
+	// Store the SHA-256 with the object so parallel downloads can verify the assembled file
+	sha256Sum, err := fileSHA256(file)
+	if err != nil {
+		return err
+	}
+	metadata := s.objectMetadata(withChecksum(opts.Metadata, sha256Sum))
+
+	// Hash the file up front, Content-MD5 must be known before the request is sent
+	var checksum []byte
+	if s.verify {
+		if checksum, err = fileMD5(file); err != nil {
+			return err
+		}
+	}
+
+	// Establish the connection first so handshake latency doesn't skew the upload timing
+	if s.warmUp {
+		s.warmUpConnection(ctx)
 	}
 
 	// Track upload start time
 	startTime := time.Now()
 
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:        aws.String(s.bucket),
-		Key:           aws.String(s3Key),
-		Body:          progressR,
-		ContentLength: aws.Int64(fileInfo.Size()),
+	var result *s3.PutObjectOutput
+	err = s.retry(ctx, func() error {
+		// A retried upload must send the file from the beginning again
+		if _, err := progressR.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to rewind upload file: %w", err)
+		}
+		var err error
+		result, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(s3Key),
+			Body:          progressR,
+			ContentLength: aws.Int64(fileInfo.Size()),
+			ContentMD5:    contentMD5(checksum),
+			StorageClass:  types.StorageClass(s.storageClass),
+			// Empty values are omitted from the request, leaving the bucket default
+			ServerSideEncryption: types.ServerSideEncryption(s.sse),
+			SSEKMSKeyId:          optionalString(s.sseKMSKeyID),
+			Tagging:              s.retentionTagging(startTime),
+			Metadata:             metadata,
+		})
+		return err
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upload to S3: %w", err)
+		return fmt.Errorf("failed to upload to S3: %w", s.scrub(err))
+	}
+
+	if s.verify {
+		if err := s.verifyETag(ctx, s3Key, checksum); err != nil {
+			return err
+		}
 	}
 
 	// Calculate duration and transfer speed
@@ -197,57 +419,406 @@ func (s *S3Client) UploadFile(ctx context.Context, filePath, s3Key string) error
 	bytesPerSec := float64(fileInfo.Size()) / duration.Seconds()
 
 	s.logger.Info("Successfully uploaded to S3",
-		zap.String("s3_key", s3Key),
-		zap.String("bucket", s.bucket),
-		zap.Duration("duration", duration),
-		zap.Float64("mb_per_sec", bytesPerSec/1024/1024),
-		zap.Int64("size_bytes", fileInfo.Size()))
+		"s3_key", s3Key,
+		"bucket", s.bucket,
+		"duration", duration,
+		"mb_per_sec", bytesPerSec/1024/1024,
+		"size_bytes", fileInfo.Size(),
+		"server_side_encryption", GetValueOrDefault(string(result.ServerSideEncryption), "none"))
 
 	return nil
 }
 
-// DownloadFile downloads a file from S3/Backblaze
-func (s *S3Client) DownloadFile(ctx context.Context, s3Key, localPath string) error {
-	s.logger.Info("Downloading from S3",
-		zap.String("s3_key", s3Key),
-		zap.String("local_path", localPath),
-		zap.String("bucket", s.bucket))
+// optionalString returns nil for an empty string, so optional request fields are omitted
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return aws.String(value)
+}
 
-	// Create the file
-	file, err := os.Create(localPath)
+// streamPartSize is the multipart part size for streamed uploads. S3 allows at most 10,000
+// parts, limiting a single streamed backup to about 156 GB.
+const streamPartSize = 16 * 1024 * 1024
+
+// UploadStream uploads everything read from r to opts.Key as a multipart upload, without knowing
+// the size up front. The upload is aborted if r returns an error, so a failed producer never
+// leaves a truncated object behind. It returns the number of bytes uploaded.
+func (s *S3Client) UploadStream(ctx context.Context, r io.Reader, opts UploadOptions) (int64, error) {
+	s3Key := opts.Key
+	s.logger.Info("Streaming upload to S3",
+		"s3_key", s3Key,
+		"bucket", s.bucket,
+		"storage_class", GetValueOrDefault(s.storageClass, "default"))
+
+	// The checksum is only known once the stream ends, it is added to the metadata afterwards
+	hash := sha256.New()
+	r = io.TeeReader(r, hash)
+
+	startTime := time.Now()
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(s3Key),
+		Tagging:      s.retentionTagging(startTime),
+		Metadata:     s.objectMetadata(opts.Metadata),
+		StorageClass: types.StorageClass(s.storageClass),
+		// Empty values are omitted from the request, leaving the bucket default
+		ServerSideEncryption: types.ServerSideEncryption(s.sse),
+		SSEKMSKeyId:          optionalString(s.sseKMSKeyID),
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create local file: %w", err)
+		return 0, fmt.Errorf("failed to start multipart upload: %w", s.scrub(err))
 	}
-	defer file.Close()
 
-	// Get the object from S3
+	var parts []types.CompletedPart
+	var total int64
+	buffer := make([]byte, streamPartSize)
+	uploadErr := func() error {
+		for partNumber := int32(1); ; partNumber++ {
+			n, readErr := io.ReadFull(r, buffer)
+			if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("failed to read upload stream: %w", readErr)
+			}
+			// Every upload needs at least one part, even an empty one
+			if n == 0 && len(parts) > 0 {
+				return nil
+			}
+
+			part, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:        aws.String(s.bucket),
+				Key:           aws.String(s3Key),
+				UploadId:      created.UploadId,
+				PartNumber:    aws.Int32(partNumber),
+				Body:          bytes.NewReader(buffer[:n]),
+				ContentLength: aws.Int64(int64(n)),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to upload part %d: %w", partNumber, s.scrub(err))
+			}
+			parts = append(parts, types.CompletedPart{ETag: part.ETag, PartNumber: aws.Int32(partNumber)})
+			total += int64(n)
+
+			s.logger.Debug("Uploaded stream part",
+				"s3_key", s3Key,
+				"part", partNumber,
+				"bytes_uploaded", total)
+
+			if readErr != nil {
+				return nil
+			}
+		}
+	}()
+	if uploadErr != nil {
+		// Use a fresh context, the upload context may be what was cancelled
+		if _, err := s.client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(s3Key),
+			UploadId: created.UploadId,
+		}); err != nil {
+			s.logger.Warn("Failed to abort multipart upload",
+				"s3_key", s3Key,
+				"error", s.scrub(err))
+		}
+		return total, uploadErr
+	}
+
+	completed, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(s3Key),
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return total, fmt.Errorf("failed to complete multipart upload: %w", s.scrub(err))
+	}
+
+	duration := time.Since(startTime)
+	s.logger.Info("Successfully streamed to S3",
+		"s3_key", s3Key,
+		"bucket", s.bucket,
+		"duration", duration,
+		"mb_per_sec", float64(total)/1024/1024/duration.Seconds(),
+		"size_bytes", total,
+		"parts", len(parts),
+		"server_side_encryption", GetValueOrDefault(string(completed.ServerSideEncryption), "none"))
+
+	s.storeStreamChecksum(ctx, s3Key, opts, total, hex.EncodeToString(hash.Sum(nil)))
+	return total, nil
+}
+
+// maxCopyObjectSize is the largest object a single CopyObject request can copy
+const maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+
+// storeStreamChecksum adds the SHA-256 of a streamed upload to its metadata by copying the
+// object onto itself. Metadata can only be replaced by a copy, which re-sends the storage
+// class and encryption settings. Objects above 5GB and failed copies keep their object
+// without a checksum, so the upload itself still succeeds.
+func (s *S3Client) storeStreamChecksum(ctx context.Context, s3Key string, opts UploadOptions, size int64, checksum string) {
+	if size > maxCopyObjectSize {
+		s.logger.Warn("Streamed object is too large to store its checksum",
+			"s3_key", s3Key,
+			"size_bytes", size)
+		return
+	}
+
+	err := s.retry(ctx, func() error {
+		_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:            aws.String(s.bucket),
+			Key:               aws.String(s3Key),
+			CopySource:        aws.String((&url.URL{Path: s.bucket + "/" + s3Key}).EscapedPath()),
+			MetadataDirective: types.MetadataDirectiveReplace,
+			Metadata:          s.objectMetadata(withChecksum(opts.Metadata, checksum)),
+			StorageClass:      types.StorageClass(s.storageClass),
+			// Empty values are omitted from the request, leaving the bucket default
+			ServerSideEncryption: types.ServerSideEncryption(s.sse),
+			SSEKMSKeyId:          optionalString(s.sseKMSKeyID),
+		})
+		return err
+	})
+	if err != nil {
+		s.logger.Warn("Failed to store the checksum of the streamed object",
+			"s3_key", s3Key,
+			"error", s.scrub(err))
+		return
+	}
+	s.logger.Debug("Stored streamed object checksum",
+		"s3_key", s3Key,
+		"sha256", checksum)
+}
+
+// DefaultRetryBaseDelay is the wait before the first retry of a failed S3 operation
+const DefaultRetryBaseDelay = time.Second
+
+// maxRetryDelay caps the exponential backoff between retries
+const maxRetryDelay = time.Minute
+
+// retry runs fn and retries it up to the configured number of times while it fails with a
+// transient error, waiting with exponential backoff and jitter between attempts
+func (s *S3Client) retry(ctx context.Context, fn func() error) error {
+	err := fn()
+	for attempt := 0; attempt < s.maxRetries && err != nil && isRetryableError(err); attempt++ {
+		delay := min(s.retryBaseDelay<<attempt, maxRetryDelay)
+		// Up to 50% jitter keeps concurrent uploads from retrying in lockstep
+		delay += rand.N(delay/2 + 1)
+
+		s.logger.Warn("S3 operation failed, retrying",
+			"attempt", attempt+1,
+			"max_retries", s.maxRetries,
+			"retry_in", delay,
+			"error", s.scrub(err))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		err = fn()
+	}
+	return err
+}
+
+// isRetryableError reports whether an S3 error is transient: a 5xx response or a network error
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatusCode() >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// fileMD5 hashes a file and rewinds it for the upload
+func fileMD5(file *os.File) ([]byte, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file after checksum: %w", err)
+	}
+	return hash.Sum(nil), nil
+}
+
+// contentMD5 returns the base64 Content-MD5 header value, or nil when no checksum is set
+func contentMD5(checksum []byte) *string {
+	if checksum == nil {
+		return nil
+	}
+	return aws.String(base64.StdEncoding.EncodeToString(checksum))
+}
+
+// verifyETag compares the stored object's ETag with the uploaded file's MD5. Single-part
+// uploads without SSE-KMS use the MD5 as ETag.
+func (s *S3Client) verifyETag(ctx context.Context, s3Key string, checksum []byte) error {
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s3Key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify upload: %w", s.scrub(err))
+	}
+
+	expected := hex.EncodeToString(checksum)
+	etag := strings.Trim(aws.ToString(head.ETag), `"`)
+	if !strings.EqualFold(etag, expected) {
+		return fmt.Errorf("%w for %s: local md5 %s, stored etag %s", ErrChecksumMismatch, s3Key, expected, etag)
+	}
+
+	s.logger.Info("Upload checksum verified",
+		"s3_key", s3Key,
+		"md5", expected)
+	return nil
+}
+
+// warmUpConnection sends a lightweight HeadBucket request and logs its duration. Failures
+// are only logged, the upload itself reports any real problem.
+func (s *S3Client) warmUpConnection(ctx context.Context) {
+	startTime := time.Now()
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(s.bucket),
+	})
+	if err != nil {
+		s.logger.Warn("S3 warm-up request failed",
+			"bucket", s.bucket,
+			"error", s.scrub(err))
+		return
+	}
+
+	s.logger.Info("S3 connection warmed up",
+		"bucket", s.bucket,
+		"warm_up_duration", time.Since(startTime))
+}
+
+// retentionTagging returns the URL-encoded created-date and expire-date object tags computed
+// from the retention age, or nil when no retention is configured
+func (s *S3Client) retentionTagging(created time.Time) *string {
+	if s.retentionAge <= 0 {
+		return nil
+	}
+
+	created = created.UTC()
+	tags := url.Values{}
+	tags.Set("created-date", created.Format(time.DateOnly))
+	tags.Set("expire-date", created.Add(s.retentionAge).Format(time.DateOnly))
+	return aws.String(tags.Encode())
+}
+
+// UploadBytes uploads a small in-memory object to S3/Backblaze
+func (s *S3Client) UploadBytes(ctx context.Context, data []byte, s3Key, contentType string) error {
+	s.logger.Info("Uploading to S3",
+		"s3_key", s3Key,
+		"bucket", s.bucket,
+		"size_bytes", len(data))
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s3Key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload to S3: %w", s.scrub(err))
+	}
+
+	return nil
+}
+
+// DownloadBytes downloads a small object into memory, returning ErrObjectNotFound if it does not exist
+func (s *S3Client) DownloadBytes(ctx context.Context, s3Key string) ([]byte, error) {
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(s3Key),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to download from S3: %w", err)
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%s: %w", s3Key, ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to download from S3: %w", s.scrub(err))
 	}
 	defer result.Body.Close()
 
-	// Write the body to file
-	_, err = io.Copy(file, result.Body)
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+
+	return data, nil
+}
+
+// DownloadFile downloads a file from S3/Backblaze
+func (s *S3Client) DownloadFile(ctx context.Context, s3Key, localPath string) error {
+	if s.parallelDownload {
+		return s.retry(ctx, func() error {
+			return s.downloadFileParallel(ctx, s3Key, localPath)
+		})
+	}
+
+	s.logger.Info("Downloading from S3",
+		"s3_key", s3Key,
+		"local_path", localPath,
+		"bucket", s.bucket)
+
+	// Create the file
+	file, err := os.Create(localPath)
 	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer file.Close()
+
+	err = s.retry(ctx, func() error {
+		// Discard whatever a failed attempt already wrote
+		if err := file.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate local file: %w", err)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to rewind local file: %w", err)
+		}
+
+		// Get the object from S3
+		result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(s3Key),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to download from S3: %w", s.scrub(err))
+		}
+		defer result.Body.Close()
+
+		// Write the body to file
+		if _, err := io.Copy(file, result.Body); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	s.logger.Info("Successfully downloaded from S3",
-		zap.String("s3_key", s3Key),
-		zap.String("local_path", localPath))
+		"s3_key", s3Key,
+		"local_path", localPath)
 
 	return nil
 }
 
-// ListBackups lists all backups in a directory
-func (s *S3Client) ListBackups(ctx context.Context, prefix string) ([]string, error) {
-	s.logger.Info("Listing backups", zap.String("prefix", prefix))
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	StorageClass string
+}
 
-	var backups []string
+// ListObjects lists all objects below a prefix with their size and storage class
+func (s *S3Client) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
 	var continuationToken *string
 
 	for {
@@ -257,11 +828,66 @@ func (s *S3Client) ListBackups(ctx context.Context, prefix string) ([]string, er
 			ContinuationToken: continuationToken,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to list objects: %w", err)
+			return nil, fmt.Errorf("failed to list objects: %w", s.scrub(err))
 		}
 
 		for _, item := range result.Contents {
-			backups = append(backups, *item.Key)
+			objects = append(objects, ObjectInfo{
+				Key:          aws.ToString(item.Key),
+				Size:         aws.ToInt64(item.Size),
+				LastModified: aws.ToTime(item.LastModified),
+				StorageClass: string(item.StorageClass),
+			})
+		}
+
+		if result.IsTruncated == nil || !*result.IsTruncated {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	return objects, nil
+}
+
+// BackupInfo describes a stored backup object
+type BackupInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size_bytes"`
+	LastModified time.Time `json:"last_modified"`
+
+	// CreatedAt is the backup time parsed from the key, or LastModified for keys without a
+	// timestamp. Only set by Dumper.ListBackups.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListBackups lists all backups in a directory
+func (s *S3Client) ListBackups(ctx context.Context, prefix string) ([]BackupInfo, error) {
+	s.logger.Info("Listing backups", "prefix", prefix)
+
+	var backups []BackupInfo
+	var continuationToken *string
+
+	for {
+		var result *s3.ListObjectsV2Output
+		err := s.retry(ctx, func() error {
+			var err error
+			result, err = s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+				Bucket:            aws.String(s.bucket),
+				Prefix:            aws.String(prefix),
+				ContinuationToken: continuationToken,
+			})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", s.scrub(err))
+		}
+
+		for _, item := range result.Contents {
+			backups = append(backups, BackupInfo{
+				Key:          aws.ToString(item.Key),
+				Size:         aws.ToInt64(item.Size),
+				LastModified: aws.ToTime(item.LastModified),
+			})
 		}
 
 		if result.IsTruncated == nil || !*result.IsTruncated {
@@ -272,3 +898,60 @@ func (s *S3Client) ListBackups(ctx context.Context, prefix string) ([]string, er
 
 	return backups, nil
 }
+
+// maxDeleteBatch is the most keys a single DeleteObjects request accepts
+const maxDeleteBatch = 1000
+
+// DeleteObjects removes objects in batches of up to 1000 keys, logging every removed key
+func (s *S3Client) DeleteObjects(ctx context.Context, keys []string) error {
+	if s.singleDeletes {
+		for _, key := range keys {
+			if err := s.DeleteObject(ctx, key); err != nil {
+				return err
+			}
+			s.logger.Info("Deleted object from S3", "s3_key", key)
+		}
+		return nil
+	}
+
+	for start := 0; start < len(keys); start += maxDeleteBatch {
+		batch := keys[start:min(start+maxDeleteBatch, len(keys))]
+
+		objects := make([]types.ObjectIdentifier, 0, len(batch))
+		for _, key := range batch {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		result, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(false)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete objects: %w", s.scrub(err))
+		}
+
+		for _, deleted := range result.Deleted {
+			s.logger.Info("Deleted object from S3", "s3_key", aws.ToString(deleted.Key))
+		}
+		if len(result.Errors) > 0 {
+			first := result.Errors[0]
+			return fmt.Errorf("failed to delete %d objects, first %s: %s",
+				len(result.Errors), aws.ToString(first.Key), aws.ToString(first.Message))
+		}
+	}
+
+	return nil
+}
+
+// DeleteObject removes an object from the bucket
+func (s *S3Client) DeleteObject(ctx context.Context, s3Key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s3Key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", s.scrub(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Defaults for parallel downloads
+const (
+	DefaultDownloadPartSize    int64 = 16 * 1024 * 1024 // 16MB
+	DefaultDownloadConcurrency       = 4
+)
+
+// ChecksumMetadataKey is the object metadata key holding the archive's hex SHA-256 checksum
+const ChecksumMetadataKey = "sha256"
+
+// withChecksum returns a copy of metadata holding the hex SHA-256 checksum under ChecksumMetadataKey
+func withChecksum(metadata map[string]string, checksum string) map[string]string {
+	metadata = maps.Clone(metadata)
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadata[ChecksumMetadataKey] = checksum
+	return metadata
+}
+
+// downloadProgress tracks bytes written by concurrent part downloads
+type downloadProgress struct {
+	mu            sync.Mutex
+	totalSize     int64
+	bytesDone     int64
+	lastLoggedPct int
+	logger        Logger
+	s3Key         string
+}
+
+// add records a finished part and logs progress at 10% intervals or 100%
+func (p *downloadProgress) add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.bytesDone += n
+	pct := int((float64(p.bytesDone) / float64(p.totalSize)) * 100)
+	if pct >= p.lastLoggedPct+10 || pct == 100 {
+		p.logger.Info("Download progress",
+			"s3_key", p.s3Key,
+			"percent_complete", pct,
+			"bytes_downloaded", p.bytesDone,
+			"total_size", p.totalSize)
+		p.lastLoggedPct = pct
+	}
+}
+
+// downloadFileParallel downloads an object with concurrent ranged GETs written at their offsets
+// in a pre-allocated file, then verifies the SHA-256 checksum stored in the object metadata
+func (s *S3Client) downloadFileParallel(ctx context.Context, s3Key, localPath string) error {
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s3Key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object info from S3: %w", s.scrub(err))
+	}
+	totalSize := aws.ToInt64(head.ContentLength)
+
+	partSize := s.downloadPartSize
+	if partSize <= 0 {
+		partSize = DefaultDownloadPartSize
+	}
+	concurrency := s.downloadConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultDownloadConcurrency
+	}
+
+	s.logger.Info("Downloading from S3 in parallel",
+		"s3_key", s3Key,
+		"local_path", localPath,
+		"bucket", s.bucket,
+		"size_bytes", totalSize,
+		"part_size", partSize,
+		"concurrency", concurrency)
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer file.Close()
+
+	// Pre-allocate so every part can be written at its final offset
+	if err := file.Truncate(totalSize); err != nil {
+		return fmt.Errorf("failed to allocate local file: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	progress := &downloadProgress{totalSize: totalSize, logger: s.logger, s3Key: s3Key}
+	offsets := make(chan int64)
+	errCh := make(chan error, concurrency)
+	var wg sync.WaitGroup
+
+	startTime := time.Now()
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range offsets {
+				end := min(offset+partSize, totalSize) - 1
+				if err := s.downloadPart(ctx, s3Key, file, offset, end); err != nil {
+					errCh <- err
+					cancel()
+					return
+				}
+				progress.add(end - offset + 1)
+			}
+		}()
+	}
+
+	// Feed part offsets until done or a worker failed
+feed:
+	for offset := int64(0); offset < totalSize; offset += partSize {
+		select {
+		case offsets <- offset:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(offsets)
+	wg.Wait()
+	close(errCh)
+
+	if err := <-errCh; err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("download cancelled: %w", err)
+	}
+
+	duration := time.Since(startTime)
+	s.logger.Info("Successfully downloaded from S3",
+		"s3_key", s3Key,
+		"local_path", localPath,
+		"duration", duration,
+		"mb_per_sec", float64(totalSize)/1024/1024/duration.Seconds())
+
+	expected, ok := head.Metadata[ChecksumMetadataKey]
+	if !ok {
+		s.logger.Warn("Object has no stored checksum, skipping verification", "s3_key", s3Key)
+		return nil
+	}
+
+	actual, err := fileSHA256(file)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", s3Key, expected, actual)
+	}
+
+	s.logger.Info("Download checksum verified",
+		"s3_key", s3Key,
+		"sha256", actual)
+
+	return nil
+}
+
+// downloadPart fetches the inclusive byte range [start, end] and writes it at its offset
+func (s *S3Client) downloadPart(ctx context.Context, s3Key string, file *os.File, start, end int64) error {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s3Key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download part %d-%d from S3: %w", start, end, s.scrub(err))
+	}
+	defer result.Body.Close()
+
+	written, err := io.Copy(io.NewOffsetWriter(file, start), result.Body)
+	if err != nil {
+		return fmt.Errorf("failed to write part %d-%d: %w", start, end, err)
+	}
+	if written != end-start+1 {
+		return fmt.Errorf("short read for part %d-%d: got %d bytes", start, end, written)
+	}
+
+	return nil
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's full content
+func fileSHA256(file *os.File) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(file, 0, 1<<62)); err != nil {
+		return "", fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
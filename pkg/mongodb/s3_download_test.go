@@ -0,0 +1,257 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// rangeServer is a minimal S3 endpoint storing PUT, multipart and copied objects in memory
+// and serving HEAD and ranged GET requests, counting the ranges it served
+type rangeServer struct {
+	mu       sync.Mutex
+	data     map[string][]byte
+	metadata map[string]http.Header
+	parts    map[string][]byte // Multipart upload data by key, parts arrive in order
+	ranges   int
+}
+
+func newRangeServer(t *testing.T) (*rangeServer, *httptest.Server) {
+	t.Helper()
+	rs := &rangeServer{data: map[string][]byte{}, metadata: map[string]http.Header{}, parts: map[string][]byte{}}
+	srv := httptest.NewServer(rs)
+	t.Cleanup(srv.Close)
+	return rs, srv
+}
+
+func (rs *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	key := r.URL.Path
+	query := r.URL.Query()
+	metadata := http.Header{}
+	for name, values := range r.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+			metadata[name] = values
+		}
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		rs.parts[key] = []byte{}
+		rs.metadata[key] = metadata
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		rs.parts[key] = append(rs.parts[key], body...)
+		w.Header().Set("ETag", `"part"`)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		rs.data[key] = rs.parts[key]
+		delete(rs.parts, key)
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		source = "/" + strings.TrimPrefix(source, "/")
+		data, ok := rs.data[source]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rs.data[key] = data
+		if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+			rs.metadata[key] = metadata
+		}
+		fmt.Fprintf(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+	case r.Method == http.MethodPut:
+		rs.data[key] = body
+		rs.metadata[key] = metadata
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		data, ok := rs.data[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for name, values := range rs.metadata[key] {
+			w.Header()[name] = values
+		}
+		start, end := 0, len(data)-1
+		if spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+			from, to, _ := strings.Cut(spec, "-")
+			start, _ = strconv.Atoi(from)
+			end, _ = strconv.Atoi(to)
+			end = min(end, len(data)-1)
+			rs.ranges++
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(end-start+1))
+		if r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		if r.Method == http.MethodGet {
+			w.Write(data[start : end+1])
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newRangeServerClient returns an S3Client of the range server downloading 1KB parts
+func newRangeServerClient(t *testing.T, srv *httptest.Server) *S3Client {
+	t.Helper()
+	client, err := NewS3Client(DumperConfig{
+		S3Endpoint:          srv.URL,
+		S3Region:            "us-east-1",
+		S3Bucket:            "backups",
+		S3AccessKey:         "test-access-key",
+		S3SecretKey:         "test-secret-key",
+		ParallelDownload:    true,
+		DownloadPartSize:    1024,
+		DownloadConcurrency: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestParallelDownloadVerifiesUploadedChecksum(t *testing.T) {
+	rs, srv := newRangeServer(t)
+	client := newRangeServerClient(t, srv)
+
+	// Not a multiple of the part size, so the last part is short
+	content := make([]byte, 10*1024+123)
+	rand.Read(content)
+	source := filepath.Join(t.TempDir(), "backup.zip")
+	if err := os.WriteFile(source, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := client.UploadFile(ctx, source, UploadOptions{Key: "test/backup.zip"}); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	if got := rs.metadata["/backups/test/backup.zip"].Get("X-Amz-Meta-Sha256"); got != hex.EncodeToString(sum[:]) {
+		t.Fatalf("uploaded sha256 metadata = %q, want %x", got, sum)
+	}
+
+	target := filepath.Join(t.TempDir(), "download.zip")
+	if err := client.DownloadFile(ctx, "test/backup.zip", target); err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, content) {
+		t.Error("downloaded file differs from the upload")
+	}
+	if rs.ranges != 11 {
+		t.Errorf("served %d ranges, want 11", rs.ranges)
+	}
+}
+
+func TestParallelDownloadDetectsChecksumMismatch(t *testing.T) {
+	rs, srv := newRangeServer(t)
+	client := newRangeServerClient(t, srv)
+
+	content := bytes.Repeat([]byte("backup"), 1000)
+	rs.data["/backups/test/backup.zip"] = content
+	rs.metadata["/backups/test/backup.zip"] = http.Header{"X-Amz-Meta-Sha256": {strings.Repeat("0", 64)}}
+
+	err := client.DownloadFile(context.Background(), "test/backup.zip", filepath.Join(t.TempDir(), "download.zip"))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("DownloadFile of a modified object = %v, want a checksum mismatch", err)
+	}
+}
+
+func TestUploadStreamStoresChecksum(t *testing.T) {
+	rs, srv := newRangeServer(t)
+	client := newRangeServerClient(t, srv)
+
+	content := bytes.Repeat([]byte("streamed backup"), 1000)
+	n, err := client.UploadStream(context.Background(), bytes.NewReader(content), UploadOptions{
+		Key:      "test/dir with space/backup.archive",
+		Metadata: map[string]string{"database": "app"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("UploadStream = %d bytes, want %d", n, len(content))
+	}
+
+	key := "/backups/test/dir with space/backup.archive"
+	if !bytes.Equal(rs.data[key], content) {
+		t.Fatal("streamed object differs from the input")
+	}
+	sum := sha256.Sum256(content)
+	if got := rs.metadata[key].Get("X-Amz-Meta-Sha256"); got != hex.EncodeToString(sum[:]) {
+		t.Errorf("streamed sha256 metadata = %q, want %x", got, sum)
+	}
+	if got := rs.metadata[key].Get("X-Amz-Meta-Database"); got != "app" {
+		t.Errorf("database metadata = %q after storing the checksum, want app", got)
+	}
+}
+
+func TestUploadStoresReleaseMetadata(t *testing.T) {
+	rs, srv := newRangeServer(t)
+	client, err := NewS3Client(DumperConfig{
+		S3Endpoint:     srv.URL,
+		S3Region:       "us-east-1",
+		S3Bucket:       "backups",
+		S3AccessKey:    "test-access-key",
+		S3SecretKey:    "test-secret-key",
+		ReleaseSHA:     "4f2a9c1",
+		ReleaseVersion: "v1.8.0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := filepath.Join(t.TempDir(), "backup.zip")
+	if err := os.WriteFile(source, []byte("backup"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err = client.UploadFile(context.Background(), source, UploadOptions{
+		Key:      "test/backup.zip",
+		Metadata: map[string]string{"database": "app"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := rs.metadata["/backups/test/backup.zip"]
+	if got := metadata.Get("X-Amz-Meta-Release-Sha"); got != "4f2a9c1" {
+		t.Errorf("release-sha metadata = %q, want 4f2a9c1", got)
+	}
+	if got := metadata.Get("X-Amz-Meta-Release-Version"); got != "v1.8.0" {
+		t.Errorf("release-version metadata = %q, want v1.8.0", got)
+	}
+	if got := metadata.Get("X-Amz-Meta-Database"); got != "app" {
+		t.Errorf("database metadata = %q next to the release, want app", got)
+	}
+}
@@ -0,0 +1,140 @@
+package mongodb
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultStatsWindow is the number of recent successful backups Stats summarizes
+const DefaultStatsWindow = 20
+
+// backupSample is one successful backup recorded for Stats
+type backupSample struct {
+	duration          time.Duration
+	sizeBytes         int64
+	originalSizeBytes int64
+}
+
+// backupSamples keeps the last samples in a fixed-size ring buffer, overwriting the oldest
+type backupSamples struct {
+	mu      sync.Mutex
+	samples []backupSample
+	next    int // Index the next sample is written to
+	count   int // Number of valid samples, at most len(samples)
+}
+
+// newBackupSamples creates a ring buffer holding up to window samples
+func newBackupSamples(window int) *backupSamples {
+	return &backupSamples{samples: make([]backupSample, window)}
+}
+
+// add records a sample, dropping the oldest once the buffer is full
+func (b *backupSamples) add(sample backupSample) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.samples[b.next] = sample
+	b.next = (b.next + 1) % len(b.samples)
+	b.count = min(b.count+1, len(b.samples))
+}
+
+// snapshot returns a copy of the recorded samples in no particular order
+func (b *backupSamples) snapshot() []backupSample {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.samples[:b.count])
+}
+
+// BackupStats summarizes the durations and sizes of recent successful backups
+type BackupStats struct {
+	Count          int // Backups summarized, at most the configured window
+	MinDuration    time.Duration
+	MedianDuration time.Duration
+	P95Duration    time.Duration
+	MaxDuration    time.Duration
+	AvgSizeBytes   int64
+
+	// AvgCompressionRatio is the mean of original size / stored size over the backups that
+	// reported their original size, 0 if none did (streamed and pipelined backups don't)
+	AvgCompressionRatio float64
+}
+
+// Stats summarizes the last StatsWindow successful backups, so operators can spot a backup
+// that suddenly takes much longer than usual
+func (d *Dumper) Stats() BackupStats {
+	samples := d.samples.snapshot()
+	if len(samples) == 0 {
+		return BackupStats{}
+	}
+
+	durations := make([]time.Duration, len(samples))
+	var totalSize int64
+	for i, sample := range samples {
+		durations[i] = sample.duration
+		totalSize += sample.sizeBytes
+	}
+	slices.Sort(durations)
+
+	stats := BackupStats{
+		Count:          len(samples),
+		MinDuration:    durations[0],
+		MedianDuration: percentile(durations, 0.5),
+		P95Duration:    percentile(durations, 0.95),
+		MaxDuration:    durations[len(durations)-1],
+		AvgSizeBytes:   totalSize / int64(len(samples)),
+	}
+	stats.AvgCompressionRatio, _ = averageRatio(samples)
+	return stats
+}
+
+// averageRatio returns the mean compression ratio of the samples that know their original
+// size and how many those are
+func averageRatio(samples []backupSample) (float64, int) {
+	var sum float64
+	var count int
+	for _, sample := range samples {
+		if sample.originalSizeBytes > 0 && sample.sizeBytes > 0 {
+			sum += float64(sample.originalSizeBytes) / float64(sample.sizeBytes)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return sum / float64(count), count
+}
+
+// minRatioSamples is how many earlier compression ratios checkCompressionRatio needs
+// before an average is considered meaningful
+const minRatioSamples = 3
+
+// checkCompressionRatio warns when a backup's compression ratio deviates from the average of
+// the recent backups by more than RatioDeviation. Per-database backups don't record their
+// ratios, so they are never compared.
+func (d *Dumper) checkCompressionRatio(ratio float64) {
+	if d.config.RatioDeviation <= 0 || ratio <= 0 {
+		return
+	}
+	average, count := averageRatio(d.samples.snapshot())
+	if count < minRatioSamples {
+		return
+	}
+
+	deviation := math.Abs(ratio-average) / average
+	if deviation > d.config.RatioDeviation {
+		d.logger.Warn("Compression ratio deviates from recent backups, check for emptied or duplicated collections",
+			"compression_ratio", ratio,
+			"average_ratio", average,
+			"backups", count,
+			"deviation_percent", math.Round(deviation*1000)/10,
+			"threshold_percent", d.config.RatioDeviation*100)
+	}
+}
+
+// percentile returns the nearest-rank percentile p (0 to 1) of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,53 @@
+package mongodb
+
+import (
+	"context"
+	"io"
+)
+
+// ObjectStore is the storage backups are uploaded to and restored from. S3Client implements
+// it; the Dumper only depends on this interface so other backends, or in-memory fakes, can
+// stand in for S3.
+type ObjectStore interface {
+	// UploadFile uploads a local file
+	UploadFile(ctx context.Context, filePath string, opts UploadOptions) error
+	// UploadStream uploads everything read from r, returning the number of bytes uploaded
+	UploadStream(ctx context.Context, r io.Reader, opts UploadOptions) (int64, error)
+	// UploadBytes uploads a small in-memory object
+	UploadBytes(ctx context.Context, data []byte, s3Key, contentType string) error
+
+	// DownloadFile downloads an object to a local file
+	DownloadFile(ctx context.Context, s3Key, localPath string) error
+	// DownloadBytes downloads a small object, returning ErrObjectNotFound if it does not exist
+	DownloadBytes(ctx context.Context, s3Key string) ([]byte, error)
+
+	// ListObjects lists all objects below a prefix
+	ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// ListBackups lists all backups below a prefix
+	ListBackups(ctx context.Context, prefix string) ([]BackupInfo, error)
+
+	// DeleteObject removes an object
+	DeleteObject(ctx context.Context, s3Key string) error
+	// DeleteObjects removes several objects
+	DeleteObjects(ctx context.Context, keys []string) error
+}
+
+var (
+	_ ObjectStore = (*S3Client)(nil)
+	_ ObjectStore = (*AzureStore)(nil)
+	_ ObjectStore = (*FilesystemStore)(nil)
+)
+
+// newObjectStore creates the store of the configured provider
+func newObjectStore(cfg DumperConfig) (ObjectStore, error) {
+	switch cfg.Provider {
+	case ProviderGCS:
+		return NewS3Client(cfg.gcsConfig())
+	case ProviderAzure:
+		return NewAzureStore(cfg)
+	case ProviderFS:
+		return NewFilesystemStore(cfg)
+	default:
+		return NewS3Client(cfg)
+	}
+}
@@ -0,0 +1,131 @@
+package mongodb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// streamArchiveExtension returns the S3 key extension of a streamed mongodump archive
+func (d *Dumper) streamArchiveExtension() string {
+	if d.config.MongodumpGzip {
+		return ".archive.gz"
+	}
+	return ".archive"
+}
+
+// DumpStream runs mongodump --archive and pipes its stdout straight into a multipart upload
+// to s3KeyPrefix plus the archive extension, without a local temp directory. A failed
+// mongodump aborts the upload, a failed upload stops mongodump.
+func (d *Dumper) DumpStream(ctx context.Context, s3KeyPrefix string) error {
+	s3Key := s3KeyPrefix + d.streamArchiveExtension()
+	d.logger.Info("STEP 1/1: Streaming MongoDB dump to S3",
+		"s3_key", s3Key)
+	startTime := time.Now()
+
+	if err := d.checkS3Circuit(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var collection string
+	if len(d.config.Collections) == 1 {
+		collection = d.config.Collections[0]
+	}
+	args := d.mongoDump.mongodumpArgs(collection, "--archive")
+
+	d.logger.Info("Executing mongodump", "args", redactedArgs(args))
+
+	cmd := exec.CommandContext(ctx, d.mongoDump.config.mongodumpBinary(), args...)
+	configureProcess(cmd)
+
+	d.mongoDump.output.Reset()
+	stderrBuf := newTailBuffer(maxCapturedOutput)
+	stdout, stderr, err := setupCommandOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to set up command output capture: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start mongodump: %w", err)
+	}
+
+	if d.config.Nice != 0 {
+		if err := setProcessNice(cmd, d.config.Nice); err != nil {
+			d.logger.Warn("Failed to set mongodump nice level", "nice", d.config.Nice, "error", err)
+		}
+	}
+
+	// With --archive on stdout, mongodump's verbose output goes to stderr
+	stderrCh := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			stderrBuf.WriteString(line + "\n")
+			d.mongoDump.output.WriteString(line + "\n")
+			d.logger.Debug("mongodump stderr", "output", line)
+		}
+		close(stderrCh)
+	}()
+
+	// The pipe only reports EOF once mongodump exited successfully, otherwise the upload
+	// sees mongodump's error and aborts instead of completing a truncated archive
+	pipeReader, pipeWriter := io.Pipe()
+	dumpErrCh := make(chan error, 1)
+	go func() {
+		_, copyErr := io.Copy(pipeWriter, stdout)
+		<-stderrCh
+		waitErr := cmd.Wait()
+		if waitErr != nil {
+			waitErr = fmt.Errorf("mongodump failed: %w - stderr: %s", waitErr, stderrBuf.String())
+		} else if copyErr != nil {
+			waitErr = fmt.Errorf("failed to read mongodump output: %w", copyErr)
+		}
+		pipeWriter.CloseWithError(waitErr)
+		dumpErrCh <- waitErr
+	}()
+
+	uploadOpts := UploadOptions{Key: s3Key, Metadata: d.backupMetadata(-1, -1)}
+	size, uploadErr := d.store.UploadStream(ctx, pipeReader, uploadOpts)
+	if uploadErr != nil {
+		// Stop mongodump and unblock its writer so the goroutine can finish
+		cancel()
+		pipeReader.CloseWithError(uploadErr)
+	}
+	dumpErr := <-dumpErrCh
+
+	// A mongodump failure reaches the upload through the pipe, it must not count against S3
+	if dumpErr != nil && (uploadErr == nil || errors.Is(uploadErr, dumpErr)) {
+		d.logger.Error("MongoDB dump failed",
+			"error", dumpErr,
+			"duration", time.Since(startTime))
+		return d.dumpError("stream", dumpErr)
+	}
+	if err := d.recordS3Result(uploadErr); err != nil {
+		return &UploadError{Phase: "stream", Key: s3Key, Err: err}
+	}
+	d.lastBackup = BackupResult{S3Key: s3Key, SizeBytes: size}
+
+	// Keep the full mongodump output next to the archive for auditing
+	if d.config.UploadDumpLog {
+		logKey := s3KeyPrefix + ".log"
+		if err := d.store.UploadBytes(ctx, d.mongoDump.DumpLog(), logKey, "text/plain"); err != nil {
+			d.logger.Warn("Failed to upload mongodump log",
+				"s3_key", logKey,
+				"error", err)
+		}
+	}
+
+	d.logger.Info("STEP 1/1: MongoDB dump streamed to S3",
+		"duration", time.Since(startTime),
+		"size_bytes", size)
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// DefaultChangeTokenField is the field whose maximum value identifies the latest change
+const DefaultChangeTokenField = "_id"
+
+// changeToken runs the cheap change-detection query: the maximum value of the configured
+// field plus the estimated document count, so both inserts and deletes change the token
+func (d *Dumper) changeToken(ctx context.Context) (string, error) {
+	field := GetValueOrDefault(d.config.SkipIfUnchangedField, DefaultChangeTokenField)
+
+	var token string
+	err := withMongoClient(ctx, &d.mongoDump.config, func(client *mongo.Client) error {
+		coll := client.Database(d.config.Database).Collection(d.config.SkipIfUnchangedCollection)
+
+		count, err := coll.EstimatedDocumentCount(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to count documents: %w", err)
+		}
+
+		var doc bson.Raw
+		opts := options.FindOne().
+			SetSort(bson.D{{Key: field, Value: -1}}).
+			SetProjection(bson.D{{Key: field, Value: 1}})
+		err = coll.FindOne(ctx, bson.D{}, opts).Decode(&doc)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			token = fmt.Sprintf("empty|%d", count)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query latest %s: %w", field, err)
+		}
+
+		token = fmt.Sprintf("%s|%d", doc.Lookup(field).String(), count)
+		return nil
+	})
+
+	return token, err
+}
+
+// changeTokenKey is the S3 key storing the change token of the last successful backup
+func (d *Dumper) changeTokenKey() string {
+	return fmt.Sprintf("%s%s.change-token", d.config.KeyPrefix(), d.config.KeyComponent(d.config.Database))
+}
+
+// unchangedSinceLastBackup reports whether the change token matches the one stored by the
+// previous backup. It returns the current token so it can be stored after a successful backup.
+// Any failure is logged and treated as changed, so the backup still runs.
+func (d *Dumper) unchangedSinceLastBackup(ctx context.Context) (bool, string) {
+	token, err := d.changeToken(ctx)
+	if err != nil {
+		d.logger.Warn("Change detection query failed, running backup", "error", err)
+		return false, ""
+	}
+
+	previous, err := d.store.DownloadBytes(ctx, d.changeTokenKey())
+	if err != nil {
+		if !errors.Is(err, ErrObjectNotFound) {
+			d.logger.Warn("Failed to read previous change token, running backup", "error", err)
+		}
+		return false, token
+	}
+
+	d.logger.Info("Change detection",
+		"collection", d.config.SkipIfUnchangedCollection,
+		"current_token", token,
+		"previous_token", string(previous))
+
+	return string(previous) == token, token
+}
+
+// storeChangeToken records the change token of a successful backup
+func (d *Dumper) storeChangeToken(ctx context.Context, token string) {
+	if token == "" {
+		return
+	}
+	if err := d.store.UploadBytes(ctx, []byte(token), d.changeTokenKey(), "text/plain"); err != nil {
+		d.logger.Warn("Failed to store change token", "error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// mongodumpVersionRegex matches the first line of `mongodump --version`, e.g.
+// "mongodump version: 100.9.4", or "mongodump version: r4.0.3" for legacy tools
+var mongodumpVersionRegex = regexp.MustCompile(`mongodump version:\s*r?(\d+\.\d+\.\d+)`)
+
+// databaseToolsMajor is the first major version of the MongoDB Database Tools, which are
+// versioned independently of the server. Older tools shipped with the server and share
+// its version.
+const databaseToolsMajor = 100
+
+// minToolsServerMajor is the oldest server major version the Database Tools support
+const minToolsServerMajor = 4
+
+// Version runs `mongodump --version` and returns the tool's semantic version, e.g. "100.9.4".
+// The result is cached, so later calls don't run mongodump again.
+func (d *MongoDumper) Version(ctx context.Context) (string, error) {
+	if d.toolVersion != "" {
+		return d.toolVersion, nil
+	}
+
+	output, err := exec.CommandContext(ctx, d.config.mongodumpBinary(), "--version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to run mongodump --version: %w", err)
+	}
+	match := mongodumpVersionRegex.FindSubmatch(output)
+	if match == nil {
+		line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+		return "", fmt.Errorf("unrecognized mongodump --version output: %q", line)
+	}
+
+	d.toolVersion = string(match[1])
+	return d.toolVersion, nil
+}
+
+// CheckVersions logs the mongodump version. With CheckServerVersion set it also reads the
+// server version from buildInfo and warns when the tools don't support it, the usual cause
+// of "unsupported BSON" style failures. Problems are logged, never returned, so a version
+// check can't prevent a backup.
+func (d *Dumper) CheckVersions(ctx context.Context) {
+	toolVersion, err := d.mongoDump.Version(ctx)
+	if err != nil {
+		d.logger.Warn("Could not determine mongodump version", "error", err)
+		return
+	}
+	d.logger.Info("Using mongodump", "version", toolVersion)
+
+	if !d.config.CheckServerVersion {
+		return
+	}
+
+	serverVersion, err := d.serverVersion(ctx)
+	if err != nil {
+		d.logger.Warn("Could not determine MongoDB server version", "error", err)
+		return
+	}
+	if reason := toolIncompatibility(toolVersion, serverVersion); reason != "" {
+		d.logger.Warn("mongodump may not support this MongoDB server",
+			"mongodump_version", toolVersion,
+			"server_version", serverVersion,
+			"reason", reason)
+		return
+	}
+	d.logger.Info("mongodump supports the MongoDB server",
+		"mongodump_version", toolVersion,
+		"server_version", serverVersion)
+}
+
+// serverVersion returns the server's version from the buildInfo command
+func (d *Dumper) serverVersion(ctx context.Context) (string, error) {
+	checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var info struct {
+		Version string `bson:"version"`
+	}
+	err := withMongoClient(checkCtx, &d.mongoDump.config, func(client *mongo.Client) error {
+		return client.Database("admin").RunCommand(checkCtx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to run buildInfo: %w", err)
+	}
+	return info.Version, nil
+}
+
+// toolIncompatibility explains why a mongodump version may not work with a server version, or
+// returns "" if it should. Database Tools (100.x) support servers from minToolsServerMajor on;
+// legacy tools must match the server's major version.
+func toolIncompatibility(toolVersion, serverVersion string) string {
+	toolMajor, ok := majorVersion(toolVersion)
+	if !ok {
+		return ""
+	}
+	serverMajor, ok := majorVersion(serverVersion)
+	if !ok {
+		return ""
+	}
+
+	if toolMajor >= databaseToolsMajor {
+		if serverMajor < minToolsServerMajor {
+			return fmt.Sprintf("MongoDB Database Tools don't support servers older than %d.0", minToolsServerMajor)
+		}
+		return ""
+	}
+	if toolMajor != serverMajor {
+		return "legacy mongodump and server major versions differ, install the MongoDB Database Tools"
+	}
+	return ""
+}
+
+// majorVersion parses the major version of a "major.minor.patch" version string
+func majorVersion(version string) (int, bool) {
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	return n, err == nil
+}
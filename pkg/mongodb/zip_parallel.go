@@ -0,0 +1,203 @@
+package mongodb
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// deflatedEntry is a dump file compressed by a worker, waiting to be added to the zip
+type deflatedEntry struct {
+	header *zip.FileHeader
+	data   *os.File // Deflated content in a temp file, so large collections don't fill memory
+	err    error
+}
+
+// compressFileParallel writes the same zip archive as compressFile, but deflates the files
+// with CompressionWorkers workers. Each worker compresses a whole file into a temp file next
+// to target; since zip.Writer is not safe for concurrent use, a single writer then copies the
+// finished entries into the archive as raw, already compressed data.
+func (d *Dumper) compressFileParallel(sourceDir, target string) (err error) {
+	zipFile, err := d.createArchiveFile(target)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file: %w", err)
+	}
+	output := bufio.NewWriterSize(zipFile, d.compressBufferSize())
+	zipWriter := zip.NewWriter(output)
+
+	// The archive is only complete once the central directory is written and flushed to the file
+	defer func() {
+		if closeErr := zipWriter.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to finalize zip archive: %w", closeErr)
+		}
+		if flushErr := output.Flush(); flushErr != nil && err == nil {
+			err = fmt.Errorf("failed to write zip file: %w", flushErr)
+		}
+		if closeErr := zipFile.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close zip file: %w", closeErr)
+		}
+	}()
+
+	// Symlinks need no compression and are handled right away, like compressFile does
+	var files []string
+	err = filepath.Walk(sourceDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if !d.config.StoreSymlinks {
+				d.logger.Warn("Skipping symlink in backup directory", "path", filePath)
+				return nil
+			}
+			return addSymlinkToZip(zipWriter, sourceDir, filePath, info)
+		}
+		files = append(files, filePath)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	jobs := make(chan string)
+	results := make(chan deflatedEntry)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(jobs)
+		for _, filePath := range files {
+			select {
+			case jobs <- filePath:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range d.config.CompressionWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filePath := range jobs {
+				entry := d.deflateFile(sourceDir, filePath, filepath.Dir(target))
+				select {
+				case results <- entry:
+				case <-done:
+					entry.remove()
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Entries are written in completion order; the first error stops the remaining workers
+	for entry := range results {
+		if entry.err != nil {
+			return entry.err
+		}
+		err := entry.writeTo(zipWriter)
+		entry.remove()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deflateFile compresses a dump file into a temp file in tempDir
+func (d *Dumper) deflateFile(sourceDir, filePath, tempDir string) deflatedEntry {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return deflatedEntry{err: fmt.Errorf("failed to stat %s: %w", filePath, err)}
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return deflatedEntry{err: fmt.Errorf("failed to create header for %s: %w", filePath, err)}
+	}
+	relPath, err := filepath.Rel(sourceDir, filePath)
+	if err != nil {
+		return deflatedEntry{err: fmt.Errorf("failed to get relative path for %s: %w", filePath, err)}
+	}
+	header.Name = relPath
+	header.Method = zip.Deflate
+
+	src, err := os.Open(filePath)
+	if err != nil {
+		return deflatedEntry{err: fmt.Errorf("failed to open file %s: %w", filePath, err)}
+	}
+	defer src.Close()
+
+	data, err := os.CreateTemp(tempDir, ".deflate-*")
+	if err != nil {
+		return deflatedEntry{err: fmt.Errorf("failed to create temp file for %s: %w", filePath, err)}
+	}
+	entry := deflatedEntry{header: header, data: data}
+
+	level := d.config.CompressionLevel
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	compressor, err := flate.NewWriter(data, level)
+	if err != nil {
+		entry.remove()
+		return deflatedEntry{err: fmt.Errorf("failed to create compressor: %w", err)}
+	}
+
+	// The zip header needs the CRC-32 and both sizes up front for a raw entry
+	checksum := crc32.NewIEEE()
+	size, err := io.CopyBuffer(io.MultiWriter(compressor, checksum), src, make([]byte, d.compressBufferSize()))
+	if err == nil {
+		err = compressor.Close()
+	}
+	if err != nil {
+		entry.remove()
+		return deflatedEntry{err: fmt.Errorf("failed to compress %s: %w", filePath, err)}
+	}
+	compressedSize, err := data.Seek(0, io.SeekCurrent)
+	if err != nil {
+		entry.remove()
+		return deflatedEntry{err: fmt.Errorf("failed to compress %s: %w", filePath, err)}
+	}
+
+	header.CRC32 = checksum.Sum32()
+	header.UncompressedSize64 = uint64(size)
+	header.CompressedSize64 = uint64(compressedSize)
+	return entry
+}
+
+// writeTo copies the deflated data into the archive as a raw entry
+func (e deflatedEntry) writeTo(zipWriter *zip.Writer) error {
+	writer, err := zipWriter.CreateRaw(e.header)
+	if err != nil {
+		return fmt.Errorf("failed to create zip entry for %s: %w", e.header.Name, err)
+	}
+	if _, err := e.data.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind %s: %w", e.header.Name, err)
+	}
+	if _, err := io.Copy(writer, e.data); err != nil {
+		return fmt.Errorf("failed to write %s to zip: %w", e.header.Name, err)
+	}
+	return nil
+}
+
+// remove deletes the entry's temp file
+func (e deflatedEntry) remove() {
+	if e.data != nil {
+		e.data.Close()
+		os.Remove(e.data.Name())
+	}
+}
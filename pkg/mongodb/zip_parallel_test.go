@@ -0,0 +1,109 @@
+package mongodb
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParallelZipRoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	files := map[string][]byte{
+		"admin/system.version.metadata.json": []byte(`{"indexes":[]}`),
+		"app/empty.bson":                     {},
+	}
+	random := make([]byte, 256*1024) // Incompressible, larger than the copy buffer
+	rand.Read(random)
+	files["app/blobs.bson"] = random
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("app/collection%02d.bson", i)] = bsonDocuments(100 * i)
+	}
+	for name, data := range files {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, workers := range []int{2, 4, 32} {
+		d := newTestDumper(t, DumperConfig{Compression: CompressionZip, CompressionWorkers: workers, CompressBufferSize: 4096}, newFakeStore())
+		archiveDir := t.TempDir()
+		archive := filepath.Join(archiveDir, "backup.zip")
+		if err := d.codec.Compress(srcDir, archive); err != nil {
+			t.Fatalf("%d workers: %v", workers, err)
+		}
+
+		reader, err := zip.OpenReader(archive)
+		if err != nil {
+			t.Fatalf("%d workers: %v", workers, err)
+		}
+		seen := map[string]bool{}
+		for _, file := range reader.File {
+			name := filepath.ToSlash(file.Name)
+			want, ok := files[name]
+			if !ok {
+				t.Errorf("%d workers: unexpected entry %s", workers, name)
+				continue
+			}
+			seen[name] = true
+			if file.CRC32 != crc32.ChecksumIEEE(want) {
+				t.Errorf("%d workers: %s has CRC-32 %08x, want %08x", workers, name, file.CRC32, crc32.ChecksumIEEE(want))
+			}
+			// Reading to the end verifies the entry's data against its CRC-32
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatalf("%d workers: %v", workers, err)
+			}
+			got, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Errorf("%d workers: extracting %s: %v", workers, name, err)
+			} else if !bytes.Equal(got, want) {
+				t.Errorf("%d workers: %s differs from the source file", workers, name)
+			}
+		}
+		reader.Close()
+		if len(seen) != len(files) {
+			t.Errorf("%d workers: archive has %d of %d files", workers, len(seen), len(files))
+		}
+
+		// The deflated temp files are removed once written to the archive
+		entries, err := os.ReadDir(archiveDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".deflate-") {
+				t.Errorf("%d workers: temp file %s left behind", workers, entry.Name())
+			}
+		}
+	}
+}
+
+func BenchmarkCompressParallelZip(b *testing.B) {
+	srcDir, size := writeBenchmarkDump(b)
+	// 1 worker is the serial compressFile, the baseline
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			d := newTestDumper(b, DumperConfig{Compression: CompressionZip, CompressionWorkers: workers}, newFakeStore())
+			archive := filepath.Join(b.TempDir(), "backup.zip")
+			b.SetBytes(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := d.codec.Compress(srcDir, archive); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BackupEvent describes the outcome of a single backup attempt
+type BackupEvent struct {
+	Environment string
+	Database    string
+	S3Key       string // Stored archive, empty if nothing was uploaded
+	SizeBytes   int64
+	Err         error // nil for a successful backup
+	Time        time.Time
+	Duration    time.Duration
+}
+
+// Succeeded reports whether the backup completed without error
+func (e BackupEvent) Succeeded() bool {
+	return e.Err == nil
+}
+
+// Notifier delivers backup events to an external service
+type Notifier interface {
+	Notify(ctx context.Context, event BackupEvent) error
+}
+
+// Multi fires every registered notifier concurrently
+type Multi []Notifier
+
+// Notify sends the event to all notifiers and returns their joined errors
+func (m Multi) Notify(ctx context.Context, event BackupEvent) error {
+	errs := make([]error, len(m))
+	var wg sync.WaitGroup
+	for i, n := range m {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = n.Notify(ctx, event)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// failuresOnly drops events of successful backups
+type failuresOnly struct {
+	Notifier
+}
+
+// FailuresOnly wraps a notifier so it is only called for failed backups
+func FailuresOnly(n Notifier) Notifier {
+	return failuresOnly{n}
+}
+
+// Notify forwards the event if the backup failed
+func (f failuresOnly) Notify(ctx context.Context, event BackupEvent) error {
+	if event.Succeeded() {
+		return nil
+	}
+	return f.Notifier.Notify(ctx, event)
+}
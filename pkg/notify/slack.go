@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single notification request so a hung endpoint can't block backups
+const DefaultTimeout = 10 * time.Second
+
+// SlackNotifier posts backup results to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier for a Slack incoming webhook URL
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Notify posts a formatted message for the event
+func (n *SlackNotifier) Notify(ctx context.Context, event BackupEvent) error {
+	body, err := json.Marshal(map[string]string{"text": slackMessage(event)})
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// The URL is the webhook's secret, keep it out of the error
+		return fmt.Errorf("failed to send Slack notification: %w", redactURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// slackMessage formats the event as Slack mrkdwn text
+func slackMessage(event BackupEvent) string {
+	var b strings.Builder
+	if event.Succeeded() {
+		b.WriteString(":white_check_mark: *MongoDB backup succeeded*\n")
+	} else {
+		b.WriteString(":x: *MongoDB backup failed*\n")
+	}
+
+	environment := event.Environment
+	if environment == "" {
+		environment = "default"
+	}
+	fmt.Fprintf(&b, "*Environment:* %s\n", environment)
+	if event.Database != "" {
+		fmt.Fprintf(&b, "*Database:* %s\n", event.Database)
+	}
+	if event.S3Key != "" {
+		fmt.Fprintf(&b, "*Archive:* %s (%d bytes)\n", event.S3Key, event.SizeBytes)
+	}
+	fmt.Fprintf(&b, "*Time:* %s\n", event.Time.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "*Duration:* %s", event.Duration.Round(time.Second))
+	if event.Err != nil {
+		fmt.Fprintf(&b, "\n*Error:* ```%s```", event.Err)
+	}
+	return b.String()
+}
+
+// redactURL strips the request URL from an HTTP client error
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier posts every backup event as JSON to an arbitrary URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// webhookPayload is the JSON body sent for each event
+type webhookPayload struct {
+	Status      string `json:"status"`
+	Environment string `json:"environment"`
+	Database    string `json:"database"`
+	S3Key       string `json:"s3_key"`
+	SizeBytes   int64  `json:"size_bytes"`
+	DurationMS  int64  `json:"duration_ms"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Notify posts the event, any non-2xx response is an error
+func (n *WebhookNotifier) Notify(ctx context.Context, event BackupEvent) error {
+	payload := webhookPayload{
+		Status:      "success",
+		Environment: event.Environment,
+		Database:    event.Database,
+		S3Key:       event.S3Key,
+		SizeBytes:   event.SizeBytes,
+		DurationMS:  event.Duration.Milliseconds(),
+	}
+	if event.Err != nil {
+		payload.Status = "failure"
+		payload.Error = event.Err.Error()
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// Webhook URLs often embed a token, keep it out of the error
+		return fmt.Errorf("failed to send webhook notification: %w", redactURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}